@@ -58,10 +58,18 @@ func (c *Config) DoneLoadURL(info *stage.Process) string {
 	return url.Join(c.DoneLoadProcessURL, path.Join(info.DestTable, date, info.EventID+shared.ProcessExt))
 }
 
+//TriggerBucketURL returns trigger bucket base URL, trigger bucket can also be specified as URL, i.e. mem://localhost/bucket
+func (c *Config) TriggerBucketURL() string {
+	if strings.Contains(c.TriggerBucket, "://") {
+		return strings.TrimRight(c.TriggerBucket, "/")
+	}
+	return "gs://" + c.TriggerBucket
+}
+
 //BuildTaskURL returns an action url for supplied event ID
 func (c *Config) BuildTaskURL(info *activity.Meta) string {
 	date := time.Now().Format(shared.DateLayout)
-	return fmt.Sprintf("gs://%v%v%v/%v", c.TriggerBucket, c.PostJobPrefix, date, info.JobFilename())
+	return fmt.Sprintf("%v%v%v/%v", c.TriggerBucketURL(), c.PostJobPrefix, date, info.JobFilename())
 }
 
 //Init initialises config
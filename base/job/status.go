@@ -18,11 +18,14 @@ type Info struct {
 	TotalSlotMs         int
 	ReservationName     string
 	TotalBytesProcessed int
+	TotalBytesBilled    int
 	InputFileBytes      int
 	InputFiles          int
 	OutputBytes         int
 	OutputRows          int
 	BadRecords          int
+	CopiedRows          int
+	DroppedRows         int
 	ExecutionTimeMs     int
 	TimeTakenMs         int
 	JobType             string
@@ -58,8 +61,14 @@ func NewInfo(job *bigquery.Job) *Info {
 		info.OutputRows = int(job.Statistics.Load.OutputRows)
 		info.BadRecords = int(job.Statistics.Load.BadRecords)
 	}
+	if copied, ok := CopiedRows(job); ok {
+		info.CopiedRows = int(copied)
+	}
 	info.TotalBytesProcessed = int(job.Statistics.TotalBytesProcessed)
 	info.TotalSlotMs = int(job.Statistics.TotalSlotMs)
+	if job.Statistics.Query != nil {
+		info.TotalBytesBilled = int(job.Statistics.Query.TotalBytesBilled)
+	}
 	if len(job.Statistics.ReservationUsage) > 0 {
 		info.ReservationName = job.Statistics.ReservationUsage[0].Name
 	}
@@ -77,3 +86,24 @@ func NewInfo(job *bigquery.Job) *Info {
 
 	return info
 }
+
+//CopiedRows returns number of rows written by a copy or query job, DML affected rows or destination table rows
+func CopiedRows(job *bigquery.Job) (int64, bool) {
+	if job == nil || job.Statistics == nil {
+		return 0, false
+	}
+	if job.Statistics.Copy != nil {
+		return job.Statistics.Copy.CopiedRows, true
+	}
+	if job.Statistics.Query == nil {
+		return 0, false
+	}
+	query := job.Statistics.Query
+	if query.DmlStats != nil || query.NumDmlAffectedRows > 0 {
+		return query.NumDmlAffectedRows, true
+	}
+	if count := len(query.QueryPlan); count > 0 {
+		return query.QueryPlan[count-1].RecordsWritten, true
+	}
+	return 0, false
+}
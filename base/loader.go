@@ -45,6 +45,7 @@ func (m *Loader) notify(ctx context.Context, currentSnapshot []storage.Object) (
 		if !modTime.Equal(lastModified) {
 			notified = true
 			m.onChange(ctx, m.fs, URL)
+			m.rules.Add(URL, lastModified)
 		}
 	}
 	removed := m.rules.GetMissing(snapshot)
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"github.com/viant/bqtail/dispatch"
 	"github.com/viant/bqtail/dispatch/contract"
+	"io/ioutil"
+	"log"
 	"net/http"
 )
 
@@ -33,3 +35,57 @@ func handleDispatchEvent(ctx context.Context) (*contract.Response, error) {
 	}
 	return response, nil
 }
+
+//BqDispatchJobEvent BigQuery job completion audit log Pub/Sub background cloud function entry point, undecodable events are logged and acknowledged
+func BqDispatchJobEvent(ctx context.Context, message contract.PubSubMessage) error {
+	event, err := contract.NewJobEvent(message.Data)
+	if err != nil {
+		log.Printf("skipping undecodable job event: %v", err)
+		return nil
+	}
+	_, err = handleJobEvents(ctx, []*contract.JobEvent{event})
+	return err
+}
+
+//BqDispatchJobEventHTTP BigQuery job completion event HTTP (i.e. Pub/Sub push subscription) cloud function entry point, undecodable events are logged and acknowledged
+func BqDispatchJobEventHTTP(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := contract.DecodeJobEvents(data)
+	if err != nil {
+		log.Printf("skipping undecodable job event: %v", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	response, err := handleJobEvents(r.Context(), events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.Lock()
+	defer response.UnLock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func handleJobEvents(ctx context.Context, events []*contract.JobEvent) (*contract.Response, error) {
+	service, err := dispatch.Singleton(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := service.DispatchJobEvents(ctx, events)
+	response.Lock()
+	data, _ := json.Marshal(response)
+	response.UnLock()
+	fmt.Printf("%s\n", data)
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+	return response, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/viant/bqtail/auth"
 	"github.com/viant/bqtail/cmd/option"
 	"github.com/viant/bqtail/cmd/rule/build"
+	"github.com/viant/bqtail/cmd/rule/explain"
 	"github.com/viant/bqtail/cmd/rule/validate"
 	"github.com/viant/bqtail/cmd/tail"
 	"github.com/viant/bqtail/shared"
@@ -54,13 +55,16 @@ func RunClient(Version string, args []string) {
 		os.Exit(1)
 	}
 
+	ctx := context.Background()
+	if options.Explain != "" && !options.Validate {
+		explainRule(ctx, options, canBuildRule)
+	}
+
 	srv, err := New(options.ProjectID, options.BaseOperationURL)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	ctx := context.Background()
-
 	if options.RuleURL == "" || canBuildRule {
 		err = srv.Build(ctx, &build.Request{Options: options})
 		if err != nil {
@@ -74,7 +78,6 @@ func RunClient(Version string, args []string) {
 		}
 		os.Exit(0)
 	}
-
 	response, err := srv.Load(ctx, &tail.Request{options})
 	if err != nil {
 		log.Fatal(err)
@@ -86,6 +89,23 @@ func RunClient(Version string, args []string) {
 	os.Exit(0)
 }
 
+//explainRule prints rule action tree with storage only service, BigQuery is emulated
+func explainRule(ctx context.Context, options *option.Options, canBuildRule bool) {
+	srv, err := newStorageService(options.ProjectID, options.BaseOperationURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if options.RuleURL == "" || canBuildRule {
+		if err = srv.Build(ctx, &build.Request{Options: options}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err = srv.Explain(ctx, &explain.Request{Options: options}); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(0)
+}
+
 func setDefaultAuth(authService auth.Service) {
 	auth.DefaultHTTPClientProvider = authService.AuthHTTPClient
 	auth.DefaultProjectProvider = authService.ProjectID
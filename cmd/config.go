@@ -56,6 +56,7 @@ func newConfig(ctx context.Context, projectID, baseOpsURL string) (*tail.Config,
 	cfg.JournalURL = url.Join(baseOpsURL, "journal")
 	cfg.SyncTaskURL = url.Join(operationURL, "tasks")
 	cfg.AsyncTaskURL = url.Join(operationURL, "tasks")
+	cfg.WindowLocker = shared.WindowLockerFile
 	cfg.Ruleset.RulesURL = ruleBaseURL
 	cfg.MaxRetries = 3
 	cfg.Ruleset.CheckInMs = 1
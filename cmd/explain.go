@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/cmd/rule/explain"
+	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/service/bq/emulator"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage"
+	"github.com/viant/bqtail/stage/load"
+	"github.com/viant/bqtail/tail"
+	"github.com/viant/bqtail/tail/batch"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"time"
+)
+
+const (
+	explainEventID   = "explain"
+	explainFormatDOT = "dot"
+	jsonFormat       = "NEWLINE_DELIMITED_JSON"
+	csvFormat        = "CSV"
+)
+
+//Explain prints resolved rule action tree for a sample source URL, BigQuery is emulated with a schema faked from the source
+func (s *service) Explain(ctx context.Context, request *explain.Request) error {
+	request.Init(s.config)
+	if request.RuleURL == "" {
+		return errors.Errorf("ruleURL was empty")
+	}
+	if request.SourceURL == "" {
+		return errors.Errorf("sourceURL was empty")
+	}
+	parent, _ := url.Split(request.RuleURL, file.Scheme)
+	cfg, err := newConfig(ctx, s.config.ProjectID, request.BaseOperationURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create config for explain")
+	}
+	cfg.RulesURL = parent
+	if err = cfg.Init(ctx, s.fs); err != nil {
+		return err
+	}
+	rule := s.explainRule(cfg, request.RuleURL)
+	if rule == nil {
+		return errors.Errorf("failed to lookup rule: %v", request.RuleURL)
+	}
+	if !rule.HasMatch(request.SourceURL) {
+		shared.LogF("source %v does not match rule filter, explaining anyway\n", request.SourceURL)
+	}
+	job, err := s.explainJob(ctx, cfg, rule, request.SourceURL)
+	if err != nil {
+		return err
+	}
+	_, action := job.NewLoadRequest()
+	root := explain.NewNode(action)
+	root.Request = nil
+	root.Source = strings.Join(job.Load.SourceUris, ",")
+	root.Dest = base.EncodeTableReference(job.Load.DestinationTable, false)
+	if request.Explain == explainFormatDOT {
+		fmt.Print(root.DOT())
+		return nil
+	}
+	YAML, err := root.YAML()
+	if err != nil {
+		return errors.Wrap(err, "failed to encode action tree")
+	}
+	fmt.Print(YAML)
+	return nil
+}
+
+func (s *service) explainRule(cfg *tail.Config, ruleURL string) *config.Rule {
+	for _, rule := range cfg.Rules {
+		if url.Path(rule.Info.URL) == url.Path(ruleURL) {
+			return rule
+		}
+	}
+	if len(cfg.Rules) == 1 {
+		return cfg.Rules[0]
+	}
+	return nil
+}
+
+//explainJob builds load job with all post load actions against emulated BigQuery
+func (s *service) explainJob(ctx context.Context, cfg *tail.Config, rule *config.Rule, sourceURL string) (*load.Job, error) {
+	modTime := time.Now()
+	var size int64
+	if object, err := s.fs.Object(ctx, sourceURL); err == nil {
+		modTime = object.ModTime()
+		size = object.Size()
+	}
+	process := stage.NewProcess(explainEventID, stage.NewSource(sourceURL, modTime), rule.Info.URL, rule.Async)
+	process.Source.Size = size
+	var err error
+	if process.DestTable, err = rule.Dest.ExpandTable(rule.Dest.Table, process.Source); err != nil {
+		return nil, errors.Wrapf(err, "failed to expand table :%v", rule.Dest.Table)
+	}
+	process.ProcessURL = cfg.BuildLoadURL(process)
+	process.DoneProcessURL = cfg.DoneLoadURL(process)
+	process.FailedURL = url.Join(cfg.JournalURL, "failed")
+	process.ProjectID = cfg.ProjectID
+	if process.Params, err = rule.Dest.Params(sourceURL); err != nil {
+		return nil, err
+	}
+	var window *batch.Window
+	if rule.Batch != nil {
+		taskURL := cfg.SyncTaskURL
+		if rule.Async {
+			taskURL = cfg.AsyncTaskURL
+		}
+		end := rule.Batch.WindowEndTime(modTime)
+		window = batch.NewWindow(process, end.Add(-rule.Batch.Window.Duration), end, rule.Batch.WindowURL(taskURL, process.DestTable, modTime))
+		window.URIs = []string{sourceURL}
+		window.Source = process.Source
+	}
+	job, err := load.NewJob(rule, process, window, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create load job")
+	}
+	fields := s.explainSchema(ctx, rule, sourceURL)
+	bqService, err := s.explainBigQuery(ctx, cfg, rule, job.DestTable, fields)
+	if err != nil {
+		return nil, err
+	}
+	if err = job.Init(ctx, bqService); err != nil {
+		return nil, errors.Wrapf(err, "failed to build actions")
+	}
+	return job, nil
+}
+
+//explainBigQuery creates emulated BigQuery with destination and template tables using faked schema
+func (s *service) explainBigQuery(ctx context.Context, cfg *tail.Config, rule *config.Rule, destTable string, fields []*bigquery.TableFieldSchema) (bq.Service, error) {
+	bqEmulator := emulator.New(url.Join(shared.InMemoryStorageBaseURL, "explain", fmt.Sprintf("%v", base.Hash(rule.Info.URL+time.Now().String()))), s.fs)
+	bqService, err := bqEmulator.Service(ctx, task.NewRegistry(), cfg.ProjectID, s.fs, cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	tables := []string{destTable}
+	if rule.Dest.Schema.Template != "" {
+		tables = append(tables, rule.Dest.Schema.Template)
+	}
+	if rule.Dest.Transient != nil && rule.Dest.Transient.Template != "" {
+		tables = append(tables, rule.Dest.Transient.Template)
+	}
+	for _, table := range tables {
+		if table == "" {
+			continue
+		}
+		ref, err := base.NewTableReference(table)
+		if err != nil {
+			return nil, err
+		}
+		if ref.ProjectId == "" {
+			ref.ProjectId = cfg.ProjectID
+		}
+		if err = bqService.CreateDatasetIfNotExist(ctx, "", &bigquery.DatasetReference{ProjectId: ref.ProjectId, DatasetId: ref.DatasetId}); err != nil {
+			return nil, errors.Wrapf(err, "failed to create emulated dataset: %v", ref.DatasetId)
+		}
+		if err = bqService.CreateTableIfNotExist(ctx, &bigquery.Table{TableReference: ref, Schema: &bigquery.TableSchema{Fields: fields}}, false); err != nil {
+			return nil, errors.Wrapf(err, "failed to create emulated table: %v", table)
+		}
+	}
+	return bqService, nil
+}
+
+//explainSchema fakes table schema from the first source record, otherwise from unique and split columns
+func (s *service) explainSchema(ctx context.Context, rule *config.Rule, sourceURL string) []*bigquery.TableFieldSchema {
+	if fields := s.sampleSchema(ctx, rule, sourceURL); len(fields) > 0 {
+		return fields
+	}
+	var fields = make([]*bigquery.TableFieldSchema, 0)
+	index := map[string]bool{}
+	addField := func(name, fieldType string) {
+		if name == "" || strings.Contains(name, ".") || index[strings.ToLower(name)] {
+			return
+		}
+		index[strings.ToLower(name)] = true
+		fields = append(fields, &bigquery.TableFieldSchema{Name: name, Type: fieldType, Mode: "NULLABLE"})
+	}
+	for _, column := range rule.Dest.UniqueColumns {
+		addField(column, "STRING")
+	}
+	if split := rule.Dest.Schema.Split; split != nil {
+		addField(split.TimeColumn, "TIMESTAMP")
+		for _, column := range split.ClusterColumns {
+			addField(column, "STRING")
+		}
+	}
+	addField("data", "STRING")
+	return fields
+}
+
+func (s *service) sampleSchema(ctx context.Context, rule *config.Rule, sourceURL string) []*bigquery.TableFieldSchema {
+	reader, err := s.fs.OpenURL(ctx, sourceURL)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return nil
+	}
+	line := scanner.Bytes()
+	format := rule.Dest.SourceFormat
+	if format == "" || format == jsonFormat {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal(line, &record); err == nil {
+			return emulator.InferSchema([]map[string]interface{}{record})
+		}
+	}
+	if !(format == "" || format == csvFormat) || rule.Dest.SkipLeadingRows == 0 {
+		return nil
+	}
+	header, err := csv.NewReader(strings.NewReader(string(line))).Read()
+	if err != nil {
+		return nil
+	}
+	var fields = make([]*bigquery.TableFieldSchema, 0, len(header))
+	for _, name := range header {
+		fields = append(fields, &bigquery.TableFieldSchema{Name: strings.TrimSpace(name), Type: "STRING", Mode: "NULLABLE"})
+	}
+	return fields
+}
@@ -16,6 +16,8 @@ type Options struct {
 
 	Validate bool `short:"V" long:"validate" description:"run validation"`
 
+	Explain string `short:"E" long:"explain" description:"explain rule actions for source URL (-s)" optional:"yes" optional-value:"yaml" choice:"yaml" choice:"dot"`
+
 	Version bool `short:"v" long:"version" description:"bqtail version"`
 
 	ProjectID string `short:"p" long:"project" description:"Google Cloud Project"`
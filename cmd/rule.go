@@ -15,6 +15,12 @@ import (
 
 func (s *service) loadRule(ctx context.Context, URL string) (*config.Rule, error) {
 	data, err := s.fs.DownloadWithURL(ctx, URL)
+	if err == nil {
+		//rule is copied to rules location, thus relative Extends/Include fragments have to be resolved upfront
+		if data, err = config.Resolve(ctx, s.fs, URL, data); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve rule fragments: %v", URL)
+		}
+	}
 	_, name := url.Split(URL, "")
 	ruleURL := url.Join(s.config.RulesURL, name)
 	err = s.fs.Upload(ctx, ruleURL, file.DefaultFileOsMode, bytes.NewReader(data))
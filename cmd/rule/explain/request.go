@@ -0,0 +1,10 @@
+package explain
+
+import (
+	"github.com/viant/bqtail/cmd/option"
+)
+
+//Request represents rule explain request
+type Request struct {
+	*option.Options
+}
@@ -0,0 +1,139 @@
+package explain
+
+import (
+	"fmt"
+	"github.com/viant/bqtail/task"
+	"github.com/viant/toolbox"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"strings"
+)
+
+const (
+	sqlKey    = "SQL"
+	destKey   = "Dest"
+	sourceKey = "Source"
+)
+
+//Node represents resolved action tree node
+type Node struct {
+	Action    string                 `yaml:"Action"`
+	Source    string                 `yaml:"Source,omitempty"`
+	Dest      string                 `yaml:"Dest,omitempty"`
+	SQL       string                 `yaml:"SQL,omitempty"`
+	Request   map[string]interface{} `yaml:"Request,omitempty"`
+	OnSuccess []*Node                `yaml:"OnSuccess,omitempty"`
+	OnFailure []*Node                `yaml:"OnFailure,omitempty"`
+}
+
+//YAML returns action tree as YAML
+func (n *Node) YAML() (string, error) {
+	data, err := yaml.Marshal(n)
+	return string(data), err
+}
+
+//DOT returns action tree as Graphviz DOT digraph
+func (n *Node) DOT() string {
+	builder := &strings.Builder{}
+	builder.WriteString("digraph bqtail {\n")
+	builder.WriteString("  node [shape=box, fontname=\"Courier\"];\n")
+	seq := 0
+	n.writeDOT(builder, &seq)
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+func (n *Node) writeDOT(builder *strings.Builder, seq *int) string {
+	ID := fmt.Sprintf("n%d", *seq)
+	*seq++
+	builder.WriteString(fmt.Sprintf("  %v [label=\"%v\"];\n", ID, n.label()))
+	for _, child := range n.OnSuccess {
+		childID := child.writeDOT(builder, seq)
+		builder.WriteString(fmt.Sprintf("  %v -> %v [label=\"onSuccess\"];\n", ID, childID))
+	}
+	for _, child := range n.OnFailure {
+		childID := child.writeDOT(builder, seq)
+		builder.WriteString(fmt.Sprintf("  %v -> %v [label=\"onFailure\", style=dashed, color=red];\n", ID, childID))
+	}
+	return ID
+}
+
+func (n *Node) label() string {
+	lines := []string{n.Action}
+	if n.Source != "" {
+		lines = append(lines, "source: "+n.Source)
+	}
+	if n.Dest != "" {
+		lines = append(lines, "dest: "+n.Dest)
+	}
+	keys := make([]string, 0, len(n.Request))
+	for key := range n.Request {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%v: %v", key, n.Request[key]))
+	}
+	if n.SQL != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(n.SQL, "\n")...)
+	}
+	for i := range lines {
+		lines[i] = escapeDOT(lines[i])
+	}
+	return strings.Join(lines, "\\l") + "\\l"
+}
+
+//normalizeSQL removes trailing spaces, so that multi line SQL can be rendered as YAML literal block
+func normalizeSQL(SQL string) string {
+	lines := strings.Split(strings.TrimSpace(SQL), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeDOT(text string) string {
+	text = strings.Replace(text, "\\", "\\\\", -1)
+	text = strings.Replace(text, "\"", "\\\"", -1)
+	return strings.Replace(text, "\t", "  ", -1)
+}
+
+//NewNode creates an action tree node
+func NewNode(action *task.Action) *Node {
+	result := &Node{Action: action.Action}
+	request := map[string]interface{}{}
+	toolbox.CopyMap(action.Request, request, toolbox.OmitEmptyMapWriter)
+	if SQL, ok := request[sqlKey]; ok {
+		result.SQL = normalizeSQL(toolbox.AsString(SQL))
+		delete(request, sqlKey)
+	}
+	if dest, ok := request[destKey]; ok {
+		result.Dest = toolbox.AsString(dest)
+		delete(request, destKey)
+	}
+	if source, ok := request[sourceKey]; ok {
+		result.Source = toolbox.AsString(source)
+		delete(request, sourceKey)
+	}
+	if len(request) > 0 {
+		result.Request = request
+	}
+	if action.Actions == nil {
+		return result
+	}
+	result.OnSuccess = newNodes(action.OnSuccess)
+	result.OnFailure = newNodes(action.OnFailure)
+	return result
+}
+
+func newNodes(actions []*task.Action) []*Node {
+	if len(actions) == 0 {
+		return nil
+	}
+	var result = make([]*Node, 0, len(actions))
+	for _, action := range actions {
+		result = append(result, NewNode(action))
+	}
+	return result
+}
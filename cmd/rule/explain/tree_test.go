@@ -0,0 +1,94 @@
+package explain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/task"
+	"testing"
+)
+
+func TestNode(t *testing.T) {
+	var useCases = []struct {
+		description string
+		action      *task.Action
+		expectNode  *Node
+		expectYAML  string
+		expectDOT   string
+	}{
+		{
+			description: "single action",
+			action: &task.Action{
+				Action:  "delete",
+				Request: map[string]interface{}{"URLs": "gs://bucket/data/events.json", "Empty": ""},
+			},
+			expectNode: &Node{Action: "delete", Request: map[string]interface{}{"URLs": "gs://bucket/data/events.json"}},
+			expectYAML: `Action: delete
+Request:
+  URLs: gs://bucket/data/events.json
+`,
+			expectDOT: `digraph bqtail {
+  node [shape=box, fontname="Courier"];
+  n0 [label="delete\lURLs: gs://bucket/data/events.json\l"];
+}
+`,
+		},
+		{
+			description: "on success and on failure actions with multi line SQL",
+			action: &task.Action{
+				Action:  "copy",
+				Request: map[string]interface{}{"Source": "p:temp.events_1", "Dest": "p:ds.events"},
+				Actions: task.NewActions([]*task.Action{
+					{
+						Action:  "query",
+						Request: map[string]interface{}{"SQL": "SELECT id, \"a\\b\" AS name  \nFROM\tevents \n", "Dest": "p:ds.summary"},
+					},
+				}, []*task.Action{
+					{
+						Action:  "notify",
+						Request: map[string]interface{}{"Channels": "#e2e"},
+					},
+				}),
+			},
+			expectNode: &Node{
+				Action: "copy",
+				Source: "p:temp.events_1",
+				Dest:   "p:ds.events",
+				OnSuccess: []*Node{
+					{Action: "query", Dest: "p:ds.summary", SQL: "SELECT id, \"a\\b\" AS name\nFROM\tevents"},
+				},
+				OnFailure: []*Node{
+					{Action: "notify", Request: map[string]interface{}{"Channels": "#e2e"}},
+				},
+			},
+			expectYAML: `Action: copy
+Source: p:temp.events_1
+Dest: p:ds.events
+OnSuccess:
+- Action: query
+  Dest: p:ds.summary
+  SQL: "SELECT id, \"a\\b\" AS name\nFROM\tevents"
+OnFailure:
+- Action: notify
+  Request:
+    Channels: '#e2e'
+`,
+			expectDOT: `digraph bqtail {
+  node [shape=box, fontname="Courier"];
+  n0 [label="copy\lsource: p:temp.events_1\ldest: p:ds.events\l"];
+  n1 [label="query\ldest: p:ds.summary\l\lSELECT id, \"a\\b\" AS name\lFROM  events\l"];
+  n0 -> n1 [label="onSuccess"];
+  n2 [label="notify\lChannels: #e2e\l"];
+  n0 -> n2 [label="onFailure", style=dashed, color=red];
+}
+`,
+		},
+	}
+
+	for _, useCase := range useCases {
+		node := NewNode(useCase.action)
+		assert.EqualValues(t, useCase.expectNode, node, useCase.description)
+		YAML, err := node.YAML()
+		assert.Nil(t, err, useCase.description)
+		assert.EqualValues(t, useCase.expectYAML, YAML, useCase.description)
+		assert.EqualValues(t, useCase.expectDOT, node.DOT(), useCase.description)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/viant/afs"
 	"github.com/viant/bqtail/cmd/rule/build"
+	"github.com/viant/bqtail/cmd/rule/explain"
 	"github.com/viant/bqtail/cmd/rule/validate"
 	ctail "github.com/viant/bqtail/cmd/tail"
 	"github.com/viant/bqtail/tail"
@@ -19,6 +20,8 @@ type Service interface {
 	Build(ctx context.Context, request *build.Request) error
 	//Validate check rule either build or with specified URL
 	Validate(ctx context.Context, request *validate.Request) error
+	//Explain print rule action tree resolved for a sample source URL
+	Explain(ctx context.Context, request *explain.Request) error
 	//Load start load process for specified source and rule
 	Load(ctx context.Context, request *ctail.Request) (*ctail.Response, error)
 	//Stop stop service
@@ -70,3 +73,12 @@ func New(projectID string, baseOpsURL string) (Service, error) {
 		stopChan:     make(chan bool, 2),
 	}, nil
 }
+
+//newStorageService creates a service without cloud clients, it supports rule build and explain only
+func newStorageService(projectID string, baseOpsURL string) (*service, error) {
+	cfg, err := newConfig(context.Background(), projectID, baseOpsURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create config")
+	}
+	return &service{config: cfg, fs: afs.New()}, nil
+}
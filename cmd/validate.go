@@ -22,7 +22,11 @@ func (s *service) Validate(ctx context.Context, request *validate.Request) error
 	cfg.RulesURL = parent
 	err = cfg.Init(ctx, s.fs)
 	if err == nil && len(cfg.Rules) > 0 {
-		s.reportRule(cfg.Rules[0])
+		rule := cfg.Rule(ctx, request.RuleURL)
+		if rule == nil {
+			rule = cfg.Rules[0]
+		}
+		s.reportRule(rule)
 		shared.LogLn("Rule is VALID\n")
 	}
 
@@ -21,6 +21,8 @@ type Config struct {
 	TimeToLiveInMin   int
 	MaxConcurrentSQL  int
 	MaxConcurrentLoad int
+	//Priorities priority classes with per class concurrency limits and weights, post-job work is admitted by weighted fair queuing across classes
+	Priorities config.Priorities `json:",omitempty"`
 }
 
 //TimeToLive returns time to live
@@ -40,6 +42,10 @@ func (c *Config) Init(ctx context.Context, fs afs.Service) error {
 	if c.TimeToLiveInMin == 0 {
 		c.TimeToLiveInMin = 1
 	}
+	c.Priorities.Init()
+	if err = c.Priorities.Validate(); err != nil {
+		return err
+	}
 	return c.Ruleset.Init(ctx, fs, c.ProjectID)
 }
 
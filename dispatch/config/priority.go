@@ -0,0 +1,69 @@
+package config
+
+import "fmt"
+
+//DefaultPriority priority class of jobs without rule priority
+const DefaultPriority = "default"
+
+//Priority represents dispatcher priority class
+type Priority struct {
+	//Name class name matching tail rule Priority, default class applies to rules without priority
+	Name string
+	//Weight relative share of post-job work admitted when classes compete (1 by default)
+	Weight int `json:",omitempty"`
+	//MaxConcurrentSQL max concurrent query jobs for the class
+	MaxConcurrentSQL int `json:",omitempty"`
+	//MaxConcurrentLoad max concurrent load jobs for the class
+	MaxConcurrentLoad int `json:",omitempty"`
+}
+
+//Priorities represents priority classes
+type Priorities []*Priority
+
+//Init initialises priority classes
+func (p Priorities) Init() {
+	for _, priority := range p {
+		if priority.Weight == 0 {
+			priority.Weight = 1
+		}
+	}
+}
+
+//Validate checks if priority classes are valid
+func (p Priorities) Validate() error {
+	var names = make(map[string]bool)
+	for _, priority := range p {
+		if priority.Name == "" {
+			return fmt.Errorf("priority.Name was empty")
+		}
+		if names[priority.Name] {
+			return fmt.Errorf("duplicate priority: %v", priority.Name)
+		}
+		names[priority.Name] = true
+		if priority.Weight < 0 || priority.MaxConcurrentSQL < 0 || priority.MaxConcurrentLoad < 0 {
+			return fmt.Errorf("invalid priority %v: weight and max concurrent limits can not be negative", priority.Name)
+		}
+	}
+	return nil
+}
+
+//Get returns priority class, or nil if class was not defined
+func (p Priorities) Get(name string) *Priority {
+	if name == "" {
+		name = DefaultPriority
+	}
+	for _, priority := range p {
+		if priority.Name == name {
+			return priority
+		}
+	}
+	return nil
+}
+
+//Weight returns class weight, undefined class has weight 1
+func (p Priorities) Weight(name string) int {
+	if priority := p.Get(name); priority != nil && priority.Weight > 0 {
+		return priority.Weight
+	}
+	return 1
+}
@@ -0,0 +1,25 @@
+package contract
+
+//Class represents priority class performance
+type Class struct {
+	//Active running and pending jobs, including jobs admitted by the current dispatch
+	Active     *Metrics `json:",omitempty"`
+	Dispatched *Metrics `json:",omitempty"`
+	Throttled  *Metrics `json:",omitempty"`
+}
+
+//Merge merges class metrics
+func (c *Class) Merge(class *Class) {
+	c.Active.Merge(class.Active)
+	c.Dispatched.Merge(class.Dispatched)
+	c.Throttled.Merge(class.Throttled)
+}
+
+//NewClass creates priority class performance
+func NewClass() *Class {
+	return &Class{
+		Active:     &Metrics{},
+		Dispatched: &Metrics{},
+		Throttled:  &Metrics{},
+	}
+}
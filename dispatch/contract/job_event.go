@@ -0,0 +1,160 @@
+package contract
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/shared"
+	"strings"
+)
+
+//JobEvent represents BigQuery job completion notification
+type JobEvent struct {
+	ProjectID string
+	Region    string `json:",omitempty"`
+	JobID     string
+	State     string
+	Error     string `json:",omitempty"`
+}
+
+//IsDone returns true if job completed
+func (e *JobEvent) IsDone() bool {
+	return strings.ToUpper(e.State) == shared.DoneState
+}
+
+//PubSubMessage represents Pub/Sub background cloud function event
+type PubSubMessage struct {
+	Data []byte `json:"data"`
+}
+
+//pushEnvelope represents Pub/Sub push subscription request body
+type pushEnvelope struct {
+	Message *struct {
+		Data      string `json:"data"`
+		MessageID string `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+//auditLogEntry represents BigQuery audit log entry exported to Pub/Sub, both AuditData (jobCompletedEvent) and BigQueryAuditMetadata (jobChange) formats are supported
+type auditLogEntry struct {
+	ProtoPayload *struct {
+		ServiceData *struct {
+			JobCompletedEvent *struct {
+				Job *struct {
+					JobName *struct {
+						ProjectID string `json:"projectId"`
+						JobID     string `json:"jobId"`
+						Location  string `json:"location"`
+					} `json:"jobName"`
+					JobStatus *struct {
+						State string `json:"state"`
+						Error *struct {
+							Message string `json:"message"`
+						} `json:"error"`
+					} `json:"jobStatus"`
+				} `json:"job"`
+			} `json:"jobCompletedEvent"`
+		} `json:"serviceData"`
+		Metadata *struct {
+			JobChange *struct {
+				After string `json:"after"`
+				Job   *struct {
+					JobName   string `json:"jobName"`
+					JobStatus *struct {
+						JobState    string `json:"jobState"`
+						ErrorResult *struct {
+							Message string `json:"message"`
+						} `json:"errorResult"`
+					} `json:"jobStatus"`
+				} `json:"job"`
+			} `json:"jobChange"`
+		} `json:"metadata"`
+	} `json:"protoPayload"`
+	Resource *struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"resource"`
+}
+
+func (e *auditLogEntry) jobEvent() *JobEvent {
+	if e.ProtoPayload == nil {
+		return nil
+	}
+	if data := e.ProtoPayload.ServiceData; data != nil && data.JobCompletedEvent != nil && data.JobCompletedEvent.Job != nil {
+		job := data.JobCompletedEvent.Job
+		if job.JobName == nil {
+			return nil
+		}
+		result := &JobEvent{ProjectID: job.JobName.ProjectID, JobID: job.JobName.JobID, Region: job.JobName.Location, State: shared.DoneState}
+		if job.JobStatus != nil {
+			if job.JobStatus.State != "" {
+				result.State = job.JobStatus.State
+			}
+			if job.JobStatus.Error != nil {
+				result.Error = job.JobStatus.Error.Message
+			}
+		}
+		return result
+	}
+	if meta := e.ProtoPayload.Metadata; meta != nil && meta.JobChange != nil && meta.JobChange.Job != nil {
+		job := meta.JobChange.Job
+		//jobName format: projects/$projectID/jobs/$jobID
+		elements := strings.Split(job.JobName, "/")
+		if len(elements) != 4 {
+			return nil
+		}
+		result := &JobEvent{ProjectID: elements[1], JobID: elements[3], State: meta.JobChange.After}
+		if job.JobStatus != nil {
+			if job.JobStatus.JobState != "" {
+				result.State = job.JobStatus.JobState
+			}
+			if job.JobStatus.ErrorResult != nil {
+				result.Error = job.JobStatus.ErrorResult.Message
+			}
+		}
+		if e.Resource != nil {
+			result.Region = e.Resource.Labels["location"]
+		}
+		return result
+	}
+	return nil
+}
+
+//NewJobEvent creates a job event from BigQuery audit log entry or JobEvent JSON
+func NewJobEvent(data []byte) (*JobEvent, error) {
+	entry := &auditLogEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode job event: %s", data)
+	}
+	if result := entry.jobEvent(); result != nil {
+		return result, nil
+	}
+	result := &JobEvent{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode job event: %s", data)
+	}
+	if result.JobID == "" {
+		return nil, errors.Errorf("job ID was empty: %s", data)
+	}
+	return result, nil
+}
+
+//DecodeJobEvents decodes Pub/Sub push request, audit log entry, JobEvent or JobEvent list
+func DecodeJobEvents(data []byte) ([]*JobEvent, error) {
+	data = []byte(strings.TrimSpace(string(data)))
+	if strings.HasPrefix(string(data), "[") {
+		var result = make([]*JobEvent, 0)
+		return result, json.Unmarshal(data, &result)
+	}
+	envelope := &pushEnvelope{}
+	if err := json.Unmarshal(data, envelope); err == nil && envelope.Message != nil {
+		if data, err = base64.StdEncoding.DecodeString(envelope.Message.Data); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode push message %v data", envelope.Message.MessageID)
+		}
+	}
+	event, err := NewJobEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	return []*JobEvent{event}, nil
+}
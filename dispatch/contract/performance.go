@@ -2,6 +2,7 @@ package contract
 
 import (
 	"fmt"
+	"github.com/viant/bqtail/dispatch/config"
 	"github.com/viant/bqtail/shared"
 	"github.com/viant/bqtail/stage/activity"
 	"strings"
@@ -21,6 +22,8 @@ type Performance struct {
 	Dispatched *Metrics `json:",omitempty"`
 	Throttled  *Metrics `json:",omitempty"`
 	NoFound    int      `json:",omitempty"`
+	//Classes performance by priority class
+	Classes map[string]*Class `json:",omitempty"`
 }
 
 // Merge merges performance
@@ -49,6 +52,25 @@ func (p *Performance) Merge(perf *Performance) {
 	p.Count += perf.Count
 	p.Dispatched.Merge(perf.Dispatched)
 	p.Throttled.Merge(perf.Throttled)
+	for name, class := range perf.Classes {
+		p.Class(name).Merge(class)
+	}
+}
+
+// Class returns priority class performance, job without priority uses default class
+func (p *Performance) Class(name string) *Class {
+	if name == "" {
+		name = config.DefaultPriority
+	}
+	if p.Classes == nil {
+		p.Classes = make(map[string]*Class)
+	}
+	class, ok := p.Classes[name]
+	if !ok {
+		class = NewClass()
+		p.Classes[name] = class
+	}
+	return class
 }
 
 // ActiveQueryCount returns active query count
@@ -74,7 +96,8 @@ func (p *Performance) AddEvent(state string, jobID string) {
 	atomic.AddUint32(&p.Count, 1)
 	metrics := p.Metric(state)
 	if metrics != nil {
-		metrics.Update(jobID)
+		stageInfo := metrics.Update(jobID)
+		p.Class(stageInfo.Priority).Active.Add(stageInfo, 1)
 	}
 }
 
@@ -92,13 +115,39 @@ func (p *Performance) Metric(state string) *Metrics {
 
 // AddDispatch add dispatched metrics
 func (p *Performance) AddDispatch(jobID string) *activity.Meta {
-	return p.Dispatched.Update(jobID)
+	stageInfo := p.Dispatched.Update(jobID)
+	p.Class(stageInfo.Priority).Dispatched.Add(stageInfo, 1)
+	return stageInfo
+}
+
+// AddAdmitted adds dispatched job post-job work to running metrics, so that subsequent admissions account for it
+func (p *Performance) AddAdmitted(stageInfo *activity.Meta) {
+	p.Running.Add(stageInfo, 1)
+	p.Class(stageInfo.Priority).Active.Add(stageInfo, 1)
+}
+
+// MergeActive merges running, pending and class active metrics
+func (p *Performance) MergeActive(perf *Performance) {
+	if perf.Running != nil {
+		p.Running.Merge(perf.Running)
+	}
+	if perf.Pending != nil {
+		p.Pending.Merge(perf.Pending)
+	}
+	for name, class := range perf.Classes {
+		if class != nil && class.Active != nil {
+			p.Class(name).Active.Merge(class.Active)
+		}
+	}
 }
 
 // AddThrottled add throttled metrics
 func (p *Performance) AddThrottled(jobID string) {
 	stageInfo := p.Throttled.Update(jobID)
 	p.Dispatched.Add(stageInfo, -1)
+	class := p.Class(stageInfo.Priority)
+	class.Throttled.Add(stageInfo, 1)
+	class.Dispatched.Add(stageInfo, -1)
 }
 
 // String return performance string
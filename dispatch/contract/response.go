@@ -19,6 +19,11 @@ type Response struct {
 	MaxPending  *time.Time
 	Performance ProjectPerformance
 	mux         *sync.Mutex
+
+	//JobEvents received job completion events count
+	JobEvents int `json:",omitempty"`
+	//UnmatchedEvents job completion events without pending post-job task
+	UnmatchedEvents int `json:",omitempty"`
 }
 
 func (r *Response) Lock() {
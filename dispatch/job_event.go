@@ -0,0 +1,80 @@
+package dispatch
+
+import (
+	"context"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/dispatch/contract"
+	"github.com/viant/bqtail/dispatch/project"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage/activity"
+)
+
+//DispatchJobEvents dispatches post-job tasks matching BigQuery job completion events
+func (s *service) DispatchJobEvents(ctx context.Context, jobEvents []*contract.JobEvent) *contract.Response {
+	response := contract.NewResponse()
+	defer response.SetTimeTaken(response.Started)
+	if err := s.dispatchJobEvents(ctx, jobEvents, response); err != nil {
+		response.SetIfError(err)
+	}
+	return response
+}
+
+//dispatchJobEvents locates pending post-job tasks of done job events by task URL derived from job ID, tasks are admitted with the same concurrency limits as polling,
+//throttled or unmatched tasks, including tasks scheduled with legacy task filename, are left for dispatch polling cycle
+func (s *service) dispatchJobEvents(ctx context.Context, jobEvents []*contract.JobEvent, response *contract.Response) error {
+	response.JobEvents = len(jobEvents)
+	var doneEvents = make([]*contract.JobEvent, 0)
+	var unique = make(map[string]bool)
+	for _, event := range jobEvents {
+		if event == nil || !event.IsDone() || unique[event.JobID] {
+			continue
+		}
+		unique[event.JobID] = true
+		doneEvents = append(doneEvents, event)
+	}
+	if len(doneEvents) == 0 {
+		return nil
+	}
+	var registry = make(map[string]*project.Events)
+	var queues = make(map[string]*fairQueue)
+	matched := 0
+	for _, event := range doneEvents {
+		URL := url.Join(s.config.AsyncTaskURL, activity.TaskFilename(event.ProjectID, event.Region, event.JobID))
+		exists, err := s.fs.Exists(ctx, URL, option.NewObjectKind(true))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		projectRegion := event.ProjectID + ":" + event.Region
+		events, ok := registry[projectRegion]
+		if !ok {
+			events = project.New(projectRegion)
+			registry[projectRegion] = events
+			queues[projectRegion] = newFairQueue()
+		}
+		matched++
+		stageInfo := events.AddDispatch(event.JobID)
+		queues[projectRegion].push(stageInfo.Priority, s.config.Priorities.Weight(stageInfo.Priority), &candidate{job: contract.NewJob(event.JobID, URL, event.State), stageInfo: stageInfo})
+	}
+	response.UnmatchedEvents = len(doneEvents) - matched
+	if matched == 0 {
+		return nil
+	}
+	performance, err := s.loadPerformance(ctx)
+	if err != nil {
+		shared.LogF("failed to load performance: %v\n", err)
+	}
+	for projectRegion, events := range registry {
+		if perf, ok := performance[events.ProjectID]; ok {
+			events.MergeActive(perf)
+		}
+		if err = s.admit(ctx, queues[projectRegion], events, response); err != nil && !IsNotFound(err) {
+			response.AddError(err)
+		}
+		response.Merge(events.Performance)
+	}
+	return nil
+}
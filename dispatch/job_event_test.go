@@ -0,0 +1,134 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/dispatch/contract"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage/activity"
+	tcontract "github.com/viant/bqtail/tail/contract"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_DispatchJobEvents(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := testBaseURL + "/events"
+	env, ok := newTestEnv(t, baseURL, "mem://events", "/data/events/")
+	if !ok {
+		return
+	}
+	dataURL := "mem://localhost/data/events/events1.json"
+	assert.Nil(t, fs.Upload(ctx, dataURL, file.DefaultFileOsMode, strings.NewReader("{\"id\":1,\"name\":\"a\"}\n")))
+	tailResponse := env.tailService.Tail(ctx, &tcontract.Request{EventID: "301", SourceURL: dataURL, Started: time.Now()})
+	if !assert.Equal(t, shared.StatusOK, tailResponse.Status, tailResponse.Error) {
+		return
+	}
+	env.bqEmulator.Wait()
+	taskURLs, err := listTaskURLs(ctx, fs, env.tailConfig.AsyncTaskURL)
+	if !assert.Nil(t, err) || !assert.Equal(t, 1, len(taskURLs)) {
+		return
+	}
+	jobID := JobID(env.tailConfig.AsyncTaskURL, taskURLs[0])
+	assert.Equal(t, url.Join(env.tailConfig.AsyncTaskURL, activity.TaskFilename("myproject", "US", jobID)), taskURLs[0])
+	legacyURL := url.Join(env.tailConfig.AsyncTaskURL, "proj:myproject:US/mydataset.events--399_00001_load--dispatch")
+	assert.Nil(t, fs.Copy(ctx, taskURLs[0], legacyURL))
+	legacyJobID := JobID(env.tailConfig.AsyncTaskURL, legacyURL)
+
+	useCases := []struct {
+		description     string
+		payload         string
+		expectJobs      int
+		expectUnmatched int
+	}{
+		{
+			description: "running job event",
+			payload:     fmt.Sprintf(`{"protoPayload":{"metadata":{"jobChange":{"after":"RUNNING","job":{"jobName":"projects/myproject/jobs/%v"}}}}}`, jobID),
+		},
+		{
+			description:     "unknown job event",
+			payload:         `{"protoPayload":{"serviceData":{"jobCompletedEvent":{"job":{"jobName":{"projectId":"myproject","jobId":"unknown"},"jobStatus":{"state":"DONE"}}}}}}`,
+			expectUnmatched: 1,
+		},
+		{
+			description:     "legacy task filename left for polling",
+			payload:         fmt.Sprintf(`{"ProjectID":"myproject","Region":"US","JobID":"%v","State":"DONE"}`, legacyJobID),
+			expectUnmatched: 1,
+		},
+		{
+			description: "push audit log job completed event",
+			payload: fmt.Sprintf(`{"message":{"data":"%v","messageId":"1"},"subscription":"projects/myproject/subscriptions/bqjobs"}`,
+				base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"protoPayload":{"serviceData":{"jobCompletedEvent":{"job":{"jobName":{"projectId":"myproject","jobId":"%v","location":"US"},"jobStatus":{"state":"DONE"}}}}}}`, jobID)))),
+			expectJobs: 1,
+		},
+	}
+
+	for _, useCase := range useCases {
+		events, err := contract.DecodeJobEvents([]byte(useCase.payload))
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		response := env.dispatchService.DispatchJobEvents(ctx, events)
+		assert.Equal(t, shared.StatusOK, response.Status, useCase.description+" "+response.Error)
+		assert.Equal(t, useCase.expectJobs, len(response.Jobs.Jobs), useCase.description)
+		assert.Equal(t, useCase.expectUnmatched, response.UnmatchedEvents, useCase.description)
+	}
+	postURLs, err := listTaskURLs(ctx, fs, env.tailConfig.TriggerBucketURL()+shared.PostJobPrefix)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, len(postURLs))
+	}
+	taskURLs, err = listTaskURLs(ctx, fs, env.tailConfig.AsyncTaskURL)
+	if assert.Nil(t, err) {
+		assert.EqualValues(t, []string{legacyURL}, taskURLs)
+	}
+}
+
+func TestService_DispatchJobEvents_Claimed(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := testBaseURL + "/claimed"
+	env, ok := newTestEnv(t, baseURL, "mem://claimed", "/data/claimed/")
+	if !ok {
+		return
+	}
+	dataURL := "mem://localhost/data/claimed/events1.json"
+	assert.Nil(t, fs.Upload(ctx, dataURL, file.DefaultFileOsMode, strings.NewReader("{\"id\":1,\"name\":\"a\"}\n")))
+	tailResponse := env.tailService.Tail(ctx, &tcontract.Request{EventID: "302", SourceURL: dataURL, Started: time.Now()})
+	if !assert.Equal(t, shared.StatusOK, tailResponse.Status, tailResponse.Error) {
+		return
+	}
+	env.bqEmulator.Wait()
+	taskURLs, err := listTaskURLs(ctx, fs, env.tailConfig.AsyncTaskURL)
+	if !assert.Nil(t, err) || !assert.Equal(t, 1, len(taskURLs)) {
+		return
+	}
+	jobID := JobID(env.tailConfig.AsyncTaskURL, taskURLs[0])
+	event := &contract.JobEvent{ProjectID: "myproject", Region: "US", JobID: jobID, State: shared.DoneState}
+
+	//the other dispatcher has already claimed the task
+	srv := env.dispatchService.(*service)
+	info := activity.Parse(jobID)
+	info.ProjectID = "myproject"
+	info.Region = "US"
+	claimedURL := srv.config.BuildTaskURL(info) + shared.JSONExt
+	assert.Nil(t, fs.Upload(ctx, claimedURL, file.DefaultFileOsMode, strings.NewReader("claimed")))
+
+	response := env.dispatchService.DispatchJobEvents(ctx, []*contract.JobEvent{event})
+	assert.Equal(t, shared.StatusOK, response.Status, response.Error)
+	assert.Equal(t, 0, len(response.Jobs.Jobs))
+	data, err := fs.DownloadWithURL(ctx, claimedURL)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "claimed", string(data))
+	}
+	taskURLs, err = listTaskURLs(ctx, fs, env.tailConfig.AsyncTaskURL)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, len(taskURLs))
+	}
+}
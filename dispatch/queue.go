@@ -0,0 +1,66 @@
+package dispatch
+
+import (
+	"github.com/viant/bqtail/dispatch/contract"
+	"github.com/viant/bqtail/stage/activity"
+)
+
+//candidate represents done job waiting for post-job work admission
+type candidate struct {
+	job       *contract.Job
+	stageInfo *activity.Meta
+}
+
+//classQueue represents priority class candidates in arrival order
+type classQueue struct {
+	name   string
+	weight int
+	served int
+	items  []*candidate
+}
+
+//fairQueue represents weighted fair queue across priority classes
+type fairQueue struct {
+	classes []*classQueue
+	byName  map[string]*classQueue
+}
+
+func (q *fairQueue) push(class string, weight int, item *candidate) {
+	queue, ok := q.byName[class]
+	if !ok {
+		queue = &classQueue{name: class, weight: weight}
+		q.byName[class] = queue
+		q.classes = append(q.classes, queue)
+	}
+	queue.items = append(queue.items, item)
+}
+
+//pop returns the next candidate from the class with the smallest virtual finish time (served+1)/weight,
+//ties go to the class with higher weight, then to the class queued first
+func (q *fairQueue) pop() (*candidate, bool) {
+	var next *classQueue
+	for _, queue := range q.classes {
+		if len(queue.items) == 0 {
+			continue
+		}
+		if next == nil {
+			next = queue
+			continue
+		}
+		left, right := (queue.served+1)*next.weight, (next.served+1)*queue.weight
+		if left < right || (left == right && queue.weight > next.weight) {
+			next = queue
+		}
+	}
+	if next == nil {
+		return nil, false
+	}
+	item := next.items[0]
+	next.items = next.items[1:]
+	next.served++
+	return item, true
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{byName: make(map[string]*classQueue)}
+}
@@ -0,0 +1,53 @@
+package dispatch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/dispatch/contract"
+	"testing"
+)
+
+func Test_fairQueue(t *testing.T) {
+
+	type item struct {
+		class  string
+		weight int
+		ID     string
+	}
+	var useCases = []struct {
+		description string
+		items       []item
+		expect      []string
+	}{
+		{
+			description: "single class keeps arrival order",
+			items:       []item{{"", 1, "a1"}, {"", 1, "a2"}},
+			expect:      []string{"a1", "a2"},
+		},
+		{
+			description: "equal weights interleave",
+			items:       []item{{"bulk", 1, "b1"}, {"bulk", 1, "b2"}, {"bulk", 1, "b3"}, {"revenue", 1, "r1"}},
+			expect:      []string{"b1", "r1", "b2", "b3"},
+		},
+		{
+			description: "higher weight gets proportional share",
+			items:       []item{{"bulk", 1, "b1"}, {"bulk", 1, "b2"}, {"revenue", 3, "r1"}, {"revenue", 3, "r2"}, {"revenue", 3, "r3"}, {"revenue", 3, "r4"}},
+			expect:      []string{"r1", "r2", "r3", "b1", "r4", "b2"},
+		},
+	}
+
+	for _, useCase := range useCases {
+		queue := newFairQueue()
+		for _, item := range useCase.items {
+			queue.push(item.class, item.weight, &candidate{job: &contract.Job{ID: item.ID}})
+		}
+		var actual = make([]string, 0)
+		for {
+			next, ok := queue.pop()
+			if !ok {
+				break
+			}
+			actual = append(actual, next.job.ID)
+		}
+		assert.EqualValues(t, useCase.expect, actual, useCase.description)
+	}
+}
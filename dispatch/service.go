@@ -10,6 +10,7 @@ import (
 	"github.com/viant/afs/option"
 	astorage "github.com/viant/afs/storage"
 	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
 	"github.com/viant/bqtail/dispatch/contract"
 	"github.com/viant/bqtail/dispatch/project"
 	"github.com/viant/bqtail/service/batch"
@@ -37,6 +38,8 @@ var maxBqJobListLoopback = 6 * time.Hour
 // Service represents event service
 type Service interface {
 	Dispatch(ctx context.Context) *contract.Response
+	//DispatchJobEvents dispatches post-job tasks matching BigQuery job completion events
+	DispatchJobEvents(ctx context.Context, events []*contract.JobEvent) *contract.Response
 	Config() *Config
 }
 
@@ -46,6 +49,7 @@ type service struct {
 	config    *Config
 	fs        afs.Service
 	bq        bq.Service
+	bigQuery  *bigquery.Service
 }
 
 // Config returns service config
@@ -65,9 +69,11 @@ func (s *service) Init(ctx context.Context) error {
 	}
 	slackService := slack.New(s.config.Region, s.config.ProjectID, s.fs, secret.New(), s.config.SlackCredentials)
 	slack.InitRegistry(s.Registry, slackService)
-	bqService, err := bigquery.NewService(ctx)
-	if err != nil {
-		return err
+	bqService := s.bigQuery
+	if bqService == nil {
+		if bqService, err = bigquery.NewService(ctx); err != nil {
+			return err
+		}
 	}
 	s.bq = bq.New(bqService, s.Registry, s.config.ProjectID, s.fs, s.config.Config)
 	bq.InitRegistry(s.Registry, s.bq)
@@ -105,7 +111,7 @@ func (s *service) dispatch(ctx context.Context, response *contract.Response) err
 	for atomic.LoadInt32(&running) == 1 {
 		cycleStartTime := time.Now()
 		waitGroup := &sync.WaitGroup{}
-		registry, err := s.listProjectEvents(ctx)
+		registry, err := s.listProjectEvents(ctx, nil)
 		if err != nil {
 			return err
 		}
@@ -160,7 +166,8 @@ func (s *service) cleanupScheduled(ctx context.Context, schedules project.Schedu
 	}
 }
 
-func (s *service) listProjectEvents(ctx context.Context) (*project.Registry, error) {
+//listProjectEvents lists async tasks, accept filters temp project locations by project region, nil accepts all
+func (s *service) listProjectEvents(ctx context.Context, accept func(projectRegion string) bool) (*project.Registry, error) {
 	registry := project.NewRegistry()
 	events, err := s.fs.List(ctx, s.config.AsyncTaskURL)
 	if err != nil {
@@ -170,6 +177,9 @@ func (s *service) listProjectEvents(ctx context.Context) (*project.Registry, err
 	waitGroup := sync.WaitGroup{}
 	for i, obj := range events {
 		if obj.IsDir() && strings.HasPrefix(obj.Name(), shared.TempProjectPrefix) {
+			if accept != nil && !accept(obj.Name()[len(shared.TempProjectPrefix):]) {
+				continue
+			}
 			waitGroup.Add(1)
 			go func(i int) {
 				defer waitGroup.Done()
@@ -295,6 +305,20 @@ func (s *service) logPerformance(ctx context.Context, response *contract.Respons
 	return err
 }
 
+//loadPerformance loads performance logged by the last dispatch cycle
+func (s *service) loadPerformance(ctx context.Context) (contract.ProjectPerformance, error) {
+	URL := url.Join(s.config.JournalURL, shared.PerformanceFile)
+	if ok, _ := s.fs.Exists(ctx, URL); !ok {
+		return nil, nil
+	}
+	data, err := s.fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	result := contract.ProjectPerformance{}
+	return result, json.Unmarshal(data, &result)
+}
+
 func (s *service) filterCandidate(response *contract.Response, objects []astorage.Object, action string) map[string][]astorage.Object {
 	var result = make(map[string][]astorage.Object, 0)
 	for i, object := range objects {
@@ -324,7 +348,7 @@ func (s *service) filterCandidate(response *contract.Response, objects []astorag
 }
 
 func (s *service) notifyDoneProcesses(ctx context.Context, events *project.Events, response *contract.Response, jobsByID *jobs) (err error) {
-	waitGroup := &sync.WaitGroup{}
+	queue := newFairQueue()
 	for i, object := range events.Items {
 		if object.IsDir() || path.Ext(object.Name()) == shared.WindowExt || path.Ext(object.Name()) == shared.WindowExtScheduled {
 			continue
@@ -363,22 +387,36 @@ func (s *service) notifyDoneProcesses(ctx context.Context, events *project.Event
 			continue
 		}
 		stageInfo := events.AddDispatch(jobID)
-		if !s.canNotify(stageInfo.Action, events.Performance) {
-			events.AddThrottled(jobID)
+		queue.push(stageInfo.Priority, s.config.Priorities.Weight(stageInfo.Priority), &candidate{job: contract.NewJob(jobID, object.URL(), state), stageInfo: stageInfo})
+	}
+	return s.admit(ctx, queue, events, response)
+}
+
+//admit notifies queued candidates in weighted fair order, candidates exceeding concurrency limits are throttled
+func (s *service) admit(ctx context.Context, queue *fairQueue, events *project.Events, response *contract.Response) (err error) {
+	waitGroup := &sync.WaitGroup{}
+	for {
+		next, ok := queue.pop()
+		if !ok {
+			break
+		}
+		if !s.canNotify(next.stageInfo.Action, events.Performance) || !s.canNotifyClass(next.stageInfo, events.Performance) {
+			events.AddThrottled(next.job.ID)
 			continue
 		}
-		job := contract.NewJob(jobID, object.URL(), state)
-
+		events.AddAdmitted(next.stageInfo)
 		waitGroup.Add(1)
 		go func(job *contract.Job) {
 			defer waitGroup.Done()
-			err = s.notify(ctx, job, events)
-			if err == nil {
+			notified, e := s.notify(ctx, job, events)
+			if notified {
 				response.Jobs.Add(job)
-			} else {
-				response.AddError(err)
 			}
-		}(job)
+			if e != nil {
+				err = e
+				response.AddError(e)
+			}
+		}(next.job)
 	}
 	waitGroup.Wait()
 	return err
@@ -394,6 +432,22 @@ func (s *service) canNotify(action string, perf *contract.Performance) bool {
 	return true
 }
 
+//canNotifyClass returns true if job priority class concurrency limits allow post-job work
+func (s *service) canNotifyClass(stageInfo *activity.Meta, perf *contract.Performance) bool {
+	priority := s.config.Priorities.Get(stageInfo.Priority)
+	if priority == nil {
+		return true
+	}
+	active := perf.Class(stageInfo.Priority).Active
+	switch stageInfo.Action {
+	case shared.ActionQuery:
+		return priority.MaxConcurrentSQL == 0 || priority.MaxConcurrentSQL > active.QueryJobs+1
+	case shared.ActionLoad:
+		return priority.MaxConcurrentLoad == 0 || priority.MaxConcurrentLoad > active.LoadJobs+1
+	}
+	return true
+}
+
 func (s *service) dispatchBqEvents(ctx context.Context, response *contract.Response, events *project.Events) error {
 	var jobsByID = newJobs()
 	stepDuration := 10 * time.Minute
@@ -457,7 +511,8 @@ func (s *service) listBQJobs(ctx context.Context, projectID string, minCreated,
 }
 
 // notify notify bqtail
-func (s *service) notify(ctx context.Context, job *contract.Job, events *project.Events) error {
+//notify claims post-job task with generation precondition write to the task URL, so that polling and job event dispatch run post actions only once, it returns true if task has been claimed
+func (s *service) notify(ctx context.Context, job *contract.Job, events *project.Events) (bool, error) {
 	info := activity.Parse(job.ID)
 	info.Region = events.Region
 	info.ProjectID = events.ProjectID
@@ -465,7 +520,24 @@ func (s *service) notify(ctx context.Context, job *contract.Job, events *project
 	if shared.IsDebugLoggingLevel() {
 		shared.LogF("notify: %v -> %v\n", job.URL, taskURL)
 	}
-	return s.fs.Move(ctx, job.URL, taskURL, option.NewObjectKind(true))
+	data, err := s.fs.DownloadWithURL(ctx, job.URL)
+	if err != nil {
+		if exists, _ := s.fs.Exists(ctx, job.URL, option.NewObjectKind(true)); !exists {
+			return false, nil
+		}
+		return false, err
+	}
+	err = s.fs.Upload(ctx, taskURL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, 0))
+	if err != nil && !base.IsPreConditionError(err) {
+		return false, err
+	}
+	claimed := err == nil
+	if err = s.fs.Delete(ctx, job.URL, option.NewObjectKind(true)); err != nil {
+		if exists, _ := s.fs.Exists(ctx, job.URL, option.NewObjectKind(true)); !exists {
+			err = nil
+		}
+	}
+	return claimed, err
 }
 
 func (s *service) dispatchBatchEvents(ctx context.Context, response *contract.Response, projectObjects *project.Events, scheduled project.ScheduleBatches) (err error) {
@@ -524,7 +596,7 @@ func (s *service) processBatch(ctx context.Context, response *contract.Response,
 	perf.Metric(shared.RunningState).BatchJobs++
 	perf.Metric(shared.RunningState).LoadJobs++
 	response.AddBatch(obj.URL(), *dueTime)
-	baseURL := fmt.Sprintf("gs://%v%v", s.config.TriggerBucket, s.config.BatchPrefix)
+	baseURL := s.config.TriggerBucketURL() + s.config.BatchPrefix
 	destURL := url.Join(baseURL, obj.Name())
 	if err := s.fs.Upload(ctx, scheduledURL, file.DefaultFileOsMode, strings.NewReader("."), option.NewGeneration(true, 0)); err == nil {
 		if err = s.fs.Copy(ctx, batchURL, destURL, option.NewObjectKind(true)); err != nil {
@@ -567,3 +639,14 @@ func New(ctx context.Context, config *Config) (Service, error) {
 	}
 	return srv, srv.Init(ctx)
 }
+
+// NewWithBigQuery creates a dispatch service with supplied BigQuery API service, i.e. in-process emulator
+func NewWithBigQuery(ctx context.Context, config *Config, bigQuery *bigquery.Service) (Service, error) {
+	srv := &service{
+		config:   config,
+		fs:       afs.New(),
+		bigQuery: bigQuery,
+		Registry: task.NewRegistry(),
+	}
+	return srv, srv.Init(ctx)
+}
@@ -0,0 +1,147 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/service/bq/emulator"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail"
+	"github.com/viant/bqtail/tail/contract"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBaseURL = "mem://localhost/dispatch/test"
+
+func TestService_Dispatch(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	env, ok := newTestEnv(t, testBaseURL, "mem://localhost", "/data/async/")
+	if !ok {
+		return
+	}
+	tailConfig, tailService, dispatchService, bqEmulator, bqService := env.tailConfig, env.tailService, env.dispatchService, env.bqEmulator, env.bqService
+
+	dataURL := "mem://localhost/data/async/events1.json"
+	assert.Nil(t, fs.Upload(ctx, dataURL, file.DefaultFileOsMode, strings.NewReader("{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n")))
+	tailResponse := tailService.Tail(ctx, &contract.Request{EventID: "201", SourceURL: dataURL, Started: time.Now()})
+	if !assert.Equal(t, shared.StatusOK, tailResponse.Status, tailResponse.Error) {
+		return
+	}
+	bqEmulator.Wait()
+	time.Sleep(thinkTime + 100*time.Millisecond)
+
+	dispatchCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	dispatchResponse := dispatchService.Dispatch(dispatchCtx)
+	if !assert.Equal(t, 1, len(dispatchResponse.Jobs.Jobs), dispatchResponse.Error) {
+		return
+	}
+	postURLs, err := listTaskURLs(ctx, fs, tailConfig.TriggerBucketURL()+shared.PostJobPrefix)
+	if !assert.Nil(t, err) || !assert.Equal(t, 1, len(postURLs)) {
+		return
+	}
+	postResponse := tailService.Tail(ctx, &contract.Request{EventID: "202", SourceURL: postURLs[0], Started: time.Now()})
+	assert.Equal(t, shared.StatusOK, postResponse.Status, postResponse.Error)
+	ref, _ := base.NewTableReference("mydataset.events")
+	table, err := bqService.Table(ctx, ref)
+	if assert.Nil(t, err) {
+		assert.EqualValues(t, 2, table.NumRows)
+	}
+	exists, _ := fs.Exists(ctx, dataURL, option.NewObjectKind(true))
+	assert.False(t, exists)
+}
+
+func listTaskURLs(ctx context.Context, fs afs.Service, baseURL string) ([]string, error) {
+	objects, err := fs.List(ctx, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	var result = make([]string, 0)
+	for i, object := range objects {
+		if i == 0 && object.IsDir() {
+			continue
+		}
+		if !object.IsDir() {
+			result = append(result, object.URL())
+			continue
+		}
+		URLs, err := listTaskURLs(ctx, fs, object.URL())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, URLs...)
+	}
+	return result, nil
+}
+
+//testEnv represents tail and dispatch services sharing BigQuery emulator
+type testEnv struct {
+	tailConfig      *tail.Config
+	tailService     tail.Service
+	dispatchService Service
+	bqEmulator      *emulator.Emulator
+	bqService       bq.Service
+}
+
+func newTestEnv(t *testing.T, baseURL, triggerBucket, prefix string) (*testEnv, bool) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseConfig := base.Config{
+		URL:           baseURL + "/config/config.json",
+		ProjectID:     "myproject",
+		TriggerBucket: triggerBucket,
+		JournalURL:    baseURL + "/journal",
+		ErrorURL:      baseURL + "/errors",
+		AsyncTaskURL:  baseURL + "/tasks",
+		SyncTaskURL:   baseURL + "/tasks",
+	}
+	tailConfig := &tail.Config{Config: baseConfig}
+	tailConfig.CorruptedFileURL = baseURL + "/corrupted"
+	tailConfig.RulesURL = baseURL + "/config/rules"
+	tailConfig.CheckInMs = 1
+	rule := `When:
+  Prefix: ` + prefix + `
+  Suffix: .json
+Async: true
+Dest:
+  Table: mydataset.events
+OnSuccess:
+  - Action: delete
+`
+	assert.Nil(t, fs.Upload(ctx, tailConfig.RulesURL+"/async.yaml", file.DefaultFileOsMode, strings.NewReader(rule)))
+	data, _ := json.Marshal(tailConfig)
+	assert.Nil(t, fs.Upload(ctx, tailConfig.URL, file.DefaultFileOsMode, bytes.NewReader(data)))
+
+	bqEmulator := emulator.New(baseURL+"/bq", fs)
+	bqService, err := bqEmulator.Service(ctx, task.NewRegistry(), baseConfig.ProjectID, fs, baseConfig)
+	if !assert.Nil(t, err) {
+		return nil, false
+	}
+	assert.Nil(t, bqService.CreateDatasetIfNotExist(ctx, "", &bigquery.DatasetReference{DatasetId: "mydataset"}))
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "STRING"}}}
+	assert.Nil(t, bqService.CreateTableIfNotExist(ctx, &bigquery.Table{TableReference: &bigquery.TableReference{DatasetId: "mydataset", TableId: "events"}, Schema: schema}, false))
+	bigQuery, err := bqEmulator.BigQuery(ctx)
+	if !assert.Nil(t, err) {
+		return nil, false
+	}
+	tailService, err := tail.NewWithBigQuery(ctx, tailConfig, bigQuery)
+	if !assert.Nil(t, err) {
+		return nil, false
+	}
+	dispatchService, err := NewWithBigQuery(ctx, &Config{Config: baseConfig}, bigQuery)
+	if !assert.Nil(t, err) {
+		return nil, false
+	}
+	return &testEnv{tailConfig: tailConfig, tailService: tailService, dispatchService: dispatchService, bqEmulator: bqEmulator, bqService: bqService}, true
+}
@@ -5,6 +5,8 @@ go 1.18
 require (
 	cloud.google.com/go/functions v1.16.1
 	github.com/GoogleCloudPlatform/functions-framework-go v1.3.0
+	github.com/aws/aws-sdk-go v1.34.10
+	github.com/aws/aws-sdk-go v1.34.10
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/nlopes/slack v0.6.0
 	github.com/pkg/errors v0.9.1
@@ -14,6 +16,7 @@ require (
 	github.com/viant/assertly v0.5.3
 	github.com/viant/toolbox v0.34.5
 	golang.org/x/oauth2 v0.17.0
+	golang.org/x/sys v0.17.0
 	google.golang.org/api v0.169.0
 	gopkg.in/ini.v1 v1.52.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -27,7 +30,6 @@ require (
 	cloud.google.com/go/kms v1.15.8 // indirect
 	cloud.google.com/go/pubsub v1.36.1 // indirect
 	cloud.google.com/go/storage v1.36.0 // indirect
-	github.com/aws/aws-sdk-go v1.34.10 // indirect
 	github.com/cloudevents/sdk-go/v2 v2.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/felixge/httpsnoop v1.0.4 // indirect
@@ -57,7 +59,6 @@ require (
 	golang.org/x/crypto v0.19.0 // indirect
 	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
-	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
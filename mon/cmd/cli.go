@@ -10,9 +10,12 @@ import (
 	"github.com/viant/bqtail/shared"
 	"github.com/viant/toolbox"
 	"log"
+	"net/http"
 	"os"
 )
 
+const metricsPath = "/metrics"
+
 //RunClient run client
 func RunClient(Version string, args []string) {
 	options := &Options{}
@@ -46,10 +49,21 @@ func RunClient(Version string, args []string) {
 	if err != nil {
 		log.Fatal(errors.Wrapf(err, "failed to create mon service with: %v", options.ConfigURL))
 	}
+	if options.Listen != "" {
+		http.Handle(metricsPath, mon.NewHandler(service))
+		shared.LogF("serving metrics on %v%v\n", options.Listen, metricsPath)
+		log.Fatal(http.ListenAndServe(options.Listen, nil))
+	}
 	response := service.Check(ctx, &mon.Request{
 		IncludeDone: options.IncludeDone,
 		Recency:     options.Recency,
 	})
+	if options.OpenMetrics {
+		if err = response.WriteOpenMetrics(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	toolbox.DumpIndent(response, true)
 }
 
@@ -12,6 +12,8 @@ type Options struct {
 	ProjectID   string `short:"p" long:"project" description:"Google Cloud Project"`
 	Client      string `short:"a" long:"aclient" description:"GCP OAuth client url"`
 	Version     bool   `short:"v" long:"version" description:"bqtail version"`
+	OpenMetrics bool   `short:"o" long:"openmetrics" description:"print status in OpenMetrics text format"`
+	Listen      string `short:"l" long:"listen" description:"serve OpenMetrics status on supplied address /metrics path, i.e. :9090"`
 }
 
 //Init initialises options
@@ -3,6 +3,7 @@ package mon
 import (
 	"github.com/viant/bqtail/mon/info"
 	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/quota"
 	"time"
 )
 
@@ -23,9 +24,13 @@ type Response struct {
 	SchemaError     string `json:",omitempty"`
 	CorruptedError  string `json:",omitempty"`
 	Timestamp       time.Time
+	//ReconciliationError source to destination row count mismatch
+	ReconciliationError string `json:",omitempty"`
 	*Info
 	Dest        []*Info
 	LongRunning []*info.Process `json:",omitempty"`
+	//TableQuota the current day destination table jobs count
+	TableQuota []*quota.Usage `json:",omitempty"`
 }
 
 //NewResponse create a response
@@ -1,6 +1,9 @@
 package mon
 
-import "strings"
+import (
+	"github.com/viant/bqtail/service/bq"
+	"strings"
+)
 
 //IsSchemaError returns true if schema error
 func IsSchemaError(text string) bool {
@@ -8,6 +11,11 @@ func IsSchemaError(text string) bool {
 	return strings.Contains(message, "field") || strings.Contains(message, "schema")
 }
 
+//IsReconciliationError returns true if source to destination row reconciliation error
+func IsReconciliationError(text string) bool {
+	return strings.Contains(strings.ToLower(text), bq.ReconciliationError)
+}
+
 //IsCorruptedError returns true if corrupted error
 func IsCorruptedError(text string) bool {
 	message := strings.ToLower(text)
@@ -0,0 +1,175 @@
+package mon
+
+import (
+	"fmt"
+	"github.com/viant/bqtail/mon/info"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/quota"
+	"github.com/viant/toolbox"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//OpenMetricsContentType OpenMetrics text format content type
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+const (
+	errorKindPermission     = "permission"
+	errorKindSchema         = "schema"
+	errorKindCorrupted      = "corrupted"
+	errorKindReconciliation = "reconciliation"
+)
+
+//metricFamily represents OpenMetrics gauge family
+type metricFamily struct {
+	name    string
+	help    string
+	samples []string
+}
+
+func (f *metricFamily) add(value interface{}, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, labels[i], escapeLabel(labels[i+1])))
+	}
+	sample := f.name
+	if len(pairs) > 0 {
+		sample += "{" + strings.Join(pairs, ",") + "}"
+	}
+	f.samples = append(f.samples, fmt.Sprintf("%v %v", sample, value))
+}
+
+func (f *metricFamily) write(writer io.Writer) error {
+	if len(f.samples) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(writer, "# TYPE %v gauge\n# HELP %v %v\n", f.name, f.name, f.help); err != nil {
+		return err
+	}
+	for _, sample := range f.samples {
+		if _, err := fmt.Fprintln(writer, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+func boolValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+//WriteOpenMetrics writes response metrics in OpenMetrics text format
+func (r *Response) WriteOpenMetrics(writer io.Writer) error {
+	status := &metricFamily{name: "bqtail_status", help: "Monitoring status, 1 for the current status."}
+	running := &metricFamily{name: "bqtail_running", help: "Running load processes count."}
+	runningLag := &metricFamily{name: "bqtail_running_lag_seconds", help: "Oldest running load process lag."}
+	scheduled := &metricFamily{name: "bqtail_scheduled", help: "Scheduled batches count."}
+	scheduledLag := &metricFamily{name: "bqtail_scheduled_lag_seconds", help: "Oldest scheduled batch lag."}
+	done := &metricFamily{name: "bqtail_done", help: "Recently done load processes count."}
+	doneLag := &metricFamily{name: "bqtail_done_lag_seconds", help: "Oldest recently done load process lag."}
+	stalled := &metricFamily{name: "bqtail_stalled", help: "Stalled load processes count."}
+	checkError := &metricFamily{name: "bqtail_check_error", help: "Monitoring error flag by error kind."}
+	errorFlag := &metricFamily{name: "bqtail_error", help: "Destination error flag by error kind."}
+	corrupted := &metricFamily{name: "bqtail_corrupted_files", help: "Recent corrupted data files count."}
+	invalidSchema := &metricFamily{name: "bqtail_invalid_schema_files", help: "Recent invalid schema data files count."}
+	quarantined := &metricFamily{name: "bqtail_quarantined_files", help: "Recent quarantined data files count."}
+	longRunningAge := &metricFamily{name: "bqtail_long_running_age_seconds", help: "Long running load process age."}
+	longRunningActive := &metricFamily{name: "bqtail_long_running_active_datafiles", help: "Long running load process active data files count."}
+	longRunningStalled := &metricFamily{name: "bqtail_long_running_stalled_datafiles", help: "Long running load process stalled data files count."}
+	tableJobs := &metricFamily{name: "bqtail_table_jobs", help: "Current day destination table jobs count by job type."}
+
+	status.add(1, "status", r.Status)
+	checkError.add(boolValue(r.PermissionError != ""), "kind", errorKindPermission)
+	checkError.add(boolValue(r.SchemaError != ""), "kind", errorKindSchema)
+	checkError.add(boolValue(r.CorruptedError != ""), "kind", errorKindCorrupted)
+	checkError.add(boolValue(r.ReconciliationError != ""), "kind", errorKindReconciliation)
+	for _, dest := range r.Dest {
+		labels := []string{"table", dest.Table, "rule_url", dest.RuleURL}
+		if dest.Activity != nil {
+			addMetric(running, runningLag, dest.Running, labels)
+			addMetric(scheduled, scheduledLag, dest.Scheduled, labels)
+			addMetric(done, doneLag, dest.Done, labels)
+			var destError = dest.Activity.Error
+			if destError == nil {
+				destError = &info.Error{}
+			}
+			errorFlag.add(boolValue(destError.IsPermission), append(labels, "kind", errorKindPermission)...)
+			errorFlag.add(boolValue(destError.IsSchema), append(labels, "kind", errorKindSchema)...)
+			errorFlag.add(boolValue(destError.IsCorrupted), append(labels, "kind", errorKindCorrupted)...)
+			errorFlag.add(boolValue(destError.IsReconciliation), append(labels, "kind", errorKindReconciliation)...)
+		}
+		addMetric(corrupted, nil, dest.Corrupted, labels)
+		addMetric(invalidSchema, nil, dest.InvalidSchema, labels)
+		addMetric(quarantined, nil, dest.Quarantined, labels)
+	}
+	if r.Info != nil {
+		for _, item := range r.Stalled.Items {
+			stalled.add(item.Count, "table", item.Key)
+		}
+	}
+	now := time.Now()
+	for _, process := range r.LongRunning {
+		longRunningAge.add(int(now.Sub(process.Created).Seconds()), "url", process.URL)
+		longRunningActive.add(process.ActiveDatafiles, "url", process.URL)
+		longRunningStalled.add(process.StalledDatafiles, "url", process.URL)
+	}
+	for _, usage := range r.TableQuota {
+		tableJobs.add(usage.LoadJobs, "table", usage.Table, "type", quota.JobTypeLoad)
+		tableJobs.add(usage.CopyJobs, "table", usage.Table, "type", quota.JobTypeCopy)
+		tableJobs.add(usage.QueryJobs, "table", usage.Table, "type", quota.JobTypeQuery)
+	}
+	families := []*metricFamily{status, checkError, running, runningLag, scheduled, scheduledLag, done, doneLag, stalled, errorFlag,
+		corrupted, invalidSchema, quarantined, longRunningAge, longRunningActive, longRunningStalled, tableJobs}
+	for _, family := range families {
+		if err := family.write(writer); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(writer, "# EOF")
+	return err
+}
+
+func addMetric(count, lag *metricFamily, metric *info.Metric, labels []string) {
+	if metric == nil {
+		count.add(0, labels...)
+		return
+	}
+	count.add(metric.Count, labels...)
+	if lag != nil {
+		lag.add(metric.LagInSec, labels...)
+	}
+}
+
+//NewHandler creates HTTP handler exposing monitoring metrics in OpenMetrics text format, request parameters: IncludeDone, Recency
+func NewHandler(service Service) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, httpRequest *http.Request) {
+		request := &Request{}
+		if err := httpRequest.ParseForm(); err == nil {
+			request.IncludeDone = toolbox.AsBoolean(httpRequest.Form.Get("IncludeDone"))
+			request.Recency = httpRequest.Form.Get("Recency")
+		}
+		if request.Recency == "" {
+			request.Recency = "1hour"
+		}
+		response := service.Check(httpRequest.Context(), request)
+		if response.Status == shared.StatusError && response.Error != "" && len(response.Dest) == 0 {
+			http.Error(writer, response.Error, http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", OpenMetricsContentType)
+		if err := response.WriteOpenMetrics(writer); err != nil {
+			shared.LogF("failed to write metrics: %v\n", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package mon
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/mon/info"
+	"github.com/viant/bqtail/shared"
+	"testing"
+	"time"
+)
+
+func TestResponse_WriteOpenMetrics(t *testing.T) {
+
+	now := time.Now()
+	var useCases = []struct {
+		description string
+		response    func() *Response
+		expect      []string
+	}{
+		{
+			description: "empty response",
+			response: func() *Response {
+				return NewResponse()
+			},
+			expect: []string{
+				`bqtail_status{status="ok"} 1`,
+				`bqtail_check_error{kind="permission"} 0`,
+				"# EOF\n",
+			},
+		},
+		{
+			description: "destination metrics",
+			response: func() *Response {
+				response := NewResponse()
+				response.Status = shared.StatusError
+				response.SchemaError = "No such field: x"
+				inf := NewInfo()
+				inf.Destination.Table = "proj:ds.table"
+				inf.Destination.RuleURL = "gs://bucket/rules/\"rule\".yaml"
+				inf.Activity.Running = &info.Metric{Count: 3, LagInSec: 120}
+				inf.Activity.Error = &info.Error{IsSchema: true}
+				inf.Corrupted = &info.Metric{Count: 2}
+				response.Dest = append(response.Dest, inf)
+				response.Stalled.GetOrCreate("proj:ds.table").AddEvent(now)
+				response.LongRunning = append(response.LongRunning, &info.Process{URL: "gs://bucket/Running/ds.table--1.run", Created: now.Add(-time.Hour), ActiveDatafiles: 5})
+				return response
+			},
+			expect: []string{
+				`bqtail_status{status="error"} 1`,
+				`bqtail_check_error{kind="schema"} 1`,
+				`bqtail_running{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml"} 3`,
+				`bqtail_running_lag_seconds{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml"} 120`,
+				`bqtail_scheduled{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml"} 0`,
+				`bqtail_error{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml",kind="schema"} 1`,
+				`bqtail_error{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml",kind="corrupted"} 0`,
+				`bqtail_corrupted_files{table="proj:ds.table",rule_url="gs://bucket/rules/\"rule\".yaml"} 2`,
+				`bqtail_stalled{table="proj:ds.table"} 1`,
+				`bqtail_long_running_age_seconds{url="gs://bucket/Running/ds.table--1.run"} 3600`,
+				`bqtail_long_running_active_datafiles{url="gs://bucket/Running/ds.table--1.run"} 5`,
+				"# TYPE bqtail_running gauge\n",
+				"# EOF\n",
+			},
+		},
+	}
+
+	for _, useCase := range useCases {
+		writer := new(bytes.Buffer)
+		err := useCase.response().WriteOpenMetrics(writer)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		for _, expect := range useCase.expect {
+			assert.Contains(t, writer.String(), expect, useCase.description)
+		}
+	}
+}
@@ -10,6 +10,7 @@ StartTime TIMESTAMP,
 EndTime TIMESTAMP,
 ReservationName STRING,
 TotalBytesProcessed INT64,
+TotalBytesBilled INT64,
 InputFileBytes INT64,
 InputFiles INT64,
 OutputBytes INT64,
@@ -33,6 +34,7 @@ CREATE OR REPLACE  TABLE bqtail.bqmon (
     PermissionError STRING,
     SchemaError STRING,
     CorruptedError STRING,
+    ReconciliationError STRING,
     Running STRUCT<
                    Count INT64,
                    Min TIMESTAMP,
@@ -126,7 +128,8 @@ CREATE OR REPLACE  TABLE bqtail.bqmon (
                                  Destination STRING,
                                  IsPermission BOOL,
                                  IsSchema BOOL,
-                                 IsCorrupted BOOL
+                                 IsCorrupted BOOL,
+                                 IsReconciliation BOOL
                     >,
                     InvalidSchema STRUCT<
                                         Min TIMESTAMP,
@@ -137,6 +140,11 @@ CREATE OR REPLACE  TABLE bqtail.bqmon (
                             Min TIMESTAMP,
                             Max TIMESTAMP,
                             Count INT64
+                    >,
+                    Quarantined STRUCT<
+                            Min TIMESTAMP,
+                            Max TIMESTAMP,
+                            Count INT64
                     >
             >
         >,
@@ -20,6 +20,8 @@ import (
 	"github.com/viant/bqtail/stage/activity"
 	"github.com/viant/bqtail/stage/load"
 	"github.com/viant/bqtail/tail"
+	"github.com/viant/bqtail/tail/quarantine"
+	"github.com/viant/bqtail/tail/quota"
 	"github.com/viant/bqtail/task"
 	"github.com/viant/toolbox"
 	"sort"
@@ -153,6 +155,10 @@ func (s *service) check(ctx context.Context, request *Request, response *Respons
 			}
 
 		}
+		if inf.Error != nil && inf.Error.IsReconciliation {
+			response.Status = shared.StatusError
+			response.ReconciliationError = inf.Error.Message
+		}
 		rule := inf.rule
 		if rule == nil {
 			rule = s.Config.Rule(ctx, inf.RuleURL)
@@ -160,6 +166,13 @@ func (s *service) check(ctx context.Context, request *Request, response *Respons
 		if rule != nil {
 			inf.Corrupted, _ = s.getURLMetrics(ctx, rule.CorruptedFileURL, inf, request.Recency)
 			inf.InvalidSchema, _ = s.getURLMetrics(ctx, rule.InvalidSchemaURL, inf, request.Recency)
+			if rule.Quarantine != nil {
+				quarantineURL := rule.Quarantine.URL
+				if quarantineURL == "" {
+					quarantineURL = s.Config.QuarantineURL
+				}
+				inf.Quarantined, _ = s.getURLMetrics(ctx, quarantine.ManifestURL(quarantineURL, rule), inf, request.Recency)
+			}
 		}
 
 		if inf.Activity != nil {
@@ -182,6 +195,11 @@ func (s *service) check(ctx context.Context, request *Request, response *Respons
 	for _, k := range keys {
 		response.Dest = append(response.Dest, infoDest[k])
 	}
+	if s.Config.TableQuotaURL != "" {
+		if response.TableQuota, err = quota.New(s.Config.TableQuotaURL, s.fs).List(ctx); err != nil {
+			return err
+		}
+	}
 
 	if request.DestPath != "" {
 		data, err := json.Marshal(response)
@@ -238,7 +256,7 @@ func (s *service) processLongRunningProcess(ctx context.Context, inf *Info, proc
 		process.ActiveDatafiles = inf.activeDatafile
 		process.StalledDatafiles = inf.stalledDatafile
 	} else {
-		baseURL := fmt.Sprintf("gs://%v", s.TriggerBucket)
+		baseURL := s.TriggerBucketURL()
 		URL := url.Join(baseURL, inf.rule.When.Prefix)
 		if err := traverse(ctx, URL, s.fs, 1000, &inf.stalledDatafile, &inf.activeDatafile, inf.rule.StalledDuration()); err == nil {
 			inf.traversed = true
@@ -412,6 +430,9 @@ func (s *service) getError(ctx context.Context, dest string, files []storage.Obj
 	}
 
 	result.IsPermission = base.IsPermissionDenied(fmt.Errorf(result.Message))
+	if result.IsReconciliation = IsReconciliationError(result.Message); result.IsReconciliation {
+		return result, nil
+	}
 	if result.IsSchema = IsSchemaError(result.Message); !result.IsSchema {
 		result.IsCorrupted = IsCorruptedError(result.Message)
 	}
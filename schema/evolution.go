@@ -0,0 +1,182 @@
+package schema
+
+import (
+	"fmt"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+)
+
+const (
+	//ChangeAdd adds a column
+	ChangeAdd = "add"
+	//ChangeRelax relaxes REQUIRED column to NULLABLE
+	ChangeRelax = "relax"
+	//ChangeWiden widens column type, i.e. INT64 to NUMERIC or FLOAT64
+	ChangeWiden = "widen"
+
+	//ModeRequired required mode
+	ModeRequired = "REQUIRED"
+	//ModeNullable nullable mode
+	ModeNullable = "NULLABLE"
+	//FieldTypeNumeric numeric type
+	FieldTypeNumeric = "NUMERIC"
+	//FieldTypeBigNumeric big numeric type
+	FieldTypeBigNumeric = "BIGNUMERIC"
+)
+
+//Change represents a table schema change
+type Change struct {
+	Kind  string
+	Field string
+	From  string                     `json:",omitempty"`
+	To    string                     `json:",omitempty"`
+	field *bigquery.TableFieldSchema //field to add
+}
+
+//IsDestructive returns true if change alters existing column, thus it can not be reverted
+func (c *Change) IsDestructive() bool {
+	return c.Kind == ChangeRelax || c.Kind == ChangeWiden
+}
+
+//String returns change description
+func (c *Change) String() string {
+	if c.From == "" {
+		return fmt.Sprintf("%v %v %v", c.Kind, c.Field, c.To)
+	}
+	return fmt.Sprintf("%v %v %v -> %v", c.Kind, c.Field, c.From, c.To)
+}
+
+//Diff returns changes required for target fields to accept source fields, aliases map source column to target column.
+//Target REQUIRED field missing in the source needs to be relaxed, INT64 target field with FLOAT64 or NUMERIC source needs to be widened.
+func Diff(target, source []*bigquery.TableFieldSchema, aliases map[string]string) ([]*Change, error) {
+	var result = make([]*Change, 0)
+	err := diff("", target, source, aliases, &result)
+	return result, err
+}
+
+func diff(parent string, target, source []*bigquery.TableFieldSchema, aliases map[string]string, changes *[]*Change) error {
+	targetFields := indexFieldsByName(target)
+	matched := map[string]bool{}
+	for _, field := range source {
+		path := fieldPath(parent, field.Name)
+		name := strings.ToLower(field.Name)
+		if alias, ok := aliases[path]; ok && parent == "" {
+			name = strings.ToLower(alias)
+		}
+		targetField, ok := targetFields[name]
+		if !ok {
+			*changes = append(*changes, &Change{Kind: ChangeAdd, Field: path, To: fieldTypeWithMode(field), field: field})
+			continue
+		}
+		matched[name] = true
+		path = fieldPath(parent, targetField.Name)
+		targetType, sourceType := NormalizeType(targetField.Type), NormalizeType(field.Type)
+		if targetType == FieldTypeRecord {
+			if sourceType != FieldTypeRecord {
+				return fmt.Errorf("incompatible %v type: %v, source: %v", path, targetField.Type, field.Type)
+			}
+			if err := diff(path, targetField.Fields, field.Fields, aliases, changes); err != nil {
+				return err
+			}
+			continue
+		}
+		if targetType == FieldTypeInt && (sourceType == FieldTypeFloat || sourceType == FieldTypeNumeric || sourceType == FieldTypeBigNumeric) {
+			*changes = append(*changes, &Change{Kind: ChangeWiden, Field: path, From: targetType, To: sourceType})
+		}
+	}
+	for _, field := range target {
+		if matched[strings.ToLower(field.Name)] || field.Mode != ModeRequired {
+			continue
+		}
+		*changes = append(*changes, &Change{Kind: ChangeRelax, Field: fieldPath(parent, field.Name), From: ModeRequired, To: ModeNullable})
+	}
+	return nil
+}
+
+//Evolve returns copy of the fields with applied changes
+func Evolve(fields []*bigquery.TableFieldSchema, changes []*Change) []*bigquery.TableFieldSchema {
+	result := CloneFields(fields)
+	for _, change := range changes {
+		result = applyChange(result, strings.Split(change.Field, "."), change)
+	}
+	return result
+}
+
+func applyChange(fields []*bigquery.TableFieldSchema, path []string, change *Change) []*bigquery.TableFieldSchema {
+	name := strings.ToLower(path[0])
+	for _, field := range fields {
+		if strings.ToLower(field.Name) != name {
+			continue
+		}
+		if len(path) > 1 {
+			field.Fields = applyChange(field.Fields, path[1:], change)
+			return fields
+		}
+		switch change.Kind {
+		case ChangeRelax:
+			field.Mode = ModeNullable
+		case ChangeWiden:
+			field.Type = change.To
+		}
+		return fields
+	}
+	if change.Kind == ChangeAdd && len(path) == 1 && change.field != nil {
+		field := CloneFields([]*bigquery.TableFieldSchema{change.field})[0]
+		if field.Mode == ModeRequired {
+			field.Mode = ModeNullable
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+//CloneFields returns deep copy of the fields
+func CloneFields(fields []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	if fields == nil {
+		return nil
+	}
+	var result = make([]*bigquery.TableFieldSchema, len(fields))
+	for i, field := range fields {
+		clone := *field
+		clone.Fields = CloneFields(field.Fields)
+		result[i] = &clone
+	}
+	return result
+}
+
+//NormalizeType returns standard SQL type for legacy type name
+func NormalizeType(fieldType string) string {
+	switch strings.ToUpper(fieldType) {
+	case "INTEGER", FieldTypeInt:
+		return FieldTypeInt
+	case "FLOAT", FieldTypeFloat:
+		return FieldTypeFloat
+	case "BOOL", FieldTypeBool:
+		return FieldTypeBool
+	case "STRUCT", FieldTypeRecord:
+		return FieldTypeRecord
+	}
+	return strings.ToUpper(fieldType)
+}
+
+func fieldTypeWithMode(field *bigquery.TableFieldSchema) string {
+	if field.Mode == ModeRepeated {
+		return ModeRepeated + " " + field.Type
+	}
+	return field.Type
+}
+
+func fieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func indexFieldsByName(fields []*bigquery.TableFieldSchema) map[string]*bigquery.TableFieldSchema {
+	var result = make(map[string]*bigquery.TableFieldSchema)
+	for _, field := range fields {
+		result[strings.ToLower(field.Name)] = field
+	}
+	return result
+}
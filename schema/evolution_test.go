@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/bigquery/v2"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+
+	useCases := []struct {
+		description  string
+		target       []*bigquery.TableFieldSchema
+		source       []*bigquery.TableFieldSchema
+		aliases      map[string]string
+		expect       []string
+		expectFields []*bigquery.TableFieldSchema
+		hasError     bool
+	}{
+		{
+			description:  "no changes",
+			target:       []*bigquery.TableFieldSchema{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "STRING"}},
+			source:       []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}, {Name: "name", Type: "STRING"}},
+			expect:       []string{},
+			expectFields: []*bigquery.TableFieldSchema{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "STRING"}},
+		},
+		{
+			description: "add, relax and widen",
+			target:      []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}, {Name: "ts", Type: "TIMESTAMP", Mode: ModeRequired}},
+			source:      []*bigquery.TableFieldSchema{{Name: "id", Type: "FLOAT64"}, {Name: "name", Type: "STRING", Mode: ModeRequired}},
+			expect:      []string{"widen id INT64 -> FLOAT64", "add name STRING", "relax ts REQUIRED -> NULLABLE"},
+			expectFields: []*bigquery.TableFieldSchema{
+				{Name: "id", Type: "FLOAT64"},
+				{Name: "ts", Type: "TIMESTAMP", Mode: ModeNullable},
+				{Name: "name", Type: "STRING", Mode: ModeNullable},
+			},
+		},
+		{
+			description: "nested record",
+			target: []*bigquery.TableFieldSchema{
+				{Name: "info", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{{Name: "a", Type: "STRING"}}},
+			},
+			source: []*bigquery.TableFieldSchema{
+				{Name: "info", Type: "STRUCT", Fields: []*bigquery.TableFieldSchema{{Name: "a", Type: "STRING"}, {Name: "b", Type: "BOOL"}}},
+			},
+			expect: []string{"add info.b BOOL"},
+			expectFields: []*bigquery.TableFieldSchema{
+				{Name: "info", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{{Name: "a", Type: "STRING"}, {Name: "b", Type: "BOOL"}}},
+			},
+		},
+		{
+			description:  "aliased column",
+			target:       []*bigquery.TableFieldSchema{{Name: "user_id", Type: "INT64", Mode: ModeRequired}},
+			source:       []*bigquery.TableFieldSchema{{Name: "uid", Type: "INT64"}},
+			aliases:      map[string]string{"uid": "user_id"},
+			expect:       []string{},
+			expectFields: []*bigquery.TableFieldSchema{{Name: "user_id", Type: "INT64", Mode: ModeRequired}},
+		},
+		{
+			description: "incompatible record",
+			target: []*bigquery.TableFieldSchema{
+				{Name: "info", Type: "RECORD", Fields: []*bigquery.TableFieldSchema{{Name: "a", Type: "STRING"}}},
+			},
+			source:   []*bigquery.TableFieldSchema{{Name: "info", Type: "STRING"}},
+			hasError: true,
+		},
+	}
+
+	for _, useCase := range useCases {
+		changes, err := Diff(useCase.target, useCase.source, useCase.aliases)
+		if useCase.hasError {
+			assert.NotNil(t, err, useCase.description)
+			continue
+		}
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		var actual = make([]string, 0)
+		for _, change := range changes {
+			actual = append(actual, change.String())
+		}
+		assert.EqualValues(t, useCase.expect, actual, useCase.description)
+		assert.EqualValues(t, useCase.expectFields, Evolve(useCase.target, changes), useCase.description)
+	}
+}
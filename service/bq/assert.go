@@ -0,0 +1,249 @@
+package bq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs/file"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/task"
+	"github.com/viant/toolbox"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+)
+
+const (
+	assertValueColumn = "value"
+	//AssertionsKey process journal assertions key
+	AssertionsKey = "Assertions"
+)
+
+//Assert runs assertion SQLs, compares results with thresholds, on violation quarantines temp table and runs OnFailure actions
+func (s *service) Assert(ctx context.Context, request *AssertRequest, action *task.Action) error {
+	if err := request.Init(action); err != nil {
+		return err
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	projectID := action.Meta.GetOrSetProject(s.projectID)
+	var results = make([]*AssertionResult, 0)
+	var violations = make([]string, 0)
+	for _, assertion := range request.Assertions {
+		result := s.assert(ctx, projectID, assertion)
+		results = append(results, result)
+		if result.Passed {
+			continue
+		}
+		if shared.IsInfoLoggingLevel() || result.Warn {
+			shared.LogF("[%v] assertion %v violated: %v\n", action.Meta.DestTable, assertion.Name, result.Message)
+		}
+		if !result.Warn {
+			violations = append(violations, assertion.Name+": "+result.Message)
+		}
+	}
+	if err := s.recordAssertions(ctx, action.Meta.ProcessURL, results); err != nil {
+		return errors.Wrapf(err, "failed to record assertions: %v", action.Meta.ProcessURL)
+	}
+	onDone := action.Actions
+	if onDone == nil {
+		onDone = task.NewActions(nil, nil)
+	}
+	var err error
+	if len(violations) > 0 {
+		err = errors.Errorf("assertion violated: %v", strings.Join(violations, "; "))
+		if request.QuarantineDataset != "" {
+			if qErr := s.quarantine(ctx, projectID, request); qErr != nil {
+				return errors.Wrapf(qErr, "failed to quarantine %v, %v", request.Table, err)
+			}
+		}
+	}
+	if _, runErr := task.RunAll(ctx, s.Registry, onDone.ToRun(err, &base.Job{})); runErr != nil {
+		return runErr
+	}
+	return err
+}
+
+func (s *service) assert(ctx context.Context, projectID string, assertion *Assertion) *AssertionResult {
+	result := &AssertionResult{Name: assertion.Name, Min: assertion.Min, Max: assertion.Max, Warn: assertion.Warn}
+	records, err := s.fetchAll(ctx, projectID, false, assertion.SQL)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	value, ok := assertionValue(records)
+	if !ok {
+		result.Message = "assertion SQL returned no value"
+		return result
+	}
+	result.Value = &value
+	result.Passed, result.Message = assertion.Evaluate(value)
+	return result
+}
+
+//assertionValue returns value column or the only column of the first row
+func assertionValue(records []map[string]bigquery.JsonValue) (float64, bool) {
+	if len(records) == 0 {
+		return 0, false
+	}
+	record := records[0]
+	value, ok := record[assertValueColumn]
+	if !ok && len(record) == 1 {
+		for _, candidate := range record {
+			value = candidate
+		}
+	}
+	switch actual := value.(type) {
+	case nil:
+		return 0, false
+	case bool:
+		if actual {
+			return 1, true
+		}
+		return 0, true
+	}
+	result, err := toolbox.ToFloat(value)
+	return result, err == nil
+}
+
+//quarantine copies temp table to the quarantine dataset
+func (s *service) quarantine(ctx context.Context, projectID string, request *AssertRequest) error {
+	source, err := base.NewTableReference(request.Table)
+	if err != nil {
+		return err
+	}
+	if source.ProjectId == "" {
+		source.ProjectId = projectID
+	}
+	dest := &bigquery.TableReference{ProjectId: source.ProjectId, DatasetId: request.QuarantineDataset, TableId: base.TableID(source.TableId)}
+	SQL := fmt.Sprintf("CREATE OR REPLACE TABLE `%v` AS SELECT * FROM `%v`", base.EncodeTableReference(dest, true), base.EncodeTableReference(source, true))
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("quarantining %v into %v\n", request.Table, base.EncodeTableReference(dest, true))
+	}
+	_, err = s.fetchAll(ctx, projectID, false, SQL)
+	return err
+}
+
+//recordAssertions records assertion results in the process journal
+func (s *service) recordAssertions(ctx context.Context, URL string, results []*AssertionResult) error {
+	if URL == "" {
+		return nil
+	}
+	ok, err := s.fs.Exists(ctx, URL)
+	if err != nil || !ok {
+		return err
+	}
+	data, err := s.fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return err
+	}
+	journal := map[string]interface{}{}
+	if err = json.Unmarshal(data, &journal); err != nil {
+		return err
+	}
+	journal[AssertionsKey] = results
+	if data, err = json.Marshal(journal); err != nil {
+		return err
+	}
+	return s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data))
+}
+
+//AssertRequest represents data quality assertion request
+type AssertRequest struct {
+	//Table asserted table, temp table by default
+	Table string
+	//QuarantineDataset optional dataset where asserted table is copied on violation
+	QuarantineDataset string
+	Assertions        []*Assertion
+}
+
+//Init initialises request
+func (r *AssertRequest) Init(action *task.Action) error {
+	if r.Table == "" && action != nil && action.Meta != nil {
+		r.Table = action.Meta.TempTable
+	}
+	for i, assertion := range r.Assertions {
+		if assertion.Name == "" {
+			assertion.Name = fmt.Sprintf("assertion_%v", i+1)
+		}
+	}
+	return nil
+}
+
+//Validate checks if request is valid
+func (r *AssertRequest) Validate() error {
+	if len(r.Assertions) == 0 {
+		return errors.New("assertions were empty")
+	}
+	if r.QuarantineDataset != "" && r.Table == "" {
+		return errors.New("table was empty")
+	}
+	for _, assertion := range r.Assertions {
+		if err := assertion.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid assertion: %v", assertion.Name)
+		}
+	}
+	return nil
+}
+
+//Assertion represents SQL returning single value (value column or the only column) checked against thresholds
+type Assertion struct {
+	Name string
+	//SQL assertion SQL, i.e. SELECT SAFE_DIVIDE(COUNTIF(id IS NULL), COUNT(1)) AS value FROM $TempTable
+	SQL string
+	//Min optional inclusive min threshold
+	Min *float64 `json:",omitempty"`
+	//Max optional inclusive max threshold
+	Max *float64 `json:",omitempty"`
+	//Warn logs violation without failing
+	Warn bool `json:",omitempty"`
+}
+
+//Validate checks if assertion is valid
+func (a *Assertion) Validate() error {
+	if a.SQL == "" {
+		return errors.New("SQL was empty")
+	}
+	if a.Min == nil && a.Max == nil {
+		return errors.New("min and max were empty")
+	}
+	if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+		return errors.Errorf("min %v is greater than max %v", *a.Min, *a.Max)
+	}
+	return nil
+}
+
+//Evaluate returns true if value is within thresholds, otherwise violation message
+func (a *Assertion) Evaluate(value float64) (bool, string) {
+	if a.Min != nil && value < *a.Min {
+		return false, fmt.Sprintf("value %v is less than min %v", value, *a.Min)
+	}
+	if a.Max != nil && value > *a.Max {
+		return false, fmt.Sprintf("value %v is greater than max %v", value, *a.Max)
+	}
+	return true, ""
+}
+
+//AssertionResult represents assertion result
+type AssertionResult struct {
+	Name    string
+	Value   *float64 `json:",omitempty"`
+	Min     *float64 `json:",omitempty"`
+	Max     *float64 `json:",omitempty"`
+	Passed  bool
+	Warn    bool   `json:",omitempty"`
+	Message string `json:",omitempty"`
+}
+
+//NewAssertAction creates a new assert action
+func NewAssertAction(request *AssertRequest, finally *task.Actions) *task.Action {
+	result := &task.Action{
+		Action:  shared.ActionAssert,
+		Actions: finally,
+	}
+	_ = result.SetRequest(request)
+	return result
+}
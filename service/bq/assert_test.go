@@ -0,0 +1,64 @@
+package bq
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/bigquery/v2"
+	"testing"
+)
+
+func TestAssertion_Evaluate(t *testing.T) {
+	zero, max := 0.0, 0.01
+	var useCases = []struct {
+		description string
+		assertion   *Assertion
+		records     []map[string]bigquery.JsonValue
+		expectValid bool
+		expectPass  bool
+	}{
+		{
+			description: "null ratio within max",
+			assertion:   &Assertion{SQL: "SELECT 1", Max: &max},
+			records:     []map[string]bigquery.JsonValue{{"value": 0.001}},
+			expectValid: true,
+			expectPass:  true,
+		},
+		{
+			description: "null ratio exceeds max",
+			assertion:   &Assertion{SQL: "SELECT 1", Max: &max},
+			records:     []map[string]bigquery.JsonValue{{"value": "0.5"}},
+			expectValid: true,
+		},
+		{
+			description: "single unnamed column",
+			assertion:   &Assertion{SQL: "SELECT 1", Min: &zero, Max: &zero},
+			records:     []map[string]bigquery.JsonValue{{"f0_": int64(0)}},
+			expectValid: true,
+			expectPass:  true,
+		},
+		{
+			description: "boolean check failed",
+			assertion:   &Assertion{SQL: "SELECT 1", Min: &max},
+			records:     []map[string]bigquery.JsonValue{{"value": false}},
+			expectValid: true,
+		},
+		{
+			description: "no rows",
+			assertion:   &Assertion{SQL: "SELECT 1", Min: &zero},
+		},
+		{
+			description: "null value",
+			assertion:   &Assertion{SQL: "SELECT 1", Min: &zero},
+			records:     []map[string]bigquery.JsonValue{{"value": nil}},
+		},
+	}
+
+	for _, useCase := range useCases {
+		assert.Nil(t, useCase.assertion.Validate(), useCase.description)
+		value, ok := assertionValue(useCase.records)
+		if !assert.EqualValues(t, useCase.expectValid, ok, useCase.description) || !ok {
+			continue
+		}
+		passed, message := useCase.assertion.Evaluate(value)
+		assert.EqualValues(t, useCase.expectPass, passed, useCase.description+" "+message)
+	}
+}
@@ -0,0 +1,393 @@
+package bq
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/toolbox"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	gzipExt          = ".gz"
+	avroMagic        = "Obj\x01"
+	avroSyncSize     = 16
+	parquetMagic     = "PAR1"
+	parquetFooterLen = 8
+	//parquetMaxTail max file tail read to locate footer
+	parquetMaxTail = 16 * 1024 * 1024
+	//parquetNumRowsField FileMetaData.num_rows thrift field id
+	parquetNumRowsField = 3
+)
+
+//countRecords returns number of data records in the source file
+func countRecords(ctx context.Context, fs afs.Service, URL, format string, skipLeadingRows int) (int64, error) {
+	switch strings.ToUpper(format) {
+	case "AVRO":
+		return countAvroRecords(ctx, fs, URL)
+	case "PARQUET":
+		return countParquetRecords(ctx, fs, URL)
+	}
+	reader, err := fs.OpenURL(ctx, URL)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = reader.Close() }()
+	var source io.Reader = reader
+	if strings.HasSuffix(URL, gzipExt) {
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to create gzip reader for %v", URL)
+		}
+		defer func() { _ = gzReader.Close() }()
+		source = gzReader
+	}
+	count, err := countLines(source)
+	if err != nil {
+		return 0, err
+	}
+	if count -= int64(skipLeadingRows); count < 0 {
+		count = 0
+	}
+	return count, nil
+}
+
+//countLines returns non empty lines count
+func countLines(reader io.Reader) (int64, error) {
+	var count int64
+	buffer := make([]byte, 64*1024)
+	lineLength := 0
+	for {
+		n, err := reader.Read(buffer)
+		for _, b := range buffer[:n] {
+			if b == '\n' {
+				if lineLength > 0 {
+					count++
+				}
+				lineLength = 0
+				continue
+			}
+			if b != '\r' {
+				lineLength++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if lineLength > 0 {
+		count++
+	}
+	return count, nil
+}
+
+//countAvroRecords returns sum of avro object container file block counts
+func countAvroRecords(ctx context.Context, fs afs.Service, URL string) (int64, error) {
+	reader, err := fs.OpenURL(ctx, URL)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = reader.Close() }()
+	count, err := avroRecords(bufio.NewReader(reader))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid avro file: %v", URL)
+	}
+	return count, nil
+}
+
+func avroRecords(reader *bufio.Reader) (int64, error) {
+	magic := make([]byte, len(avroMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return 0, err
+	}
+	if string(magic) != avroMagic {
+		return 0, errors.New("invalid magic")
+	}
+	for { //header metadata map blocks
+		entries, err := binary.ReadVarint(reader)
+		if err != nil {
+			return 0, err
+		}
+		if entries == 0 {
+			break
+		}
+		if entries < 0 {
+			entries = -entries
+			if _, err = binary.ReadVarint(reader); err != nil {
+				return 0, err
+			}
+		}
+		for i := int64(0); i < 2*entries; i++ {
+			if err = skipAvroBytes(reader); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if _, err := reader.Discard(avroSyncSize); err != nil {
+		return 0, err
+	}
+	var count int64
+	for {
+		blockCount, err := binary.ReadVarint(reader)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		count += blockCount
+		if err = skipAvroBytes(reader); err != nil {
+			return 0, err
+		}
+		if _, err = reader.Discard(avroSyncSize); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func skipAvroBytes(reader *bufio.Reader) error {
+	size, err := binary.ReadVarint(reader)
+	if err != nil {
+		return err
+	}
+	if size < 0 {
+		return errors.Errorf("invalid size: %v", size)
+	}
+	_, err = reader.Discard(int(size))
+	return err
+}
+
+//countParquetRecords returns parquet footer FileMetaData.num_rows
+func countParquetRecords(ctx context.Context, fs afs.Service, URL string) (int64, error) {
+	reader, err := fs.OpenURL(ctx, URL)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = reader.Close() }()
+	tail, err := readTail(reader, parquetMaxTail)
+	if err != nil {
+		return 0, err
+	}
+	count, err := parquetTailNumRows(tail)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid parquet file: %v", URL)
+	}
+	return count, nil
+}
+
+//readTail returns up to max trailing bytes
+func readTail(reader io.Reader, max int) ([]byte, error) {
+	if seeker, ok := reader.(io.Seeker); ok {
+		size, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		offset := size - int64(max)
+		if offset < 0 {
+			offset = 0
+		}
+		if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(reader)
+	}
+	var result []byte
+	buffer := make([]byte, 64*1024)
+	for {
+		n, err := reader.Read(buffer)
+		result = append(result, buffer[:n]...)
+		if len(result) > 2*max {
+			result = append([]byte{}, result[len(result)-max:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(result) > max {
+		result = result[len(result)-max:]
+	}
+	return result, nil
+}
+
+//parquetTailNumRows returns num_rows from file tail with footer
+func parquetTailNumRows(tail []byte) (int64, error) {
+	if len(tail) < parquetFooterLen || string(tail[len(tail)-len(parquetMagic):]) != parquetMagic {
+		return 0, errors.New("missing footer")
+	}
+	footerEnd := len(tail) - parquetFooterLen
+	footerLen := int(binary.LittleEndian.Uint32(tail[footerEnd : footerEnd+4]))
+	if footerLen <= 0 || footerLen > footerEnd {
+		return 0, errors.Errorf("unsupported footer length: %v", footerLen)
+	}
+	return parquetNumRows(bufio.NewReader(bytes.NewReader(tail[footerEnd-footerLen : footerEnd])))
+}
+
+//parquetNumRows reads num_rows from thrift compact encoded FileMetaData
+func parquetNumRows(reader *bufio.Reader) (int64, error) {
+	fieldID := int16(0)
+	for {
+		header, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		fieldType := header & 0x0F
+		if fieldType == thriftStop {
+			return 0, errors.New("num_rows was missing")
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			fieldID += delta
+		} else {
+			id, err := binary.ReadVarint(reader)
+			if err != nil {
+				return 0, err
+			}
+			fieldID = int16(id)
+		}
+		if fieldID == parquetNumRowsField && fieldType == thriftI64 {
+			return binary.ReadVarint(reader)
+		}
+		if err = skipThrift(reader, fieldType); err != nil {
+			return 0, err
+		}
+	}
+}
+
+//thrift compact protocol types
+const (
+	thriftStop      = 0
+	thriftTrue      = 1
+	thriftFalse     = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+	thriftLongLists = 0x0F
+)
+
+func skipThrift(reader *bufio.Reader, fieldType byte) error {
+	var err error
+	switch fieldType {
+	case thriftTrue, thriftFalse:
+		return nil
+	case thriftByte:
+		_, err = reader.ReadByte()
+	case thriftI16, thriftI32, thriftI64:
+		_, err = binary.ReadVarint(reader)
+	case thriftDouble:
+		_, err = reader.Discard(8)
+	case thriftBinary:
+		var size uint64
+		if size, err = binary.ReadUvarint(reader); err == nil {
+			_, err = reader.Discard(int(size))
+		}
+	case thriftList, thriftSet:
+		var header byte
+		if header, err = reader.ReadByte(); err != nil {
+			return err
+		}
+		size := uint64(header >> 4)
+		if size == thriftLongLists {
+			if size, err = binary.ReadUvarint(reader); err != nil {
+				return err
+			}
+		}
+		elementType := header & 0x0F
+		for i := uint64(0); i < size; i++ {
+			if elementType == thriftTrue || elementType == thriftFalse {
+				_, err = reader.ReadByte()
+			} else {
+				err = skipThrift(reader, elementType)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case thriftMap:
+		var size uint64
+		if size, err = binary.ReadUvarint(reader); err != nil || size == 0 {
+			return err
+		}
+		var types byte
+		if types, err = reader.ReadByte(); err != nil {
+			return err
+		}
+		for i := uint64(0); i < size; i++ {
+			if err = skipThrift(reader, types>>4); err != nil {
+				return err
+			}
+			if err = skipThrift(reader, types&0x0F); err != nil {
+				return err
+			}
+		}
+	case thriftStruct:
+		for {
+			var header byte
+			if header, err = reader.ReadByte(); err != nil {
+				return err
+			}
+			if header&0x0F == thriftStop {
+				return nil
+			}
+			if header>>4 == 0 {
+				if _, err = binary.ReadVarint(reader); err != nil {
+					return err
+				}
+			}
+			if err = skipThrift(reader, header&0x0F); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("unsupported thrift type: %v", fieldType)
+	}
+	return err
+}
+
+//manifestRecords returns record count from sidecar manifest, JSON with Rows/Records/Count or plain number
+func manifestRecords(ctx context.Context, fs afs.Service, URL string) (int64, bool, error) {
+	ok, err := fs.Exists(ctx, URL)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	data, err := fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return 0, false, err
+	}
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, "{") {
+		count, err := toolbox.ToInt(text)
+		return int64(count), err == nil, err
+	}
+	manifest := map[string]interface{}{}
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return 0, false, errors.Wrapf(err, "invalid manifest: %v", URL)
+	}
+	for _, key := range []string{"Rows", "Records", "Count"} {
+		for k, value := range manifest {
+			if strings.EqualFold(k, key) {
+				count, err := toolbox.ToInt(value)
+				return int64(count), err == nil, err
+			}
+		}
+	}
+	return 0, false, errors.Errorf("invalid manifest: %v, expected Rows, Records or Count", URL)
+}
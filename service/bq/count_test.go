@@ -0,0 +1,169 @@
+package bq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"testing"
+)
+
+func TestCountRecords(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/reconcile/test"
+
+	compressed := new(bytes.Buffer)
+	gzWriter := gzip.NewWriter(compressed)
+	_, _ = gzWriter.Write([]byte("{\"id\":1}\n{\"id\":2}\n{\"id\":3}"))
+	_ = gzWriter.Close()
+
+	var useCases = []struct {
+		description     string
+		URL             string
+		data            []byte
+		format          string
+		skipLeadingRows int
+		expect          int64
+	}{
+		{
+			description: "NDJSON with trailing new line and empty line",
+			URL:         baseURL + "/data.json",
+			data:        []byte("{\"id\":1}\n\n{\"id\":2}\r\n"),
+			format:      "NEWLINE_DELIMITED_JSON",
+			expect:      2,
+		},
+		{
+			description:     "CSV with header",
+			URL:             baseURL + "/data.csv",
+			data:            []byte("id,name\n1,a\n2,b\n3,c"),
+			format:          "CSV",
+			skipLeadingRows: 1,
+			expect:          3,
+		},
+		{
+			description: "gzip NDJSON",
+			URL:         baseURL + "/data.json.gz",
+			data:        compressed.Bytes(),
+			format:      "NEWLINE_DELIMITED_JSON",
+			expect:      3,
+		},
+		{
+			description: "AVRO block counts",
+			URL:         baseURL + "/data.avro",
+			data:        avroFile(3, 2),
+			format:      "AVRO",
+			expect:      5,
+		},
+		{
+			description: "PARQUET footer num rows",
+			URL:         baseURL + "/data.parquet",
+			data:        parquetFile(42),
+			format:      "PARQUET",
+			expect:      42,
+		},
+	}
+
+	for _, useCase := range useCases {
+		err := fs.Upload(ctx, useCase.URL, file.DefaultFileOsMode, bytes.NewReader(useCase.data))
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		actual, err := countRecords(ctx, fs, useCase.URL, useCase.format, useCase.skipLeadingRows)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expect, actual, useCase.description)
+	}
+
+	manifestURL := baseURL + "/data.csv.manifest"
+	_ = fs.Upload(ctx, manifestURL, file.DefaultFileOsMode, bytes.NewReader([]byte(`{"rows": 7}`)))
+	count, ok, err := manifestRecords(ctx, fs, manifestURL)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, 7, count)
+	_, ok, err = manifestRecords(ctx, fs, baseURL+"/missing.manifest")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestReconciliation_Error(t *testing.T) {
+	copied, lost := int64(95), int64(90)
+	var useCases = []struct {
+		description    string
+		reconciliation *Reconciliation
+		tolerance      float64
+		expectError    bool
+	}{
+		{
+			description:    "all rows loaded",
+			reconciliation: &Reconciliation{SourceRecords: 100, OutputRows: 95, BadRecords: 5, CopiedRows: &copied},
+		},
+		{
+			description:    "missing load rows",
+			reconciliation: &Reconciliation{SourceRecords: 100, OutputRows: 95},
+			expectError:    true,
+		},
+		{
+			description:    "missing load rows within tolerance",
+			reconciliation: &Reconciliation{SourceRecords: 100, OutputRows: 95},
+			tolerance:      0.05,
+		},
+		{
+			description:    "missing copied rows",
+			reconciliation: &Reconciliation{SourceRecords: 100, OutputRows: 95, BadRecords: 5, CopiedRows: &lost},
+			tolerance:      0.01,
+			expectError:    true,
+		},
+	}
+
+	for _, useCase := range useCases {
+		err := useCase.reconciliation.Error(useCase.tolerance)
+		assert.EqualValues(t, useCase.expectError, err != nil, useCase.description)
+	}
+}
+
+func avroFile(blockCounts ...int64) []byte {
+	buffer := new(bytes.Buffer)
+	writeLong := func(value int64) {
+		data := make([]byte, binary.MaxVarintLen64)
+		buffer.Write(data[:binary.PutVarint(data, value)])
+	}
+	writeBytes := func(value string) {
+		writeLong(int64(len(value)))
+		buffer.WriteString(value)
+	}
+	sync := bytes.Repeat([]byte{0xAB}, avroSyncSize)
+	buffer.WriteString(avroMagic)
+	writeLong(1)
+	writeBytes("avro.codec")
+	writeBytes("null")
+	writeLong(0)
+	buffer.Write(sync)
+	for _, count := range blockCounts {
+		writeLong(count)
+		writeBytes("xy")
+		buffer.Write(sync)
+	}
+	return buffer.Bytes()
+}
+
+func parquetFile(numRows int64) []byte {
+	footer := []byte{
+		0x15, 0x02, //version: 1
+		0x19, 0x1C, 0x18, 0x04, 'r', 'o', 'o', 't', 0x00, //schema: [{name: root}]
+		0x16, //num_rows
+	}
+	data := make([]byte, binary.MaxVarintLen64)
+	footer = append(footer, data[:binary.PutVarint(data, numRows)]...)
+	footer = append(footer, 0x00)
+	result := append([]byte(parquetMagic), []byte("column data")...)
+	result = append(result, footer...)
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(footer)))
+	result = append(result, length...)
+	return append(result, []byte(parquetMagic)...)
+}
@@ -0,0 +1,41 @@
+package emulator
+
+import (
+	"context"
+	"google.golang.org/api/bigquery/v2"
+	"net/http"
+)
+
+func (e *Emulator) runCopy(ctx context.Context, job *bigquery.Job) error {
+	config := job.Configuration.Copy
+	sources := config.SourceTables
+	if config.SourceTable != nil {
+		sources = append(sources, config.SourceTable)
+	}
+	if len(sources) == 0 || config.DestinationTable == nil {
+		return newError(http.StatusBadRequest, "invalid", "source or destination table was empty")
+	}
+	var fields []*bigquery.TableFieldSchema
+	var rows = make([]map[string]interface{}, 0)
+	for _, source := range sources {
+		table, err := e.getTable(ctx, source)
+		if err != nil {
+			return err
+		}
+		fields, _ = mergeSchema(fields, table.Schema.Fields)
+		sourceRows, err := e.readRows(ctx, source)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, sourceRows...)
+	}
+	writeDisposition := config.WriteDisposition
+	if writeDisposition == "" {
+		writeDisposition = "WRITE_EMPTY"
+	}
+	if err := e.writeTable(ctx, config.DestinationTable, fields, rows, config.CreateDisposition, writeDisposition, nil); err != nil {
+		return err
+	}
+	job.Statistics.Copy = &bigquery.JobStatistics5{CopiedRows: int64(len(rows)), CopiedLogicalBytes: rowsSize(rows)}
+	return nil
+}
@@ -0,0 +1,192 @@
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/viant/afs"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	//Endpoint emulator BigQuery API endpoint
+	Endpoint  = "http://bqemulator/bigquery/v2/"
+	basePath  = "/bigquery/v2/"
+	jobFolder = "_jobs"
+)
+
+//Emulator represents an in-process BigQuery REST API emulator, datasets, tables, rows and jobs are stored with afs under baseURL
+type Emulator struct {
+	baseURL string
+	fs      afs.Service
+	mux     sync.Mutex
+	running sync.WaitGroup
+}
+
+//BigQuery returns BigQuery API service that sends all requests to the emulator
+func (e *Emulator) BigQuery(ctx context.Context) (*bigquery.Service, error) {
+	client := &http.Client{Transport: e}
+	return bigquery.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(Endpoint))
+}
+
+//Service returns bq service backed by the emulator
+func (e *Emulator) Service(ctx context.Context, registry task.Registry, projectID string, storageService afs.Service, config base.Config) (bq.Service, error) {
+	bqService, err := e.BigQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bq.New(bqService, registry, projectID, storageService, config), nil
+}
+
+//Wait waits for all submitted jobs to complete
+func (e *Emulator) Wait() {
+	e.running.Wait()
+}
+
+//RoundTrip serves http request in process
+func (e *Emulator) RoundTrip(request *http.Request) (*http.Response, error) {
+	writer := newResponseWriter()
+	e.ServeHTTP(writer, request)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", writer.status, http.StatusText(writer.status)),
+		StatusCode:    writer.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        writer.header,
+		Body:          ioutil.NopCloser(bytes.NewReader(writer.body.Bytes())),
+		ContentLength: int64(writer.body.Len()),
+		Request:       request,
+	}, nil
+}
+
+//ServeHTTP serves BigQuery REST API request
+func (e *Emulator) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	URLPath := request.URL.Path
+	if index := strings.Index(URLPath, basePath); index != -1 {
+		URLPath = URLPath[index+len(basePath):]
+	}
+	elements := strings.Split(strings.Trim(URLPath, "/"), "/")
+	if len(elements) < 3 || elements[0] != "projects" {
+		writeError(writer, newError(http.StatusNotFound, "notFound", "Not found: %v", request.URL.Path))
+		return
+	}
+	projectID := elements[1]
+	var result interface{}
+	var err error
+	switch elements[2] {
+	case "jobs":
+		result, err = e.serveJobs(ctx, request, projectID, elements[3:])
+	case "queries":
+		if len(elements) != 4 {
+			err = newError(http.StatusNotFound, "notFound", "Not found: %v", request.URL.Path)
+			break
+		}
+		result, err = e.getQueryResults(ctx, projectID, elements[3])
+	case "datasets":
+		result, err = e.serveDatasets(ctx, request, projectID, elements[3:])
+	default:
+		err = newError(http.StatusNotFound, "notFound", "Not found: %v", request.URL.Path)
+	}
+	if err != nil {
+		writeError(writer, err)
+		return
+	}
+	writeJSON(writer, http.StatusOK, result)
+}
+
+func (e *Emulator) serveJobs(ctx context.Context, request *http.Request, projectID string, elements []string) (interface{}, error) {
+	switch len(elements) {
+	case 0:
+		if request.Method == http.MethodPost {
+			job := &bigquery.Job{}
+			if err := decodeBody(request, job); err != nil {
+				return nil, err
+			}
+			return e.insertJob(ctx, projectID, job)
+		}
+		return e.listJobs(ctx, projectID, request.URL.Query())
+	case 1:
+		return e.getJob(ctx, projectID, elements[0])
+	}
+	return nil, newError(http.StatusNotFound, "notFound", "Not found: %v", request.URL.Path)
+}
+
+func (e *Emulator) serveDatasets(ctx context.Context, request *http.Request, projectID string, elements []string) (interface{}, error) {
+	switch len(elements) {
+	case 0:
+		if request.Method != http.MethodPost {
+			break
+		}
+		dataset := &bigquery.Dataset{}
+		if err := decodeBody(request, dataset); err != nil {
+			return nil, err
+		}
+		return e.insertDataset(ctx, projectID, dataset)
+	case 1:
+		if request.Method == http.MethodGet {
+			return e.getDataset(ctx, projectID, elements[0])
+		}
+	case 2:
+		if elements[1] == "tables" && request.Method == http.MethodPost {
+			table := &bigquery.Table{}
+			if err := decodeBody(request, table); err != nil {
+				return nil, err
+			}
+			return e.insertTable(ctx, projectID, elements[0], table)
+		}
+	case 3:
+		ref := &bigquery.TableReference{ProjectId: projectID, DatasetId: elements[0], TableId: elements[2]}
+		switch request.Method {
+		case http.MethodGet:
+			return e.getTable(ctx, ref)
+		case http.MethodPatch, http.MethodPut:
+			table := &bigquery.Table{}
+			if err := decodeBody(request, table); err != nil {
+				return nil, err
+			}
+			return e.patchTable(ctx, ref, table)
+		case http.MethodDelete:
+			return nil, e.deleteTable(ctx, ref)
+		}
+	case 4:
+		if elements[3] == "insertAll" {
+			ref := &bigquery.TableReference{ProjectId: projectID, DatasetId: elements[0], TableId: elements[2]}
+			insertRequest := &bigquery.TableDataInsertAllRequest{}
+			if err := decodeBody(request, insertRequest); err != nil {
+				return nil, err
+			}
+			return e.insertAll(ctx, ref, insertRequest)
+		}
+	}
+	return nil, newError(http.StatusNotFound, "notFound", "Not found: %v", request.URL.Path)
+}
+
+func decodeBody(request *http.Request, target interface{}) error {
+	if request.Body == nil {
+		return newError(http.StatusBadRequest, "invalid", "request body was empty")
+	}
+	defer request.Body.Close()
+	if err := json.NewDecoder(request.Body).Decode(target); err != nil {
+		return newError(http.StatusBadRequest, "invalid", "failed to decode %T: %v", target, err)
+	}
+	return nil
+}
+
+//New creates an emulator storing its state under baseURL
+func New(baseURL string, fs afs.Service) *Emulator {
+	return &Emulator{
+		baseURL: baseURL,
+		fs:      fs,
+	}
+}
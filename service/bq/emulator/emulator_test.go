@@ -0,0 +1,108 @@
+package emulator
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage"
+	"github.com/viant/bqtail/stage/activity"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmulator_Service(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/emulator/service"
+	_ = fs.Upload(ctx, baseURL+"/data/events1.json", file.DefaultFileOsMode, strings.NewReader("{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n"))
+	_ = fs.Upload(ctx, baseURL+"/data/events2.json", file.DefaultFileOsMode, strings.NewReader("{\"id\":3,\"name\":\"c\"}\n"))
+	_ = fs.Upload(ctx, baseURL+"/data/invalid.json", file.DefaultFileOsMode, strings.NewReader("{\"id\":4,\"xname\":\"d\"}\n"))
+
+	emulator := New(baseURL+"/bq", fs)
+	service, err := emulator.Service(ctx, task.NewRegistry(), "myproject", fs, base.Config{ProjectID: "myproject", ErrorURL: baseURL + "/errors"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = service.CreateDatasetIfNotExist(ctx, "", &bigquery.DatasetReference{DatasetId: "mydataset"})
+	assert.Nil(t, err)
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "STRING"}}}
+	err = service.CreateTableIfNotExist(ctx, &bigquery.Table{TableReference: &bigquery.TableReference{DatasetId: "mydataset", TableId: "events"}, Schema: schema}, false)
+	assert.Nil(t, err)
+
+	var useCases = []struct {
+		description string
+		run         func(action *task.Action) (*bigquery.Job, error)
+		table       string
+		expectRows  uint64
+		hasJobError bool
+	}{
+		{
+			description: "load job",
+			run: func(action *task.Action) (*bigquery.Job, error) {
+				return service.Load(ctx, &bq.LoadRequest{JobConfigurationLoad: &bigquery.JobConfigurationLoad{
+					DestinationTable: &bigquery.TableReference{DatasetId: "mydataset", TableId: "events"},
+					SourceUris:       []string{baseURL + "/data/events1.json", baseURL + "/data/events2.json"},
+				}}, action)
+			},
+			table:      "mydataset.events",
+			expectRows: 3,
+		},
+		{
+			description: "load job with invalid schema",
+			run: func(action *task.Action) (*bigquery.Job, error) {
+				return service.Load(ctx, &bq.LoadRequest{JobConfigurationLoad: &bigquery.JobConfigurationLoad{
+					DestinationTable: &bigquery.TableReference{DatasetId: "mydataset", TableId: "events"},
+					SourceUris:       []string{baseURL + "/data/invalid.json"},
+				}}, action)
+			},
+			table:       "mydataset.events",
+			expectRows:  3,
+			hasJobError: true,
+		},
+		{
+			description: "copy job",
+			run: func(action *task.Action) (*bigquery.Job, error) {
+				return service.Copy(ctx, &bq.CopyRequest{Source: "mydataset.events", Dest: "mydataset.events_copy", Append: true}, action)
+			},
+			table:      "mydataset.events_copy",
+			expectRows: 3,
+		},
+		{
+			description: "query job",
+			run: func(action *task.Action) (*bigquery.Job, error) {
+				dest, _ := base.NewTableReference("mydataset.events_query")
+				return service.Query(ctx, &bq.QueryRequest{SQL: "SELECT * FROM mydataset.events", Append: true, Dest: "mydataset.events_query", DatasetID: dest.DatasetId}, action)
+			},
+			table:      "mydataset.events_query",
+			expectRows: 3,
+		},
+	}
+
+	for i, useCase := range useCases {
+		action := &task.Action{Meta: activity.New(&stage.Process{DestTable: "mydataset.events", EventID: "123"}, "test", shared.StepModeTail, i+1), Actions: &task.Actions{}}
+		job, err := useCase.run(action)
+		if useCase.hasJobError {
+			assert.NotNil(t, err, useCase.description)
+		} else if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.Equal(t, useCase.hasJobError, base.JobError(job) != nil, useCase.description)
+		ref, _ := base.NewTableReference(useCase.table)
+		table, err := service.Table(ctx, ref)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectRows, table.NumRows, useCase.description)
+	}
+
+	jobs, err := service.ListJob(ctx, "myproject", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "done")
+	assert.Nil(t, err)
+	assert.Equal(t, len(useCases), len(jobs))
+}
@@ -0,0 +1,65 @@
+package emulator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/viant/afs/file"
+	"google.golang.org/api/bigquery/v2"
+	"net/http"
+	"strings"
+)
+
+func (e *Emulator) runExtract(ctx context.Context, job *bigquery.Job) error {
+	config := job.Configuration.Extract
+	if config.SourceTable == nil || len(config.DestinationUris) == 0 {
+		return newError(http.StatusBadRequest, "invalid", "source table or destination URIs were empty")
+	}
+	table, err := e.getTable(ctx, config.SourceTable)
+	if err != nil {
+		return err
+	}
+	rows, err := e.readRows(ctx, config.SourceTable)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch strings.ToUpper(config.DestinationFormat) {
+	case "NEWLINE_DELIMITED_JSON":
+		buffer := new(bytes.Buffer)
+		for _, row := range rows {
+			encoded, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			buffer.Write(encoded)
+			buffer.WriteByte('\n')
+		}
+		data = buffer.Bytes()
+	case "", "CSV":
+		withHeader := config.PrintHeader == nil || *config.PrintHeader
+		if data, err = encodeCSV(rows, table.Schema.Fields, withHeader); err != nil {
+			return err
+		}
+	default:
+		return newError(http.StatusBadRequest, "invalid", "unsupported destination format: %v", config.DestinationFormat)
+	}
+	if strings.ToUpper(config.Compression) == "GZIP" {
+		buffer := new(bytes.Buffer)
+		writer := gzip.NewWriter(buffer)
+		if _, err = writer.Write(data); err != nil {
+			return err
+		}
+		if err = writer.Close(); err != nil {
+			return err
+		}
+		data = buffer.Bytes()
+	}
+	URL := strings.Replace(config.DestinationUris[0], "*", "000000000000", 1)
+	if err = e.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	job.Statistics.Extract = &bigquery.JobStatistics4{DestinationUriFileCounts: []int64{1}, InputBytes: rowsSize(rows)}
+	return nil
+}
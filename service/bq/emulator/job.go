@@ -0,0 +1,238 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"github.com/viant/toolbox"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	stateDone    = "DONE"
+	stateRunning = "RUNNING"
+	statePending = "PENDING"
+)
+
+func (e *Emulator) insertJob(ctx context.Context, projectID string, job *bigquery.Job) (*bigquery.Job, error) {
+	if job.Configuration == nil {
+		return nil, newError(http.StatusBadRequest, "invalid", "job configuration was empty")
+	}
+	if job.JobReference == nil {
+		job.JobReference = &bigquery.JobReference{}
+	}
+	ref := job.JobReference
+	if ref.ProjectId == "" {
+		ref.ProjectId = projectID
+	}
+	if ref.JobId == "" {
+		ref.JobId = fmt.Sprintf("job_%v", time.Now().UnixNano())
+	}
+	if ref.Location == "" {
+		ref.Location = defaultLocation
+	}
+	e.mux.Lock()
+	URL := e.jobURL(ref.ProjectId, ref.JobId)
+	if e.exists(ctx, URL) {
+		e.mux.Unlock()
+		return nil, newError(http.StatusConflict, "duplicate", "Already Exists: Job %v:%v.%v", ref.ProjectId, ref.Location, ref.JobId)
+	}
+	job.Id = fmt.Sprintf("%v:%v.%v", ref.ProjectId, ref.Location, ref.JobId)
+	job.Kind = "bigquery#job"
+	job.Configuration.JobType = jobType(job.Configuration)
+	job.Status = &bigquery.JobStatus{State: statePending}
+	job.Statistics = &bigquery.JobStatistics{CreationTime: asMillis(time.Now())}
+	if err := e.save(ctx, URL, job); err != nil {
+		e.mux.Unlock()
+		return nil, err
+	}
+	e.running.Add(1)
+	//lock is handed over to the job runner, any subsequent call waits for the job completion
+	go e.runJob(context.Background(), *job)
+	return job, nil
+}
+
+//getJob returns a job, since running job holds a lock, the call waits for the currently executed job
+func (e *Emulator) getJob(ctx context.Context, projectID, jobID string) (*bigquery.Job, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	job := &bigquery.Job{}
+	found, err := e.load(ctx, e.jobURL(projectID, jobID), job)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newError(http.StatusNotFound, "notFound", "Not found: Job %v:%v", projectID, jobID)
+	}
+	return job, nil
+}
+
+func (e *Emulator) listJobs(ctx context.Context, projectID string, query url.Values) (*bigquery.JobList, error) {
+	var states = make(map[string]bool)
+	for _, state := range query["stateFilter"] {
+		states[strings.ToUpper(state)] = true
+	}
+	minCreationTime := int64(toolbox.AsInt(query.Get("minCreationTime")))
+	maxCreationTime := int64(toolbox.AsInt(query.Get("maxCreationTime")))
+	result := &bigquery.JobList{Kind: "bigquery#jobList", Jobs: make([]*bigquery.JobListJobs, 0)}
+	objects, err := e.fs.List(ctx, e.jobsURL(projectID))
+	if err != nil {
+		return result, nil
+	}
+	for _, object := range objects {
+		if object.IsDir() {
+			continue
+		}
+		job := &bigquery.Job{}
+		if _, err := e.load(ctx, object.URL(), job); err != nil {
+			return nil, err
+		}
+		if len(states) > 0 && !states[job.Status.State] {
+			continue
+		}
+		created := job.Statistics.CreationTime
+		if (minCreationTime > 0 && created < minCreationTime) || (maxCreationTime > 0 && created > maxCreationTime) {
+			continue
+		}
+		result.Jobs = append(result.Jobs, &bigquery.JobListJobs{
+			Id:            job.Id,
+			Kind:          job.Kind,
+			JobReference:  job.JobReference,
+			Configuration: job.Configuration,
+			State:         job.Status.State,
+			Status:        job.Status,
+			ErrorResult:   job.Status.ErrorResult,
+			Statistics:    job.Statistics,
+		})
+	}
+	return result, nil
+}
+
+func (e *Emulator) getQueryResults(ctx context.Context, projectID, jobID string) (*bigquery.GetQueryResultsResponse, error) {
+	job, err := e.waitForJob(ctx, projectID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	response := &bigquery.GetQueryResultsResponse{
+		Kind:         "bigquery#getQueryResultsResponse",
+		JobReference: job.JobReference,
+		JobComplete:  true,
+		Rows:         make([]*bigquery.TableRow, 0),
+	}
+	if job.Status.ErrorResult != nil {
+		response.Errors = job.Status.Errors
+		return response, nil
+	}
+	if job.Configuration.Query == nil || job.Configuration.Query.DestinationTable == nil {
+		return response, nil
+	}
+	table, err := e.getTable(ctx, job.Configuration.Query.DestinationTable)
+	if err != nil {
+		return response, nil
+	}
+	rows, err := e.readRows(ctx, job.Configuration.Query.DestinationTable)
+	if err != nil {
+		return nil, err
+	}
+	response.Schema = table.Schema
+	response.TotalRows = uint64(len(rows))
+	for _, row := range rows {
+		response.Rows = append(response.Rows, asTableRow(row, table.Schema.Fields))
+	}
+	return response, nil
+}
+
+func (e *Emulator) waitForJob(ctx context.Context, projectID, jobID string) (*bigquery.Job, error) {
+	for {
+		job, err := e.getJob(ctx, projectID, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status.State == stateDone {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+//runJob runs a job, caller has to hold a lock which is released once job is completed
+func (e *Emulator) runJob(ctx context.Context, job bigquery.Job) {
+	defer e.running.Done()
+	defer e.mux.Unlock()
+	URL := e.jobURL(job.JobReference.ProjectId, job.JobReference.JobId)
+	job.Status = &bigquery.JobStatus{State: stateRunning}
+	job.Statistics.StartTime = asMillis(time.Now())
+	_ = e.save(ctx, URL, &job)
+	var err error
+	switch job.Configuration.JobType {
+	case "LOAD":
+		err = e.runLoad(ctx, &job)
+	case "COPY":
+		err = e.runCopy(ctx, &job)
+	case "QUERY":
+		err = e.runQuery(ctx, &job)
+	case "EXTRACT":
+		err = e.runExtract(ctx, &job)
+	default:
+		err = newError(http.StatusBadRequest, "invalid", "unsupported job type: %v", job.Configuration.JobType)
+	}
+	job.Status.State = stateDone
+	job.Statistics.EndTime = asMillis(time.Now())
+	if err != nil {
+		setJobError(&job, err)
+	}
+	_ = e.save(ctx, URL, &job)
+}
+
+func setJobError(job *bigquery.Job, err error) {
+	if jobErr, ok := err.(*jobError); ok {
+		job.Status.ErrorResult = jobErr.result
+		job.Status.Errors = append(jobErr.errors, jobErr.result)
+		return
+	}
+	result := &bigquery.ErrorProto{Reason: "invalid", Message: err.Error()}
+	if apiError, ok := err.(*googleapi.Error); ok {
+		result.Message = apiError.Message
+		if len(apiError.Errors) > 0 {
+			result.Reason = apiError.Errors[0].Reason
+		}
+	}
+	job.Status.ErrorResult = result
+	job.Status.Errors = []*bigquery.ErrorProto{result}
+}
+
+//jobError represents job error with individual errors
+type jobError struct {
+	result *bigquery.ErrorProto
+	errors []*bigquery.ErrorProto
+}
+
+func (e *jobError) Error() string {
+	return e.result.Message
+}
+
+func jobType(config *bigquery.JobConfiguration) string {
+	switch {
+	case config.Load != nil:
+		return "LOAD"
+	case config.Copy != nil:
+		return "COPY"
+	case config.Query != nil:
+		return "QUERY"
+	case config.Extract != nil:
+		return "EXTRACT"
+	}
+	return config.JobType
+}
+
+func asMillis(ts time.Time) int64 {
+	return ts.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,159 @@
+package emulator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/bigquery/v2"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//sourceRecord represents a decoded record with its source location
+type sourceRecord struct {
+	URI    string
+	Offset int
+	Record map[string]interface{}
+}
+
+func (e *Emulator) runLoad(ctx context.Context, job *bigquery.Job) error {
+	config := job.Configuration.Load
+	stats := &bigquery.JobStatistics3{}
+	job.Statistics.Load = stats
+	if config.DestinationTable == nil {
+		return newError(http.StatusBadRequest, "invalid", "destinationTable was empty")
+	}
+	var fields []*bigquery.TableFieldSchema
+	if config.Schema != nil {
+		fields = config.Schema.Fields
+	}
+	if len(fields) == 0 {
+		if table, err := e.getTable(ctx, config.DestinationTable); err == nil {
+			fields = table.Schema.Fields
+		}
+	}
+	var records = make([]*sourceRecord, 0)
+	var loadErrors = make([]*bigquery.ErrorProto, 0)
+	for _, URI := range config.SourceUris {
+		data, err := e.fs.DownloadWithURL(ctx, URI)
+		if err != nil {
+			return newError(http.StatusNotFound, "notFound", "Not found: URI %v", URI)
+		}
+		stats.InputFiles++
+		stats.InputFileBytes += int64(len(data))
+		if data, err = uncompress(data); err != nil {
+			loadErrors = append(loadErrors, readError(URI, err))
+			continue
+		}
+		switch strings.ToUpper(config.SourceFormat) {
+		case "NEWLINE_DELIMITED_JSON":
+			fileRecords, err := decodeJSON(URI, data)
+			if err != nil {
+				loadErrors = append(loadErrors, readError(URI, err))
+			}
+			records = append(records, fileRecords...)
+		case "", "CSV":
+			fileRecords, err := decodeCSV(data, config, fields)
+			if err != nil {
+				loadErrors = append(loadErrors, readError(URI, err))
+				continue
+			}
+			for i := range fileRecords {
+				records = append(records, &sourceRecord{URI: URI, Offset: i, Record: fileRecords[i]})
+			}
+		default:
+			return newError(http.StatusBadRequest, "invalid", "unsupported source format: %v", config.SourceFormat)
+		}
+	}
+	if len(fields) == 0 {
+		if !config.Autodetect && strings.ToUpper(config.SourceFormat) == "NEWLINE_DELIMITED_JSON" {
+			return newError(http.StatusBadRequest, "invalid", "No schema specified on job or table.")
+		}
+		var candidates = make([]map[string]interface{}, len(records))
+		for i := range records {
+			candidates[i] = records[i].Record
+		}
+		fields = InferSchema(candidates)
+	}
+	var rows = make([]map[string]interface{}, 0)
+	var outputBytes = 0
+	for _, record := range records {
+		if err := validateRecord(record.Record, fields, config.IgnoreUnknownValues); err != nil {
+			loadErrors = append(loadErrors, readError(record.URI, fmt.Errorf("JSON parsing error in row starting at position %v: %v. File: %v", record.Offset, err, record.URI)))
+			continue
+		}
+		if data, err := json.Marshal(record.Record); err == nil {
+			outputBytes += len(data)
+		}
+		rows = append(rows, record.Record)
+	}
+	stats.BadRecords = int64(len(loadErrors))
+	if len(loadErrors) > int(config.MaxBadRecords) {
+		return &jobError{
+			result: &bigquery.ErrorProto{
+				Reason:  "invalid",
+				Message: fmt.Sprintf("Error while reading data, error message: table encountered too many errors, giving up. Rows: %v; errors: %v. Please look into the errors[] collection for more details.", len(records), len(loadErrors)),
+			},
+			errors: loadErrors,
+		}
+	}
+	writeDisposition := config.WriteDisposition
+	if writeDisposition == "" {
+		writeDisposition = "WRITE_APPEND"
+	}
+	if err := e.writeTable(ctx, config.DestinationTable, fields, rows, config.CreateDisposition, writeDisposition, config.SchemaUpdateOptions); err != nil {
+		return err
+	}
+	stats.OutputRows = int64(len(rows))
+	stats.OutputBytes = int64(outputBytes)
+	return nil
+}
+
+func readError(URI string, err error) *bigquery.ErrorProto {
+	message := err.Error()
+	if !strings.Contains(message, "File:") {
+		message += " File: " + URI
+	}
+	return &bigquery.ErrorProto{
+		Reason:   "invalid",
+		Location: URI,
+		Message:  "Error while reading data, error message: " + message,
+	}
+}
+
+func decodeJSON(URI string, data []byte) ([]*sourceRecord, error) {
+	var result = make([]*sourceRecord, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	offset := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		position := offset
+		offset += len(line) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return result, fmt.Errorf("JSON parsing error in row starting at position %v: %v", position, err)
+		}
+		result = append(result, &sourceRecord{URI: URI, Offset: position, Record: record})
+	}
+	return result, scanner.Err()
+}
+
+func uncompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
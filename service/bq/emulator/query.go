@@ -0,0 +1,169 @@
+package emulator
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/viant/bqtail/base"
+	"google.golang.org/api/bigquery/v2"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const anonymousDataset = "_emulator_anonymous"
+
+var fromExpr = regexp.MustCompile("(?is)\\b(?:FROM|USING)\\s+`?([\\w\\-]+(?:[:.][\\w\\-]+){1,2}(?:\\$\\w+)?)`?")
+var alterColumnExpr = regexp.MustCompile("(?is)^\\s*ALTER\\s+TABLE\\s+`?([\\w\\-]+(?:[:.][\\w\\-]+){1,2})`?\\s+ALTER\\s+COLUMN\\s+`?(\\w+)`?\\s+SET\\s+DATA\\s+TYPE\\s+(\\w+)")
+var targetExpr = regexp.MustCompile("(?is)^\\s*(?:INSERT|MERGE|DELETE|UPDATE)\\s+(?:INTO\\s+|FROM\\s+)?`?([\\w\\-]+(?:[:.][\\w\\-]+){1,2}(?:\\$\\w+)?)`?")
+
+//runQuery emulates query job: the first table referenced in FROM (or USING) clause is the query source and all its rows are projected (SELECT * semantics);
+//INSERT and MERGE append source rows to the target table, DELETE and UPDATE do not modify any rows, ALTER TABLE supports ALTER COLUMN SET DATA TYPE only.
+func (e *Emulator) runQuery(ctx context.Context, job *bigquery.Job) error {
+	config := job.Configuration.Query
+	SQL := strings.TrimSpace(config.Query)
+	statementType := strings.ToUpper(strings.SplitN(SQL+" ", " ", 2)[0])
+	if statementType == "WITH" || statementType == "(" {
+		statementType = "SELECT"
+	}
+	stats := &bigquery.JobStatistics2{StatementType: statementType}
+	job.Statistics.Query = stats
+	if statementType == "ALTER" {
+		return e.alterColumn(ctx, SQL, job.JobReference.ProjectId, config.DefaultDataset)
+	}
+
+	var fields []*bigquery.TableFieldSchema
+	var rows = make([]map[string]interface{}, 0)
+	if sourceRef := e.sourceTable(SQL, job.JobReference.ProjectId, config.DefaultDataset); sourceRef != nil {
+		table, err := e.getTable(ctx, sourceRef)
+		if err != nil {
+			return newError(http.StatusNotFound, "notFound", "Not found: Table %v was not found in location %v", base.EncodeTableReference(sourceRef, false), job.JobReference.Location)
+		}
+		fields = table.Schema.Fields
+		if rows, err = e.readRows(ctx, sourceRef); err != nil {
+			return err
+		}
+	}
+	stats.TotalBytesProcessed = rowsSize(rows)
+	job.Statistics.TotalBytesProcessed = stats.TotalBytesProcessed
+	job.Statistics.TotalSlotMs = int64(len(rows))
+
+	switch statementType {
+	case "SELECT":
+		destination := config.DestinationTable
+		if destination == nil {
+			var err error
+			if destination, err = e.anonymousTable(ctx, job.JobReference); err != nil {
+				return err
+			}
+			config.DestinationTable = destination
+		}
+		writeDisposition := config.WriteDisposition
+		if writeDisposition == "" {
+			writeDisposition = "WRITE_EMPTY"
+		}
+		return e.writeTable(ctx, destination, fields, rows, config.CreateDisposition, writeDisposition, config.SchemaUpdateOptions)
+	case "INSERT", "MERGE":
+		targetRef := e.targetTable(SQL, job.JobReference.ProjectId, config.DefaultDataset)
+		if targetRef == nil {
+			return newError(http.StatusBadRequest, "invalid", "unable to resolve target table: %v", SQL)
+		}
+		target, err := e.getTable(ctx, targetRef)
+		if err != nil {
+			return err
+		}
+		index := indexFields(target.Schema.Fields)
+		for _, row := range rows {
+			for key := range row {
+				if _, ok := index[key]; !ok {
+					delete(row, key)
+				}
+			}
+		}
+		if err = e.appendRows(ctx, target, targetRef, rows); err != nil {
+			return err
+		}
+		stats.NumDmlAffectedRows = int64(len(rows))
+	}
+	return nil
+}
+
+//alterColumn changes column data type, caller has to hold a lock
+func (e *Emulator) alterColumn(ctx context.Context, SQL string, projectID string, dataset *bigquery.DatasetReference) error {
+	matched := alterColumnExpr.FindStringSubmatch(SQL)
+	if len(matched) < 4 {
+		return newError(http.StatusBadRequest, "invalidQuery", "unsupported DDL: %v", SQL)
+	}
+	ref := resolveTable(matched[1], projectID, dataset)
+	if ref == nil {
+		return newError(http.StatusBadRequest, "invalidQuery", "invalid table: %v", matched[1])
+	}
+	table, err := e.getTable(ctx, ref)
+	if err != nil {
+		return err
+	}
+	field, ok := indexFields(table.Schema.Fields)[matched[2]]
+	if !ok {
+		return newError(http.StatusBadRequest, "invalidQuery", "Column %v not found in table %v", matched[2], matched[1])
+	}
+	field.Type = strings.ToUpper(matched[3])
+	table.LastModifiedTime = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	return e.save(ctx, e.tableURL(ref), table)
+}
+
+func (e *Emulator) anonymousTable(ctx context.Context, jobRef *bigquery.JobReference) (*bigquery.TableReference, error) {
+	URL := e.datasetURL(jobRef.ProjectId, anonymousDataset)
+	if !e.exists(ctx, URL) {
+		dataset := &bigquery.Dataset{
+			Id:               jobRef.ProjectId + ":" + anonymousDataset,
+			DatasetReference: &bigquery.DatasetReference{ProjectId: jobRef.ProjectId, DatasetId: anonymousDataset},
+			Location:         jobRef.Location,
+			CreationTime:     asMillis(time.Now()),
+		}
+		if err := e.save(ctx, URL, dataset); err != nil {
+			return nil, err
+		}
+	}
+	tableID := "anon" + strings.Replace(jobRef.JobId, "-", "_", -1)
+	return &bigquery.TableReference{ProjectId: jobRef.ProjectId, DatasetId: anonymousDataset, TableId: tableID}, nil
+}
+
+func (e *Emulator) sourceTable(SQL string, projectID string, dataset *bigquery.DatasetReference) *bigquery.TableReference {
+	matched := fromExpr.FindStringSubmatch(SQL)
+	if len(matched) < 2 {
+		return nil
+	}
+	return resolveTable(matched[1], projectID, dataset)
+}
+
+func (e *Emulator) targetTable(SQL string, projectID string, dataset *bigquery.DatasetReference) *bigquery.TableReference {
+	matched := targetExpr.FindStringSubmatch(SQL)
+	if len(matched) < 2 {
+		return nil
+	}
+	return resolveTable(matched[1], projectID, dataset)
+}
+
+func resolveTable(table string, projectID string, dataset *bigquery.DatasetReference) *bigquery.TableReference {
+	if !strings.Contains(table, ".") && dataset != nil {
+		table = dataset.DatasetId + "." + table
+	}
+	ref, err := base.NewTableReference(table)
+	if err != nil {
+		return nil
+	}
+	if ref.ProjectId == "" {
+		ref.ProjectId = projectID
+	}
+	return ref
+}
+
+func rowsSize(rows []map[string]interface{}) int64 {
+	var result int64
+	for _, row := range rows {
+		if data, err := json.Marshal(row); err == nil {
+			result += int64(len(data))
+		}
+	}
+	return result
+}
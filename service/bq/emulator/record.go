@@ -0,0 +1,296 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/viant/toolbox"
+	"google.golang.org/api/bigquery/v2"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//validateRecord checks if record matches schema
+func validateRecord(record map[string]interface{}, fields []*bigquery.TableFieldSchema, ignoreUnknown bool) error {
+	index := indexFields(fields)
+	for key, value := range record {
+		field, ok := index[key]
+		if !ok {
+			if ignoreUnknown {
+				delete(record, key)
+				continue
+			}
+			return fmt.Errorf("No such field: %v", key)
+		}
+		if value == nil {
+			continue
+		}
+		if field.Mode == "REPEATED" {
+			items, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("array specified for non-repeated field: %v", key)
+			}
+			for _, item := range items {
+				if err := validateValue(item, field, ignoreUnknown); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := validateValue(value, field, ignoreUnknown); err != nil {
+			return err
+		}
+	}
+	for _, field := range fields {
+		if field.Mode == "REQUIRED" && record[field.Name] == nil {
+			return fmt.Errorf("missing required field: %v", field.Name)
+		}
+	}
+	return nil
+}
+
+func validateValue(value interface{}, field *bigquery.TableFieldSchema, ignoreUnknown bool) error {
+	if value == nil {
+		return nil
+	}
+	switch normalizeType(field.Type) {
+	case "RECORD":
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid value for record field: %v, %v", field.Name, value)
+		}
+		return validateRecord(nested, field.Fields, ignoreUnknown)
+	case "INTEGER":
+		if _, err := toolbox.ToInt(value); err != nil {
+			return fmt.Errorf("could not parse '%v' as int for field %v", value, field.Name)
+		}
+	case "FLOAT", "NUMERIC", "BIGNUMERIC":
+		if _, err := toolbox.ToFloat(value); err != nil {
+			return fmt.Errorf("could not parse '%v' as double for field %v", value, field.Name)
+		}
+	case "BOOLEAN":
+		switch actual := value.(type) {
+		case bool:
+		case string:
+			if lower := strings.ToLower(actual); lower != "true" && lower != "false" {
+				return fmt.Errorf("could not parse '%v' as bool for field %v", value, field.Name)
+			}
+		default:
+			return fmt.Errorf("could not parse '%v' as bool for field %v", value, field.Name)
+		}
+	case "TIMESTAMP", "DATETIME", "DATE":
+		if _, err := asTime(value); err != nil {
+			return fmt.Errorf("could not parse '%v' as %v for field %v", value, strings.ToLower(field.Type), field.Name)
+		}
+	}
+	return nil
+}
+
+func asTime(value interface{}) (*time.Time, error) {
+	switch actual := value.(type) {
+	case float64:
+		ts := time.Unix(0, int64(actual*float64(time.Second)))
+		return &ts, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 MST", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if ts, err := time.Parse(layout, actual); err == nil {
+				return &ts, nil
+			}
+		}
+	}
+	return toolbox.ToTime(value, "")
+}
+
+//InferSchema detects schema from sample records
+func InferSchema(records []map[string]interface{}) []*bigquery.TableFieldSchema {
+	var result = make([]*bigquery.TableFieldSchema, 0)
+	index := map[string]*bigquery.TableFieldSchema{}
+	for _, record := range records {
+		keys := make([]string, 0, len(record))
+		for key := range record {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := index[key]; ok {
+				continue
+			}
+			value := record[key]
+			if value == nil {
+				continue
+			}
+			field := inferField(key, value)
+			index[key] = field
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+func inferField(name string, value interface{}) *bigquery.TableFieldSchema {
+	field := &bigquery.TableFieldSchema{Name: name, Mode: "NULLABLE"}
+	switch actual := value.(type) {
+	case []interface{}:
+		field.Mode = "REPEATED"
+		field.Type = "STRING"
+		if len(actual) > 0 {
+			item := inferField(name, actual[0])
+			field.Type = item.Type
+			field.Fields = item.Fields
+		}
+	case map[string]interface{}:
+		field.Type = "RECORD"
+		field.Fields = InferSchema([]map[string]interface{}{actual})
+	case bool:
+		field.Type = "BOOLEAN"
+	case float64:
+		field.Type = "FLOAT"
+		if actual == float64(int64(actual)) {
+			field.Type = "INTEGER"
+		}
+	default:
+		field.Type = "STRING"
+	}
+	return field
+}
+
+//mergeSchema adds fields missing in the source schema
+func mergeSchema(source, candidate []*bigquery.TableFieldSchema) ([]*bigquery.TableFieldSchema, bool) {
+	index := indexFields(source)
+	modified := false
+	for _, field := range candidate {
+		existing, ok := index[field.Name]
+		if !ok {
+			source = append(source, field)
+			modified = true
+			continue
+		}
+		if len(field.Fields) > 0 {
+			var nestedModified bool
+			existing.Fields, nestedModified = mergeSchema(existing.Fields, field.Fields)
+			modified = modified || nestedModified
+		}
+	}
+	return source, modified
+}
+
+//decodeCSV decodes CSV data into records using schema field order
+func decodeCSV(data []byte, config *bigquery.JobConfigurationLoad, fields []*bigquery.TableFieldSchema) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	if config.FieldDelimiter != "" {
+		reader.Comma = []rune(config.FieldDelimiter)[0]
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = config.AllowQuotedNewlines
+	var result = make([]map[string]interface{}, 0)
+	skip := config.SkipLeadingRows
+	var header []string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if skip > 0 {
+			skip--
+			if header == nil {
+				header = row
+			}
+			continue
+		}
+		if len(fields) == 0 && header == nil {
+			return nil, fmt.Errorf("schema was empty")
+		}
+		record := map[string]interface{}{}
+		if len(fields) > 0 {
+			if len(row) > len(fields) && !config.AllowJaggedRows {
+				return nil, fmt.Errorf("too many values in row: %v", row)
+			}
+			for i, field := range fields {
+				if i >= len(row) || row[i] == "" && config.NullMarker == "" {
+					continue
+				}
+				record[field.Name] = row[i]
+			}
+		} else {
+			for i, name := range header {
+				if i < len(row) && row[i] != "" {
+					record[name] = row[i]
+				}
+			}
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+//encodeCSV encodes records as CSV
+func encodeCSV(records []map[string]interface{}, fields []*bigquery.TableFieldSchema, withHeader bool) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	if withHeader {
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.Name
+		}
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	for _, record := range records {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			if value, ok := record[field.Name]; ok && value != nil {
+				row[i] = toolbox.AsString(value)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+//asTableRow converts record to API f/v row representation
+func asTableRow(record map[string]interface{}, fields []*bigquery.TableFieldSchema) *bigquery.TableRow {
+	row := &bigquery.TableRow{F: make([]*bigquery.TableCell, len(fields))}
+	for i, field := range fields {
+		row.F[i] = &bigquery.TableCell{V: asCellValue(record[field.Name], field)}
+	}
+	return row
+}
+
+func asCellValue(value interface{}, field *bigquery.TableFieldSchema) interface{} {
+	if value == nil {
+		return nil
+	}
+	if field.Mode == "REPEATED" {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		var result = make([]interface{}, len(items))
+		itemField := *field
+		itemField.Mode = "NULLABLE"
+		for i, item := range items {
+			result[i] = map[string]interface{}{"v": asCellValue(item, &itemField)}
+		}
+		return result
+	}
+	switch normalizeType(field.Type) {
+	case "RECORD":
+		nested, _ := value.(map[string]interface{})
+		return asTableRow(nested, field.Fields)
+	case "TIMESTAMP":
+		if ts, err := asTime(value); err == nil {
+			return strconv.FormatFloat(float64(ts.UnixNano())/float64(time.Second), 'f', -1, 64)
+		}
+	}
+	return toolbox.AsString(value)
+}
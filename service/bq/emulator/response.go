@@ -0,0 +1,77 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"net/http"
+)
+
+type responseWriter struct {
+	header http.Header
+	status int
+	body   *bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{
+		header: make(http.Header),
+		status: http.StatusOK,
+		body:   new(bytes.Buffer),
+	}
+}
+
+type errorDetail struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Errors  []googleapi.ErrorItem `json:"errors"`
+}
+
+type errorResponse struct {
+	Error *errorDetail `json:"error"`
+}
+
+func newError(code int, reason, template string, args ...interface{}) *googleapi.Error {
+	message := fmt.Sprintf(template, args...)
+	return &googleapi.Error{
+		Code:    code,
+		Message: message,
+		Errors:  []googleapi.ErrorItem{{Reason: reason, Message: message}},
+	}
+}
+
+func writeError(writer http.ResponseWriter, err error) {
+	apiError, ok := err.(*googleapi.Error)
+	if !ok {
+		apiError = newError(http.StatusBadRequest, "invalid", "%v", err)
+	}
+	writeJSON(writer, apiError.Code, &errorResponse{Error: &errorDetail{Code: apiError.Code, Message: apiError.Message, Errors: apiError.Errors}})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	if value == nil {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data = []byte(fmt.Sprintf(`{"error":{"code":500,"message":%q}}`, err.Error()))
+	}
+	writer.WriteHeader(status)
+	_, _ = writer.Write(data)
+}
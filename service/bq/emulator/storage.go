@@ -0,0 +1,167 @@
+package emulator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+)
+
+const (
+	datasetFile      = "dataset.json"
+	tableFile        = "table.json"
+	dataFolder       = "data"
+	defaultPartition = "default"
+)
+
+func (e *Emulator) datasetURL(projectID, datasetID string) string {
+	return url.Join(e.baseURL, projectID, datasetID, datasetFile)
+}
+
+func (e *Emulator) tableURL(ref *bigquery.TableReference) string {
+	return url.Join(e.baseURL, ref.ProjectId, ref.DatasetId, base.TableID(ref.TableId), tableFile)
+}
+
+func (e *Emulator) dataURL(ref *bigquery.TableReference) string {
+	return url.Join(e.baseURL, ref.ProjectId, ref.DatasetId, base.TableID(ref.TableId), dataFolder)
+}
+
+func (e *Emulator) partitionURL(ref *bigquery.TableReference) string {
+	partition := base.TablePartition(ref.TableId)
+	if partition == "" {
+		partition = defaultPartition
+	}
+	return url.Join(e.dataURL(ref), partition+shared.JSONExt)
+}
+
+func (e *Emulator) jobsURL(projectID string) string {
+	return url.Join(e.baseURL, projectID, jobFolder)
+}
+
+func (e *Emulator) jobURL(projectID, jobID string) string {
+	return url.Join(e.jobsURL(projectID), jobID+shared.JSONExt)
+}
+
+func (e *Emulator) exists(ctx context.Context, URL string) bool {
+	exists, _ := e.fs.Exists(ctx, URL, option.NewObjectKind(true))
+	return exists
+}
+
+func (e *Emulator) load(ctx context.Context, URL string, target interface{}) (bool, error) {
+	if !e.exists(ctx, URL) {
+		return false, nil
+	}
+	data, err := e.fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to download: %v", URL)
+	}
+	if err = json.Unmarshal(data, target); err != nil {
+		return false, errors.Wrapf(err, "failed to decode: %v", URL)
+	}
+	return true, nil
+}
+
+func (e *Emulator) save(ctx context.Context, URL string, source interface{}) error {
+	data, err := json.Marshal(source)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode: %v", URL)
+	}
+	return e.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data))
+}
+
+//readRows reads table rows, if table reference uses partition decorator only that partition rows are returned
+func (e *Emulator) readRows(ctx context.Context, ref *bigquery.TableReference) ([]map[string]interface{}, error) {
+	var URLs []string
+	if base.TablePartition(ref.TableId) != "" {
+		URLs = append(URLs, e.partitionURL(ref))
+	} else {
+		objects, err := e.fs.List(ctx, e.dataURL(ref))
+		if err != nil {
+			return []map[string]interface{}{}, nil
+		}
+		for _, object := range objects {
+			if object.IsDir() {
+				continue
+			}
+			URLs = append(URLs, object.URL())
+		}
+	}
+	var result = make([]map[string]interface{}, 0)
+	for _, URL := range URLs {
+		if !e.exists(ctx, URL) {
+			continue
+		}
+		data, err := e.fs.DownloadWithURL(ctx, URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to download: %v", URL)
+		}
+		rows, err := decodeRows(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode: %v", URL)
+		}
+		result = append(result, rows...)
+	}
+	return result, nil
+}
+
+//writeRows writes table rows, truncate removes either whole table or partition rows
+func (e *Emulator) writeRows(ctx context.Context, ref *bigquery.TableReference, rows []map[string]interface{}, truncate bool) error {
+	URL := e.partitionURL(ref)
+	if truncate {
+		truncateURL := URL
+		if base.TablePartition(ref.TableId) == "" {
+			truncateURL = e.dataURL(ref)
+		}
+		if e.exists(ctx, truncateURL) {
+			if err := e.fs.Delete(ctx, truncateURL); err != nil {
+				return errors.Wrapf(err, "failed to truncate: %v", truncateURL)
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var existing []byte
+	if e.exists(ctx, URL) {
+		var err error
+		if existing, err = e.fs.DownloadWithURL(ctx, URL); err != nil {
+			return errors.Wrapf(err, "failed to download: %v", URL)
+		}
+	}
+	buffer := bytes.NewBuffer(existing)
+	for _, row := range rows {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode row: %v", row)
+		}
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+	return e.fs.Upload(ctx, URL, file.DefaultFileOsMode, buffer)
+}
+
+func decodeRows(data []byte) ([]map[string]interface{}, error) {
+	var result = make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, scanner.Err()
+}
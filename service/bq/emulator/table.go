@@ -0,0 +1,272 @@
+package emulator
+
+import (
+	"context"
+	"github.com/viant/bqtail/base"
+	"google.golang.org/api/bigquery/v2"
+	"net/http"
+	"time"
+)
+
+const defaultLocation = "US"
+
+func (e *Emulator) getDataset(ctx context.Context, projectID, datasetID string) (*bigquery.Dataset, error) {
+	dataset := &bigquery.Dataset{}
+	found, err := e.load(ctx, e.datasetURL(projectID, datasetID), dataset)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newError(http.StatusNotFound, "notFound", "Not found: Dataset %v:%v", projectID, datasetID)
+	}
+	return dataset, nil
+}
+
+func (e *Emulator) insertDataset(ctx context.Context, projectID string, dataset *bigquery.Dataset) (*bigquery.Dataset, error) {
+	if dataset.DatasetReference == nil || dataset.DatasetReference.DatasetId == "" {
+		return nil, newError(http.StatusBadRequest, "invalid", "datasetReference was empty")
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	ref := dataset.DatasetReference
+	if ref.ProjectId == "" {
+		ref.ProjectId = projectID
+	}
+	URL := e.datasetURL(ref.ProjectId, ref.DatasetId)
+	if e.exists(ctx, URL) {
+		return nil, newError(http.StatusConflict, "duplicate", "Already Exists: Dataset %v:%v", ref.ProjectId, ref.DatasetId)
+	}
+	if dataset.Location == "" {
+		dataset.Location = defaultLocation
+	}
+	dataset.Id = ref.ProjectId + ":" + ref.DatasetId
+	dataset.CreationTime = time.Now().UnixNano() / int64(time.Millisecond)
+	return dataset, e.save(ctx, URL, dataset)
+}
+
+func (e *Emulator) getTable(ctx context.Context, ref *bigquery.TableReference) (*bigquery.Table, error) {
+	table := &bigquery.Table{}
+	found, err := e.load(ctx, e.tableURL(ref), table)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newError(http.StatusNotFound, "notFound", "Not found: Table %v", base.EncodeTableReference(ref, false))
+	}
+	return table, nil
+}
+
+func (e *Emulator) insertTable(ctx context.Context, projectID, datasetID string, table *bigquery.Table) (*bigquery.Table, error) {
+	if table.TableReference == nil || table.TableReference.TableId == "" {
+		return nil, newError(http.StatusBadRequest, "invalid", "tableReference was empty")
+	}
+	ref := table.TableReference
+	if ref.ProjectId == "" {
+		ref.ProjectId = projectID
+	}
+	if ref.DatasetId == "" {
+		ref.DatasetId = datasetID
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.createTable(ctx, table)
+}
+
+//createTable creates a table, caller has to hold a lock
+func (e *Emulator) createTable(ctx context.Context, table *bigquery.Table) (*bigquery.Table, error) {
+	ref := table.TableReference
+	dataset, err := e.getDataset(ctx, ref.ProjectId, ref.DatasetId)
+	if err != nil {
+		return nil, err
+	}
+	URL := e.tableURL(ref)
+	if e.exists(ctx, URL) {
+		return nil, newError(http.StatusConflict, "duplicate", "Already Exists: Table %v", base.EncodeTableReference(ref, false))
+	}
+	ref.TableId = base.TableID(ref.TableId)
+	now := time.Now()
+	table.Id = base.EncodeTableReference(ref, false)
+	table.Kind = "bigquery#table"
+	table.Type = "TABLE"
+	table.Location = dataset.Location
+	table.CreationTime = now.UnixNano() / int64(time.Millisecond)
+	table.LastModifiedTime = uint64(table.CreationTime)
+	if table.Schema == nil {
+		table.Schema = &bigquery.TableSchema{}
+	}
+	return table, e.save(ctx, URL, table)
+}
+
+func (e *Emulator) patchTable(ctx context.Context, ref *bigquery.TableReference, patch *bigquery.Table) (*bigquery.Table, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	table, err := e.getTable(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Schema != nil && len(patch.Schema.Fields) > 0 {
+		if err = checkSchemaPatch(table.Schema.Fields, patch.Schema.Fields); err != nil {
+			return nil, err
+		}
+		table.Schema = patch.Schema
+	}
+	if patch.Description != "" {
+		table.Description = patch.Description
+	}
+	if len(patch.Labels) > 0 {
+		table.Labels = patch.Labels
+	}
+	if patch.ExpirationTime != 0 {
+		table.ExpirationTime = patch.ExpirationTime
+	}
+	table.LastModifiedTime = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	return table, e.save(ctx, e.tableURL(ref), table)
+}
+
+func (e *Emulator) deleteTable(ctx context.Context, ref *bigquery.TableReference) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	URL := e.tableURL(ref)
+	if !e.exists(ctx, URL) {
+		return newError(http.StatusNotFound, "notFound", "Not found: Table %v", base.EncodeTableReference(ref, false))
+	}
+	if e.exists(ctx, e.dataURL(ref)) {
+		if err := e.fs.Delete(ctx, e.dataURL(ref)); err != nil {
+			return err
+		}
+	}
+	return e.fs.Delete(ctx, URL)
+}
+
+func (e *Emulator) insertAll(ctx context.Context, ref *bigquery.TableReference, request *bigquery.TableDataInsertAllRequest) (*bigquery.TableDataInsertAllResponse, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	table, err := e.getTable(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	response := &bigquery.TableDataInsertAllResponse{Kind: "bigquery#tableDataInsertAllResponse"}
+	var rows = make([]map[string]interface{}, 0)
+	for i, row := range request.Rows {
+		record := map[string]interface{}{}
+		for k, v := range row.Json {
+			record[k] = v
+		}
+		if err := validateRecord(record, table.Schema.Fields, request.IgnoreUnknownValues); err != nil {
+			response.InsertErrors = append(response.InsertErrors, &bigquery.TableDataInsertAllResponseInsertErrors{
+				Index:  int64(i),
+				Errors: []*bigquery.ErrorProto{{Reason: "invalid", Message: err.Error()}},
+			})
+			continue
+		}
+		rows = append(rows, record)
+	}
+	if len(response.InsertErrors) > 0 && !request.SkipInvalidRows {
+		return response, nil
+	}
+	if err = e.appendRows(ctx, table, ref, rows); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+//appendRows appends rows and updates table stats, caller has to hold a lock
+func (e *Emulator) appendRows(ctx context.Context, table *bigquery.Table, ref *bigquery.TableReference, rows []map[string]interface{}) error {
+	if err := e.writeRows(ctx, ref, rows, false); err != nil {
+		return err
+	}
+	return e.updateStats(ctx, table)
+}
+
+//updateStats updates table row count, caller has to hold a lock
+func (e *Emulator) updateStats(ctx context.Context, table *bigquery.Table) error {
+	rows, err := e.readRows(ctx, &bigquery.TableReference{ProjectId: table.TableReference.ProjectId, DatasetId: table.TableReference.DatasetId, TableId: table.TableReference.TableId})
+	if err != nil {
+		return err
+	}
+	table.NumRows = uint64(len(rows))
+	table.LastModifiedTime = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	return e.save(ctx, e.tableURL(table.TableReference), table)
+}
+
+//checkSchemaPatch checks if patch only adds fields, or relaxes mode
+func checkSchemaPatch(source, patch []*bigquery.TableFieldSchema) error {
+	index := indexFields(patch)
+	for _, field := range source {
+		candidate, ok := index[field.Name]
+		if !ok {
+			return newError(http.StatusBadRequest, "invalid", "Provided Schema does not match Table. Field %v is missing in new schema", field.Name)
+		}
+		if normalizeType(candidate.Type) != normalizeType(field.Type) {
+			return newError(http.StatusBadRequest, "invalid", "Provided Schema does not match Table. Field %v has changed type from %v to %v", field.Name, field.Type, candidate.Type)
+		}
+		if len(field.Fields) > 0 {
+			if err := checkSchemaPatch(field.Fields, candidate.Fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func indexFields(fields []*bigquery.TableFieldSchema) map[string]*bigquery.TableFieldSchema {
+	var result = make(map[string]*bigquery.TableFieldSchema)
+	for i := range fields {
+		result[fields[i].Name] = fields[i]
+	}
+	return result
+}
+
+func normalizeType(fieldType string) string {
+	switch fieldType {
+	case "INT64":
+		return "INTEGER"
+	case "FLOAT64":
+		return "FLOAT"
+	case "BOOL":
+		return "BOOLEAN"
+	case "STRUCT":
+		return "RECORD"
+	}
+	return fieldType
+}
+
+//writeTable writes rows to destination table applying create, write dispositions and schema update options, caller has to hold a lock
+func (e *Emulator) writeTable(ctx context.Context, ref *bigquery.TableReference, fields []*bigquery.TableFieldSchema, rows []map[string]interface{}, createDisposition, writeDisposition string, schemaUpdateOptions []string) error {
+	table, err := e.getTable(ctx, ref)
+	if err != nil {
+		if createDisposition == "CREATE_NEVER" {
+			return err
+		}
+		tableRef := *ref
+		if table, err = e.createTable(ctx, &bigquery.Table{TableReference: &tableRef, Schema: &bigquery.TableSchema{Fields: fields}}); err != nil {
+			return err
+		}
+	}
+	truncate := writeDisposition == "WRITE_TRUNCATE"
+	if writeDisposition == "WRITE_EMPTY" {
+		existing, err := e.readRows(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return newError(http.StatusConflict, "duplicate", "Already Exists: Table %v", base.EncodeTableReference(ref, false))
+		}
+	}
+	if merged, modified := mergeSchema(table.Schema.Fields, fields); modified {
+		canAdd := truncate && base.TablePartition(ref.TableId) == ""
+		for _, option := range schemaUpdateOptions {
+			if option == "ALLOW_FIELD_ADDITION" {
+				canAdd = true
+			}
+		}
+		if !canAdd {
+			return newError(http.StatusBadRequest, "invalid", "Provided Schema does not match Table %v. Cannot add fields", base.EncodeTableReference(ref, false))
+		}
+		table.Schema.Fields = merged
+	}
+	if err = e.writeRows(ctx, ref, rows, truncate); err != nil {
+		return err
+	}
+	return e.updateStats(ctx, table)
+}
@@ -27,6 +27,14 @@ func (s *service) fetchAll(ctx context.Context, projectID string, useLegacy bool
 	if err != nil {
 		return nil, err
 	}
+	if !base.IsJobDone(job) {
+		if job, err = s.Wait(ctx, job.JobReference); err != nil {
+			return nil, err
+		}
+	}
+	if err = base.JobError(job); err != nil {
+		return nil, err
+	}
 	var records = []map[string]bigquery.JsonValue{}
 	queryResultCall := s.Jobs.GetQueryResults(job.JobReference.ProjectId, job.JobReference.JobId)
 	queryResultCall.Context(ctx)
@@ -17,5 +17,8 @@ func InitRegistry(registry task.Registry, service Service) {
 	registry.RegisterAction(shared.ActionExport, task.NewServiceAction(id, ExportRequest{}))
 	registry.RegisterAction(shared.ActionInsert, task.NewServiceAction(id, InsertRequest{}))
 	registry.RegisterAction(shared.ActionTableExists, task.NewServiceAction(id, TableExistsRequest{}))
+	registry.RegisterAction(shared.ActionSplit, task.NewServiceAction(id, SplitRequest{}))
+	registry.RegisterAction(shared.ActionAssert, task.NewServiceAction(id, AssertRequest{}))
+	registry.RegisterAction(shared.ActionReconcile, task.NewServiceAction(id, ReconcileRequest{}))
 
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/viant/afs/url"
 	"github.com/viant/bqtail/base"
 	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage/activity"
 	"github.com/viant/bqtail/task"
 	"google.golang.org/api/bigquery/v2"
 	"strings"
@@ -34,7 +35,7 @@ func (s *service) schedulePostTask(ctx context.Context, job *bigquery.Job, actio
 	if err != nil {
 		return errors.Wrapf(err, "failed to encode actions: %v", action)
 	}
-	filename := action.Meta.JobFilename()
+	filename := activity.TaskFilename(job.JobReference.ProjectId, job.JobReference.Location, job.JobReference.JobId)
 	URL := url.Join(s.Config.AsyncTaskURL, filename)
 	return base.RunWithRetriesOnRetryOrInternalError(func() error {
 		return s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data))
@@ -70,6 +71,11 @@ func (s *service) Post(ctx context.Context, callerJob *bigquery.Job, action *tas
 		if job == nil {
 			job = callerJob
 		}
+		if s.listener != nil && base.IsJobDone(job) {
+			if listenerErr := s.listener(ctx, job, action); listenerErr != nil {
+				shared.LogF("failed to notify job listener: %v, %v\n", job.JobReference.JobId, listenerErr)
+			}
+		}
 		postErr := s.runActions(ctx, err, job, action.Actions)
 		if postErr != nil {
 			if err == nil {
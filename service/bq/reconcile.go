@@ -0,0 +1,167 @@
+package bq
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/base/job"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/task"
+	"math"
+	"strings"
+)
+
+//ReconciliationError reconciliation error message prefix
+const ReconciliationError = "reconciliation error"
+
+//Reconcile compares source files record count with load job output rows and bad records, and with the final copy output rows
+func (s *service) Reconcile(ctx context.Context, request *ReconcileRequest, action *task.Action) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	result, err := s.reconcile(ctx, request, action)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to reconcile %v", action.Meta.DestTable)
+	} else {
+		if shared.IsInfoLoggingLevel() {
+			shared.LogF("[%v] reconciliation: %+v\n", action.Meta.DestTable, result)
+		}
+		if err = result.Error(request.Tolerance); err != nil {
+			errorURL := url.Join(s.ErrorURL, action.Meta.DestTable, fmt.Sprintf("%v%v", action.Meta.EventID, shared.ErrorExt))
+			_ = s.fs.Upload(ctx, errorURL, file.DefaultFileOsMode, strings.NewReader(err.Error()))
+		}
+	}
+	onDone := action.Actions
+	if onDone == nil {
+		onDone = task.NewActions(nil, nil)
+	}
+	if _, runErr := task.RunAll(ctx, s.Registry, onDone.ToRun(err, &base.Job{})); runErr != nil {
+		return runErr
+	}
+	return err
+}
+
+func (s *service) reconcile(ctx context.Context, request *ReconcileRequest, action *task.Action) (*Reconciliation, error) {
+	result := &Reconciliation{}
+	var err error
+	if result.SourceRecords, err = s.countSourceRecords(ctx, request); err != nil {
+		return nil, err
+	}
+	projectID := action.Meta.GetOrSetProject(s.projectID)
+	loadJob, err := s.GetJob(ctx, action.Meta.Region, projectID, request.LoadJobID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get load job: %v", request.LoadJobID)
+	}
+	if loadJob.Statistics != nil && loadJob.Statistics.Load != nil {
+		result.OutputRows = loadJob.Statistics.Load.OutputRows
+		result.BadRecords = loadJob.Statistics.Load.BadRecords
+	}
+	if !request.CheckCopy || request.ParentJobID == "" || request.ParentJobID == request.LoadJobID {
+		return result, nil
+	}
+	copyJob, err := s.GetJob(ctx, action.Meta.Region, projectID, request.ParentJobID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get copy job: %v", request.ParentJobID)
+	}
+	if copied, ok := job.CopiedRows(copyJob); ok {
+		result.CopiedRows = &copied
+	}
+	return result, nil
+}
+
+func (s *service) countSourceRecords(ctx context.Context, request *ReconcileRequest) (int64, error) {
+	var total int64
+	for _, URI := range request.SourceURIs {
+		if request.ManifestExt != "" {
+			count, ok, err := manifestRecords(ctx, s.fs, URI+request.ManifestExt)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				total += count
+				continue
+			}
+		}
+		count, err := countRecords(ctx, s.fs, URI, request.SourceFormat, request.SkipLeadingRows)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to count records: %v", URI)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+//Reconciliation represents source to destination row counts
+type Reconciliation struct {
+	SourceRecords int64
+	OutputRows    int64
+	BadRecords    int64
+	CopiedRows    *int64 `json:",omitempty"`
+}
+
+//Error returns reconciliation error if counts differ beyond tolerance (fraction of source records)
+func (r *Reconciliation) Error(tolerance float64) error {
+	allowed := int64(math.Floor(float64(r.SourceRecords) * tolerance))
+	var mismatches = make([]string, 0)
+	if loaded := r.OutputRows + r.BadRecords; abs(r.SourceRecords-loaded) > allowed {
+		mismatches = append(mismatches, fmt.Sprintf("source records: %v, load output rows: %v, bad records: %v", r.SourceRecords, r.OutputRows, r.BadRecords))
+	}
+	if r.CopiedRows != nil && abs(r.OutputRows-*r.CopiedRows) > allowed {
+		mismatches = append(mismatches, fmt.Sprintf("load output rows: %v, copied rows: %v", r.OutputRows, *r.CopiedRows))
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return errors.Errorf("%v: %v (tolerance: %v)", ReconciliationError, strings.Join(mismatches, "; "), tolerance)
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+//ReconcileRequest represents reconciliation request
+type ReconcileRequest struct {
+	SourceURIs      []string
+	SourceFormat    string
+	SkipLeadingRows int
+	//ManifestExt optional sidecar manifest extension (i.e. .manifest) with source file record count
+	ManifestExt string
+	//LoadJobID load job ID
+	LoadJobID string
+	//ParentJobID job preceding reconcile action (load or copy job)
+	ParentJobID string
+	//CheckCopy compares load output rows with copy job output rows
+	CheckCopy bool
+	//Tolerance allowed difference as fraction of source records
+	Tolerance float64
+}
+
+//Validate checks if request is valid
+func (r *ReconcileRequest) Validate() error {
+	if len(r.SourceURIs) == 0 {
+		return errors.New("sourceURIs were empty")
+	}
+	if r.LoadJobID == "" {
+		return errors.New("loadJobID was empty")
+	}
+	if r.Tolerance < 0 || r.Tolerance >= 1 {
+		return errors.Errorf("invalid tolerance: %v, expected [0,1)", r.Tolerance)
+	}
+	return nil
+}
+
+//NewReconcileAction creates a new reconcile action
+func NewReconcileAction(request *ReconcileRequest, finally *task.Actions) *task.Action {
+	result := &task.Action{
+		Action:  shared.ActionReconcile,
+		Actions: finally,
+	}
+	_ = result.SetRequest(request)
+	return result
+}
@@ -20,6 +20,12 @@ func (s *service) Run(ctx context.Context, request *task.Action) (task.Response,
 		job, err = s.Export(ctx, req, request)
 	case *DropRequest:
 		err = s.Drop(ctx, req, request)
+	case *SplitRequest:
+		err = s.Split(ctx, req, request)
+	case *AssertRequest:
+		err = s.Assert(ctx, req, request)
+	case *ReconcileRequest:
+		err = s.Reconcile(ctx, req, request)
 	case *QueryRequest:
 		job, err = s.Query(ctx, req, request)
 	case *LoadRequest:
@@ -34,8 +34,13 @@ type Service interface {
 	Copy(ctx context.Context, request *CopyRequest, action *task.Action) (*bigquery.Job, error)
 
 	Insert(ctx context.Context, request *InsertRequest, action *task.Action) (response *bigquery.TableDataInsertAllResponse, err error)
+
+	//SetJobListener sets sync mode job completion listener
+	SetJobListener(listener JobListener)
 }
 
+//JobListener represents sync mode job completion listener, it is called before job post actions run
+type JobListener func(ctx context.Context, job *bigquery.Job, action *task.Action) error
 
 type service struct {
 	base.Config
@@ -44,6 +49,12 @@ type service struct {
 	jobs      *bigquery.JobsService
 	projectID string
 	fs        afs.Service
+	listener  JobListener
+}
+
+//SetJobListener sets sync mode job completion listener
+func (s *service) SetJobListener(listener JobListener) {
+	s.listener = listener
 }
 
 //New creates bq service
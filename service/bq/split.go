@@ -0,0 +1,202 @@
+package bq
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/task"
+	"github.com/viant/toolbox"
+	"google.golang.org/api/bigquery/v2"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const splitValueColumn = "value"
+
+//SplitValuePlaceholder split destination table and SQL placeholder replaced with column value
+const SplitValuePlaceholder = "_SPLIT_VALUE_"
+
+var invalidTableChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+//Split discovers distinct column values in the source table and runs one query per value into value specific destination table
+func (s *service) Split(ctx context.Context, request *SplitRequest, action *task.Action) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	projectID := action.Meta.GetOrSetProject(s.projectID)
+	records, err := s.fetchAll(ctx, projectID, false, request.distinctSQL())
+	if err != nil {
+		return errors.Wrapf(err, "failed to discover %v split values", request.Column)
+	}
+	var values = make([]string, 0)
+	for _, record := range records {
+		if value := record[splitValueColumn]; value != nil {
+			values = append(values, toolbox.AsString(value))
+		}
+	}
+	if len(values) > request.MaxTables {
+		return errors.Errorf("dynamic split %v exceeded max tables: %v, values: %v", request.Column, request.MaxTables, strings.Join(values, ","))
+	}
+	sort.Strings(values)
+	var queries = make([]*task.Action, 0)
+	suffixes := splitTableSuffixes(values)
+	for _, value := range values {
+		criteria := fmt.Sprintf("CAST(%v AS STRING) = '%v'", request.Column, escapeString(value))
+		queries = append(queries, request.queryAction(request.SQL, request.Dest, suffixes[value], criteria))
+	}
+	if request.Else != "" {
+		criteria := request.Column + " IS NULL"
+		if len(request.Values) > 0 {
+			criteria = fmt.Sprintf("(%v OR CAST(%v AS STRING) NOT IN (%v))", criteria, request.Column, request.quotedValues())
+		}
+		queries = append(queries, request.queryAction(request.ElseSQL, request.Else, "", criteria))
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] running split %v into %v tables\n", action.Meta.DestTable, request.Column, len(queries))
+	}
+	onDone := action.Actions
+	if onDone == nil {
+		onDone = task.NewActions(nil, nil)
+	}
+	if len(queries) == 0 {
+		_, err = task.RunAll(ctx, s.Registry, onDone.ToRun(nil, &base.Job{}))
+		return err
+	}
+	next := onDone
+	for i := len(queries) - 1; i >= 0; i-- {
+		query := queries[i]
+		meta := action.Meta.Wrap(shared.ActionQuery)
+		meta.Step = action.Meta.Step*100 + i + 1
+		meta.Mode = meta.Process.Mode(shared.ActionQuery)
+		query.Meta = meta
+		query.Actions = next
+		group := task.NewActions(nil, onDone.OnFailure)
+		group.AddOnSuccess(query)
+		next = group
+	}
+	_, err = task.Run(ctx, s.Registry, queries[0])
+	return err
+}
+
+//SplitRequest represents dynamic split request
+type SplitRequest struct {
+	//Table source table
+	Table string
+	//Column split column
+	Column string
+	//SQL select or DML template with $WHERE and SplitValuePlaceholder
+	SQL string
+	//Dest destination table with SplitValuePlaceholder
+	Dest string
+	//Template destination table template
+	Template string
+	//Else optional destination table for values not in the allow-list and NULL values
+	Else string
+	//ElseSQL select or DML template with $WHERE for Else table
+	ElseSQL   string
+	Values    []string
+	MaxTables int
+	Append    bool
+}
+
+//Validate checks if request is valid
+func (r *SplitRequest) Validate() error {
+	if r.Table == "" {
+		return errors.New("table was empty")
+	}
+	if r.Column == "" {
+		return errors.New("column was empty")
+	}
+	if r.SQL == "" {
+		return errors.New("SQL was empty")
+	}
+	if !strings.Contains(r.Dest, SplitValuePlaceholder) {
+		return errors.Errorf("invalid dest: %v, expected %v placeholder", r.Dest, SplitValuePlaceholder)
+	}
+	if r.MaxTables == 0 {
+		return errors.New("maxTables was empty")
+	}
+	if r.Else != "" && r.ElseSQL == "" {
+		return errors.New("elseSQL was empty")
+	}
+	return nil
+}
+
+func (r *SplitRequest) distinctSQL() string {
+	tableRef, _ := base.NewTableReference(r.Table)
+	where := r.Column + " IS NOT NULL"
+	if len(r.Values) > 0 {
+		where += fmt.Sprintf(" AND CAST(%v AS STRING) IN (%v)", r.Column, r.quotedValues())
+	}
+	return fmt.Sprintf("SELECT DISTINCT CAST(%v AS STRING) AS %v FROM `%v` WHERE %v LIMIT %v",
+		r.Column, splitValueColumn, base.EncodeTableReference(tableRef, true), where, r.MaxTables+1)
+}
+
+func (r *SplitRequest) quotedValues() string {
+	var quoted = make([]string, 0, len(r.Values))
+	for _, value := range r.Values {
+		quoted = append(quoted, "'"+escapeString(value)+"'")
+	}
+	return strings.Join(quoted, ",")
+}
+
+//queryAction returns query action copying rows matching criteria to the destination table with placeholder replaced with value suffix
+func (r *SplitRequest) queryAction(SQL, dest, suffix, criteria string) *task.Action {
+	dest = strings.Replace(dest, SplitValuePlaceholder, suffix, -1)
+	SQL = strings.Replace(SQL, SplitValuePlaceholder, suffix, -1)
+	SQL = strings.Replace(SQL, "$WHERE", " WHERE  "+criteria+" ", 1)
+	destRef := tableReference(dest)
+	if r.isDML() {
+		return NewDMLAction(SQL, destRef, r.Template, true, nil)
+	}
+	return NewQueryAction(SQL, destRef, r.Template, r.Append, nil)
+}
+
+func (r *SplitRequest) isDML() bool {
+	query := &QueryRequest{SQL: r.SQL}
+	return !query.IsSelectQuery()
+}
+
+func tableReference(table string) *bigquery.TableReference {
+	ref, _ := base.NewTableReference(table)
+	return ref
+}
+
+func splitTableSuffix(value string) string {
+	return invalidTableChars.ReplaceAllString(value, "_")
+}
+
+//splitTableSuffixes returns table suffix for each value, values with invalid table characters colliding with other value suffix get a value hash appended
+func splitTableSuffixes(values []string) map[string]string {
+	var result = make(map[string]string, len(values))
+	var count = make(map[string]int, len(values))
+	for _, value := range values {
+		count[splitTableSuffix(value)]++
+	}
+	for _, value := range values {
+		suffix := splitTableSuffix(value)
+		if count[suffix] > 1 && suffix != value {
+			suffix = fmt.Sprintf("%v_%x", suffix, uint32(base.Hash(value)))
+		}
+		result[value] = suffix
+	}
+	return result
+}
+
+func escapeString(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return strings.Replace(value, `'`, `\'`, -1)
+}
+
+//NewSplitAction creates a new dynamic split action
+func NewSplitAction(request *SplitRequest, finally *task.Actions) *task.Action {
+	result := &task.Action{
+		Action:  shared.ActionSplit,
+		Actions: finally,
+	}
+	_ = result.SetRequest(request)
+	return result
+}
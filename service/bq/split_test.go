@@ -0,0 +1,82 @@
+package bq
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitRequest_queryAction(t *testing.T) {
+	request := &SplitRequest{
+		Table:     "p:temp.events_123",
+		Column:    "event_type",
+		SQL:       "SELECT t.id AS id FROM `p.temp.events_123` t  $WHERE",
+		Dest:      "p:ds.events_" + SplitValuePlaceholder,
+		Values:    []string{"click", "it's"},
+		MaxTables: 10,
+	}
+	assert.Nil(t, request.Validate())
+	assert.EqualValues(t, "SELECT DISTINCT CAST(event_type AS STRING) AS value FROM `p.temp.events_123` WHERE event_type IS NOT NULL AND CAST(event_type AS STRING) IN ('click','it\\'s') LIMIT 11", request.distinctSQL())
+
+	var useCases = []struct {
+		description string
+		value       string
+		expectSQL   string
+		expectDest  string
+	}{
+		{
+			description: "plain value",
+			value:       "click",
+			expectSQL:   "SELECT t.id AS id FROM `p.temp.events_123` t   WHERE  CAST(event_type AS STRING) = 'click' ",
+			expectDest:  "p:ds.events_click",
+		},
+		{
+			description: "value with invalid table characters",
+			value:       "it's",
+			expectSQL:   "SELECT t.id AS id FROM `p.temp.events_123` t   WHERE  CAST(event_type AS STRING) = 'it\\'s' ",
+			expectDest:  "p:ds.events_it_s",
+		},
+	}
+	for _, useCase := range useCases {
+		criteria := "CAST(event_type AS STRING) = '" + escapeString(useCase.value) + "'"
+		action := request.queryAction(request.SQL, request.Dest, splitTableSuffix(useCase.value), criteria)
+		query, ok := action.ServiceRequest().(*QueryRequest)
+		if !assert.True(t, ok, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectSQL, query.SQL, useCase.description)
+		assert.EqualValues(t, useCase.expectDest, query.Dest, useCase.description)
+	}
+}
+
+func TestSplitTableSuffixes(t *testing.T) {
+	var useCases = []struct {
+		description string
+		values      []string
+		expect      map[string]string
+	}{
+		{
+			description: "distinct values",
+			values:      []string{"click", "it's", "view"},
+			expect:      map[string]string{"click": "click", "it's": "it_s", "view": "view"},
+		},
+		{
+			description: "colliding values",
+			values:      []string{"a b", "a-b", "a_b"},
+			expect:      map[string]string{"a b": "a_b_944fe254", "a-b": "a_b_944fe729", "a_b": "a_b"},
+		},
+		{
+			description: "colliding values with invalid characters only",
+			values:      []string{"a.b", "a/b"},
+			expect:      map[string]string{"a.b": "a_b_944fec02", "a/b": "a_b_944fead3"},
+		},
+	}
+	for _, useCase := range useCases {
+		actual := splitTableSuffixes(useCase.values)
+		assert.EqualValues(t, useCase.expect, actual, useCase.description)
+		unique := map[string]bool{}
+		for _, suffix := range actual {
+			unique[suffix] = true
+		}
+		assert.EqualValues(t, len(useCase.values), len(unique), useCase.description)
+	}
+}
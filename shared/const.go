@@ -16,6 +16,10 @@ const (
 	StatusError = "error"
 	//StatusStalled status for unprocessed file
 	StatusStalled = "stalled"
+	//StatusDuplicate status for source object already ingested
+	StatusDuplicate = "duplicate"
+	//StatusPaused status for data file paused by exceeded rule budget
+	StatusPaused = "paused"
 
 	//StatusPending pending status
 	StatusPending = "pending"
@@ -36,10 +40,36 @@ const (
 
 	//LocationExt location extension
 	LocationExt = ".loc"
+	//MemberExt batch window member file extension
+	MemberExt = ".mem"
+	//BatchStateExt batch window state entry file extension, one entry per file added to a window
+	BatchStateExt = ".bst"
+	//BatchSealExt batch window seal marker file extension
+	BatchSealExt = ".sld"
 	//CounterExt counter file extension
 	CounterExt = ".cnt"
 )
 
+//Batch window locker
+const (
+	//WindowLockerGeneration storage generation precondition window locker
+	WindowLockerGeneration = "generation"
+	//WindowLockerFile local file window locker
+	WindowLockerFile = "file"
+	//WindowLockerLease lease with expiry window locker
+	WindowLockerLease = "lease"
+	//DefaultWindowLeaseInSec default window lease duration after window end time
+	DefaultWindowLeaseInSec = 600
+)
+
+//BigQuery load job limits
+const (
+	//MaxLoadURIs max number of source URIs per load job
+	MaxLoadURIs = 10000
+	//MaxLoadBytes max total size of source files per load job (15TB)
+	MaxLoadBytes = 15 * 1024 * 1024 * 1024 * 1024
+)
+
 //Process action
 const (
 	//ActionLoad load action
@@ -69,6 +99,12 @@ const (
 	ActionNotify = "notify"
 	//ActionDrop drop table
 	ActionDrop = "drop"
+	//ActionSplit split action, fans out one query per distinct column value
+	ActionSplit = "split"
+	//ActionAssert data quality assertion action
+	ActionAssert = "assert"
+	//ActionReconcile source to destination row reconciliation action
+	ActionReconcile = "reconcile"
 	//ActionCall http call action
 	ActionCall = "call"
 	//ActionPush action pubusb push
@@ -77,16 +113,19 @@ const (
 
 //Actionable  action with action meta
 var Actionable = map[string]bool{
-	ActionLoad:   true,
-	ActionReload: true,
-	ActionCopy:   true,
-	ActionQuery:  true,
-	ActionExport: true,
-	ActionInsert: true,
-	ActionDrop:   true,
-	ActionCall:   true,
-	ActionPush:   true,
-	ActionGroup:  true,
+	ActionLoad:      true,
+	ActionReload:    true,
+	ActionCopy:      true,
+	ActionQuery:     true,
+	ActionExport:    true,
+	ActionInsert:    true,
+	ActionDrop:      true,
+	ActionCall:      true,
+	ActionPush:      true,
+	ActionGroup:     true,
+	ActionSplit:     true,
+	ActionAssert:    true,
+	ActionReconcile: true,
 }
 
 const (
@@ -130,6 +169,10 @@ const (
 
 	//JobIDKey job id key
 	JobIDKey = "JobID"
+	//ParentJobIDKey preceding job ID key
+	ParentJobIDKey = "ParentJobID"
+	//LoadJobIDKey load job ID key
+	LoadJobIDKey = "LoadJobID"
 	//JobSourceKey source table/sql
 	JobSourceKey = "JobSource"
 	//ErrorKey error key
@@ -190,6 +233,25 @@ const (
 
 	//RetryDataSubpath retry data subpath
 	RetryDataSubpath = "retry/data"
+
+	//LedgerFolder ingestion ledger folder
+	LedgerFolder = "ledger"
+	//SchemaAuditFolder schema evolution audit folder
+	SchemaAuditFolder = "schema"
+	//QuarantineFolder corrupted data quarantine folder
+	QuarantineFolder = "quarantine"
+	//StagingFolder external data file staging journal folder
+	StagingFolder = "staging"
+	//ChunkFolder oversized compressed data file chunks folder
+	ChunkFolder = "chunks"
+	//CostFolder daily rule cost records folder
+	CostFolder = "cost"
+	//PausedFolder budget paused data files folder
+	PausedFolder = "paused"
+	//QuotaFolder daily destination table jobs count folder
+	QuotaFolder = "quota"
+	//BalancerFolder round robin balancer sequence folder
+	BalancerFolder = "balancer"
 )
 
 const (
@@ -197,6 +259,14 @@ const (
 	BalancerStrategyRand = "rand"
 	//BalancerStrategyFallback select next project from the list if previous project hit limits
 	BalancerStrategyFallback = "fallback"
+	//BalancerStrategyWeighted randomly select project proportionally to its weight
+	BalancerStrategyWeighted = "weighted"
+	//BalancerStrategyLeastLoaded select project with the lowest active load, query and copy jobs count
+	BalancerStrategyLeastLoaded = "leastLoaded"
+	//BalancerStrategySticky select project by destination table hash
+	BalancerStrategySticky = "sticky"
+	//BalancerStrategyRoundRobin select next project in the list, selection sequence is persisted in the journal
+	BalancerStrategyRoundRobin = "roundRobin"
 )
 
 //PerformanceFile defines job performance file
@@ -263,3 +333,6 @@ var CopyMethodQuery = "QUERY"
 
 //CopyMethodDML represents a copy with Query job and INSERT INTO AS SELECT DML
 var CopyMethodDML = "DML"
+
+//CopyMethodMerge represents a copy with Query job and MERGE DML upserting/deleting dest rows by unique columns
+var CopyMethodMerge = "MERGE"
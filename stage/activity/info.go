@@ -27,7 +27,16 @@ type Meta struct {
 
 //ID returns stage ID
 func (i *Meta) ID() string {
-	return path.Join(i.DestTable, fmt.Sprintf("%v_%05d_%v", i.EventID, i.Step%99999, i.Action)+shared.PathElementSeparator+i.Mode)
+	return path.Join(i.DestTable, i.stepID()+shared.PathElementSeparator+i.Mode)
+}
+
+//stepID returns event step ID, priority class is appended if specified
+func (i *Meta) stepID() string {
+	result := fmt.Sprintf("%v_%05d_%v", i.EventID, i.Step%99999, i.Action)
+	if i.Priority != "" {
+		result += "_" + i.Priority
+	}
+	return result
 }
 
 //JobFilename returns job filename
@@ -40,7 +49,16 @@ func (i *Meta) JobFilename() string {
 	if i.ProjectID != "" {
 		baseLocation = shared.TempProjectPrefix + i.ProjectID + ":" + i.Region + "/"
 	}
-	return baseLocation + dest + fmt.Sprintf("%v_%05d_%v", i.EventID, i.Step%99999, i.Action) + shared.PathElementSeparator + i.Mode
+	return baseLocation + dest + i.stepID() + shared.PathElementSeparator + i.Mode
+}
+
+//TaskFilename returns post-job task filename, the name is derived from BigQuery job ID, so that the task can be located with a job completion event
+func TaskFilename(projectID, region, jobID string) string {
+	baseLocation := ""
+	if projectID != "" {
+		baseLocation = shared.TempProjectPrefix + projectID + ":" + region + "/"
+	}
+	return baseLocation + jobID
 }
 
 //Sequence returns step sequence
@@ -116,6 +134,9 @@ func Parse(encoded string) *Meta {
 			result.Step = toolbox.AsInt(eventElements[1])
 			result.Action = eventElements[2]
 		}
+		if len(eventElements) > 3 {
+			result.Priority = eventElements[3]
+		}
 	}
 	result.Async = strings.HasSuffix(result.Mode, shared.StepModeDispach)
 	return result
@@ -28,6 +28,11 @@ func TestParse(t *testing.T) {
 			encoded:     "github.com/viant/bqtail:dummy/869694905034386_0004_load/dispatch",
 			expect:      `{"DestTable":"github.com/viant/bqtail:dummy","EventID":"869694905034386","Action":"load","Mode":"dispatch","Meta":4}`,
 		},
+		{
+			description: "info style priority",
+			encoded:     "bqtail:dummy/869694905034386_0004_load_revenue/dispatch",
+			expect:      `{"DestTable":"bqtail:dummy","EventID":"869694905034386","Action":"load","Priority":"revenue","Mode":"dispatch","Step":4,"Async":true}`,
+		},
 		{
 			description: "invalid",
 			encoded:     "github.com/viant/bqtail869694905034386--tail",
@@ -2,11 +2,15 @@ package load
 
 import (
 	sbatch "github.com/viant/bqtail/service/batch"
+	"github.com/viant/bqtail/service/bq"
 	"github.com/viant/bqtail/service/storage"
 	"github.com/viant/bqtail/shared"
+	ctransient "github.com/viant/bqtail/tail/config/transient"
 	"github.com/viant/bqtail/task"
 )
 
+const sourceURIsKey = "SourceURIs"
+
 func (j *Job) buildActions() (*task.Actions, error) {
 	actions := j.Rule.Actions().Clone()
 	if j.Window != nil {
@@ -15,9 +19,66 @@ func (j *Job) buildActions() (*task.Actions, error) {
 			j.buildGroupActions(actions)
 		}
 	}
+	j.buildChunkActions(actions)
 	j.buildProcessActions(actions)
+	actions = j.buildReconcileActions(actions)
 	result, err := j.buildTransientActions(actions)
-	return result, err
+	if err != nil {
+		return nil, err
+	}
+	return j.buildAssertActions(result), nil
+}
+
+//buildReconcileActions runs reconciliation after load or final copy, before rule actions
+func (j *Job) buildReconcileActions(actions *task.Actions) *task.Actions {
+	if j.Rule.Reconcile == nil {
+		return actions
+	}
+	dest := j.Rule.Dest
+	request := &bq.ReconcileRequest{
+		SourceFormat:    j.Load.SourceFormat,
+		SkipLeadingRows: int(j.Load.SkipLeadingRows),
+		ManifestExt:     j.Rule.Reconcile.ManifestExt,
+		LoadJobID:       "$" + shared.LoadJobIDKey,
+		Tolerance:       j.Rule.Reconcile.Tolerance,
+	}
+	//copy output is only expected to match load output without row filtering transformation
+	request.CheckCopy = dest.Transient != nil && len(dest.UniqueColumns) == 0 && dest.Dedupe == nil &&
+		dest.Transient.Criteria == "" && !dest.HasSplit() && !j.Rule.IsMergeCopy()
+	result := task.NewActions(nil, actions.OnFailure)
+	reconcile := bq.NewReconcileAction(request, actions)
+	reconcile.Request[sourceURIsKey] = shared.LoadURIsVar
+	result.AddOnSuccess(reconcile)
+	return result
+}
+
+//buildAssertActions runs transient assertions before temp table is copied to dest table
+func (j *Job) buildAssertActions(actions *task.Actions) *task.Actions {
+	transient := j.Rule.Dest.Transient
+	if transient == nil || transient.Assert == nil || j.DestTable == "" {
+		return actions
+	}
+	result := task.NewActions(nil, actions.OnFailure)
+	result.AddOnSuccess(bq.NewAssertAction(newAssertRequest(transient.Assert), actions))
+	return result
+}
+
+//newAssertRequest maps transient assert settings to assert request
+func newAssertRequest(assert *ctransient.Assert) *bq.AssertRequest {
+	result := &bq.AssertRequest{
+		QuarantineDataset: assert.QuarantineDataset,
+		Assertions:        make([]*bq.Assertion, 0, len(assert.Assertions)),
+	}
+	for _, assertion := range assert.Assertions {
+		result.Assertions = append(result.Assertions, &bq.Assertion{
+			Name: assertion.Name,
+			SQL:  assertion.SQL,
+			Min:  assertion.Min,
+			Max:  assertion.Max,
+			Warn: assertion.Warn,
+		})
+	}
+	return result
 }
 
 func (j *Job) buildGroupActions(actions *task.Actions) {
@@ -21,6 +21,9 @@ func buildBatchActions(window *batch.Window, actions *task.Actions) {
 	if len(window.Locations) > 0 {
 		URLsToDelete = append(URLsToDelete, window.Locations...)
 	}
+	if len(window.Members) > 0 {
+		URLsToDelete = append(URLsToDelete, window.Members...)
+	}
 	if len(URLsToDelete) == 0 {
 		return
 	}
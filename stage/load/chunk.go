@@ -0,0 +1,70 @@
+package load
+
+import (
+	"github.com/viant/afs/url"
+	"github.com/viant/afsc/gs"
+	"github.com/viant/bqtail/service/storage"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/task"
+)
+
+//UseChunks replaces oversized compressed data files with their chunks in load source URIs
+func (j *Job) UseChunks(chunks map[string][]string) {
+	if len(chunks) == 0 {
+		return
+	}
+	j.Chunks = chunks
+	var URIs = make([]string, 0)
+	for _, URI := range j.Load.SourceUris {
+		if dataChunks, ok := chunks[URI]; ok {
+			URIs = append(URIs, dataChunks...)
+			continue
+		}
+		URIs = append(URIs, URI)
+	}
+	j.Load.SourceUris = URIs
+}
+
+//SourceURIs returns data file URIs, chunk URIs are replaced with their data file, so that post actions operate on original data files
+func (j *Job) SourceURIs(URIs []string) []string {
+	if len(j.Chunks) == 0 {
+		return URIs
+	}
+	dataFiles := make(map[string]string)
+	for URI, dataChunks := range j.Chunks {
+		for _, chunk := range dataChunks {
+			dataFiles[chunk] = URI
+		}
+	}
+	var result = make([]string, 0)
+	var unique = make(map[string]bool)
+	for _, URI := range URIs {
+		if dataFile, ok := dataFiles[URI]; ok {
+			URI = dataFile
+		}
+		if unique[URI] {
+			continue
+		}
+		unique[URI] = true
+		result = append(result, URI)
+	}
+	return result
+}
+
+//buildChunkActions adds chunks clean up action
+func (j *Job) buildChunkActions(actions *task.Actions) {
+	if len(j.Chunks) == 0 {
+		return
+	}
+	URLsToDelete := make([]string, 0)
+	for _, dataChunks := range j.Chunks {
+		if len(dataChunks) == 0 {
+			continue
+		}
+		location, _ := url.Split(dataChunks[0], gs.Scheme)
+		URLsToDelete = append(URLsToDelete, location)
+	}
+	deleteReq := storage.DeleteRequest{URLs: URLsToDelete}
+	deleteAction, _ := task.NewAction(shared.ActionDelete, deleteReq)
+	actions.AddOnSuccess(deleteAction)
+}
@@ -31,6 +31,8 @@ type Job struct {
 	IsTablePartitioned bool                           `json:",omitempty"`
 	DestSchema         *bigquery.Table                `json:",omitempty"`
 	Actions            *task.Actions                  `json:",omitempty"`
+	Chunks             map[string][]string            `json:",omitempty"`
+	Downgraded         bool                           `json:",omitempty"`
 	BqJob              *bigquery.Job                  `json:"-"`
 	splitColumns       []*bigquery.TableFieldSchema
 }
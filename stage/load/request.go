@@ -9,14 +9,28 @@ import (
 
 //NewLoadRequest create a load request
 func (j *Job) NewLoadRequest() (*bq.LoadRequest, *task.Action) {
+	root := j.Process
+	meta := activity.New(root, shared.ActionLoad, root.Mode(shared.ActionLoad), root.IncStepCount())
+	return j.newLoadRequest(meta)
+}
+
+//NewReloadRequest create a reload request for supplied step, LoadJobID refers to the submitted reload job
+func (j *Job) NewReloadRequest(step int) (*bq.LoadRequest, *task.Action) {
+	root := j.Process
+	meta := activity.New(root, shared.ActionLoad, root.Mode(shared.ActionLoad), root.IncStepCount())
+	meta.Step = step
+	return j.newLoadRequest(meta.Wrap(shared.ActionReload))
+}
+
+func (j *Job) newLoadRequest(meta *activity.Meta) (*bq.LoadRequest, *task.Action) {
 	load := *j.Load
 	root := j.Process
 	loadRequest := &bq.LoadRequest{
 		Append:               j.Rule.IsAppend(),
 		JobConfigurationLoad: &load,
 	}
-	meta := activity.New(root, shared.ActionLoad, root.Mode(shared.ActionLoad), root.IncStepCount())
-	actions := j.Actions.Expand(root, shared.ActionLoad, load.SourceUris)
+	root.LoadJobID = meta.GetJobID()
+	actions := j.Actions.Expand(root, shared.ActionLoad, j.SourceURIs(load.SourceUris))
 	action := &task.Action{
 		Action:  shared.ActionLoad,
 		Actions: actions,
@@ -143,3 +143,53 @@ func loadTestAsset(ctx context.Context, asset interface{}, location string) erro
 	}
 	return json.Unmarshal(data, asset)
 }
+
+func TestJob_NewReloadRequest(t *testing.T) {
+	baseURL := path.Join(toolbox.CallerDirectory(3), "test")
+	ctx := context.Background()
+	var useCases = []struct {
+		description string
+		caseURL     string
+		step        int
+		expectStep  int
+	}{
+		{
+			description: "first reload",
+			caseURL:     path.Join(baseURL, "001_single_sync"),
+			step:        2,
+			expectStep:  3,
+		},
+		{
+			description: "subsequent reload",
+			caseURL:     path.Join(baseURL, "002_batch_sync"),
+			step:        4,
+			expectStep:  5,
+		},
+	}
+
+	for _, useCase := range useCases {
+		process := stage.Process{}
+		rule := config.Rule{}
+		window := batch.Window{}
+		tables := make(map[string]*bigquery.Table)
+		assert.Nil(t, loadTestAsset(ctx, &process, path.Join(useCase.caseURL, "process.json")), useCase.description)
+		assert.Nil(t, loadTestAsset(ctx, &rule, path.Join(useCase.caseURL, "rule.json")), useCase.description)
+		assert.Nil(t, loadTestAsset(ctx, &window, path.Join(useCase.caseURL, "window.json")), useCase.description)
+		assert.Nil(t, loadTestAsset(ctx, &tables, path.Join(useCase.caseURL, "tables.json")), useCase.description)
+		var batchWindow *batch.Window
+		if rule.Batch != nil {
+			batchWindow = &window
+		}
+		job, err := NewJob(&rule, &process, batchWindow, nil)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		if !assert.Nil(t, job.Init(ctx, bq.NewFakerWithTables(tables)), useCase.description) {
+			continue
+		}
+		_, action := job.NewReloadRequest(useCase.step)
+		assert.Equal(t, shared.ActionReload, action.Meta.Action, useCase.description)
+		assert.Equal(t, useCase.expectStep, action.Meta.Step, useCase.description)
+		assert.Equal(t, action.Meta.GetJobID(), job.Process.LoadJobID, useCase.description)
+	}
+}
@@ -114,6 +114,9 @@ func resetExpiryTime(table *bigquery.Table) {
 func (j *Job) updateSchema(table *bigquery.Table) error {
 	if table != nil {
 		j.Load.Schema = table.Schema
+		if evolution := j.Rule.Dest.SchemaEvolution; evolution != nil && len(evolution.Aliases) > 0 && table.Schema != nil {
+			j.Load.Schema = &bigquery.TableSchema{Fields: evolution.AliasFields(table.Schema.Fields)}
+		}
 		if table.TimePartitioning != nil {
 			j.Load.TimePartitioning = table.TimePartitioning
 			j.Load.TimePartitioning.RequirePartitionFilter = false
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/viant/bqtail/base"
 	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/tail/config"
 	"github.com/viant/bqtail/tail/sql"
 	"github.com/viant/bqtail/task"
 	"google.golang.org/api/bigquery/v2"
@@ -29,20 +30,39 @@ func (j *Job) addSplitActions(selectSQL string, result, onDone *task.Actions) er
 	}
 	clusterColumnMap := j.clusterColumnMap()
 
+	tempRef, _ := base.NewTableReference(j.TempTable)
+	if split.Dynamic != nil {
+		dynamic := split.Dynamic
+		then := strings.Replace(dynamic.Then, dynamic.Placeholder(), bq.SplitValuePlaceholder, -1)
+		destTable, _ := dest.CustomTableReference(then, j.Process.Source)
+		request := &bq.SplitRequest{
+			Table:     j.TempTable,
+			Column:    replaceWithMap(dynamic.Column, clusterColumnMap),
+			SQL:       j.splitSQL(selectSQL, tempRef, destTable, dest),
+			Dest:      base.EncodeTableReference(destTable, false),
+			Template:  destTemplate,
+			Values:    dynamic.Values,
+			MaxTables: dynamic.MaxTables,
+			Append:    j.Rule.IsAppend(),
+		}
+		if dynamic.Else != "" {
+			elseTable, _ := dest.CustomTableReference(dynamic.Else, j.Process.Source)
+			request.Else = base.EncodeTableReference(elseTable, false)
+			request.ElseSQL = j.splitSQL(selectSQL, tempRef, elseTable, dest)
+		}
+		next = task.NewActions(nil, nil)
+		next.AddOnSuccess(bq.NewSplitAction(request, onDone))
+	}
+
 	for i := range split.Mapping {
 		mapping := split.Mapping[i]
 		destTable, _ := dest.CustomTableReference(mapping.Then, j.Process.Source)
 		where := replaceWithMap(mapping.When, clusterColumnMap)
+		SQL := strings.Replace(j.splitSQL(selectSQL, tempRef, destTable, dest), "$WHERE", " WHERE  "+where+" ", 1)
 		var query *task.Action
-
-		tempRef, _ := base.NewTableReference(j.TempTable)
-		if j.Rule.IsDMLCopy() {
-			SQL := sql.BuildAppendDML(tempRef, destTable, j.Load.Schema, dest, j.getDestTableSchema())
-			SQL = strings.Replace(SQL, "$WHERE", " WHERE  "+where+" ", 1)
+		if j.Rule.IsDMLCopy() || j.Rule.IsMergeCopy() {
 			query = bq.NewDMLAction(SQL, destTable, destTemplate, true, next)
-
 		} else {
-			SQL := strings.Replace(selectSQL, "$WHERE", " WHERE  "+where+" ", 1)
 			query = bq.NewQueryAction(SQL, destTable, destTemplate, j.Rule.IsAppend(), next)
 		}
 		group := task.NewActions(nil, nil)
@@ -82,7 +102,7 @@ func (j *Job) addSplitActions(selectSQL string, result, onDone *task.Actions) er
 	//otherwise load job would fail
 
 	sourceRef, _ := base.NewTableReference(j.SplitTable())
-	selectAll := sql.BuildSelect(sourceRef, j.SplitSchema.Schema, dest, j.getDestTableSchema())
+	selectAll := sql.BuildSelect(sourceRef, nil, j.SplitSchema.Schema, dest, j.getDestTableSchema())
 	selectAll = strings.Replace(selectAll, "$WHERE", j.getDMLWhereClause(), 1)
 	destRef, _ := base.NewTableReference(j.TempTable)
 
@@ -92,6 +112,19 @@ func (j *Job) addSplitActions(selectSQL string, result, onDone *task.Actions) er
 	return nil
 }
 
+//splitSQL returns split destination table SQL with $WHERE placeholder
+func (j *Job) splitSQL(selectSQL string, tempRef, destTable *bigquery.TableReference, dest *config.Destination) string {
+	switch {
+	case j.Rule.IsDMLCopy():
+		return sql.BuildAppendDML(tempRef, destTable, j.Load.Schema, dest, j.getDestTableSchema())
+	case j.Rule.IsMergeCopy():
+		return sql.BuildMergeDML(tempRef, destTable, j.Load.Schema, dest, j.getDestTableSchema(), j.isIngestionTimePartitioned())
+	case dest.Dedupe != nil:
+		return sql.BuildSelect(tempRef, destTable, j.Load.Schema, dest, j.getDestTableSchema())
+	}
+	return selectSQL
+}
+
 func (j *Job) getDestTableSchema() *bigquery.TableSchema {
 	var destSchema *bigquery.TableSchema
 	if j.DestSchema != nil {
@@ -100,6 +133,11 @@ func (j *Job) getDestTableSchema() *bigquery.TableSchema {
 	return destSchema
 }
 
+//isIngestionTimePartitioned returns true if dest table is partitioned by ingestion time
+func (j *Job) isIngestionTimePartitioned() bool {
+	return j.DestSchema != nil && j.DestSchema.TimePartitioning != nil && j.DestSchema.TimePartitioning.Field == ""
+}
+
 func replaceWithMap(when string, columnMap map[string]string) string {
 	for k, v := range columnMap {
 		count := strings.Count(when, k)
@@ -27,7 +27,7 @@ func (j Job) buildTransientActions(actions *task.Actions) (*task.Actions, error)
 
 	destinationTable, _ := dest.CustomTableReference(j.DestTable, j.Source)
 
-	if dest.Schema.Autodetect {
+	if dest.Schema.Autodetect || j.Downgraded {
 		source := base.EncodeTableReference(load.DestinationTable, false)
 		destRef := base.EncodeTableReference(destinationTable, false)
 		if j.Rule.IsDMLCopy() && load.Schema != nil {
@@ -39,10 +39,10 @@ func (j Job) buildTransientActions(actions *task.Actions) (*task.Actions, error)
 		return result, nil
 	}
 
-	selectAll := sql.BuildSelect(load.DestinationTable, load.Schema, dest, j.getDestTableSchema())
+	selectAll := sql.BuildSelect(load.DestinationTable, destinationTable, load.Schema, dest, j.getDestTableSchema())
 	if dest.HasSplit() {
 		tempRef, _ := base.NewTableReference(j.TempTable)
-		selectAll := sql.BuildSelect(tempRef, load.Schema, dest, j.getDestTableSchema())
+		selectAll := sql.BuildSelect(tempRef, nil, load.Schema, dest, j.getDestTableSchema())
 		return result, j.addSplitActions(selectAll, result, actions)
 	}
 
@@ -57,6 +57,10 @@ func (j Job) buildTransientActions(actions *task.Actions) (*task.Actions, error)
 		j.addDMLCopy(load, destinationTable, dest, actions, result)
 		return result, nil
 	}
+	if j.Rule.IsMergeCopy() {
+		j.addMergeCopy(load, destinationTable, dest, destTemplate, actions, result)
+		return result, nil
+	}
 	canCopy := schema.CanCopy(j.TempSchema, j.DestSchema)
 
 	if j.Rule.Dest.IsCopyMethodQuery() || partition != "" || !canCopy {
@@ -78,6 +82,13 @@ func (j Job) addDMLCopy(load *bigquery.JobConfigurationLoad, destinationTable *b
 	result.AddOnSuccess(query)
 }
 
+func (j Job) addMergeCopy(load *bigquery.JobConfigurationLoad, destinationTable *bigquery.TableReference, dest *config.Destination, destTemplate string, actions *task.Actions, result *task.Actions) {
+	SQL := sql.BuildMergeDML(load.DestinationTable, destinationTable, load.Schema, dest, j.getDestTableSchema(), j.isIngestionTimePartitioned())
+	SQL = strings.Replace(SQL, "$WHERE", j.getDMLWhereClause(), 1)
+	query := bq.NewDMLAction(SQL, destinationTable, destTemplate, true, actions)
+	result.AddOnSuccess(query)
+}
+
 func (j Job) getDMLWhereClause() string {
 	where := ""
 	if j.Rule.Dest.Transient.Criteria != "" {
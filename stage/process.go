@@ -26,6 +26,8 @@ type Process struct {
 	TempTable      string                 `json:",omitempty"`
 	DestTable      string                 `json:",omitempty"`
 	StepCount      int                    `json:",omitempty"`
+	LoadJobID      string                 `json:",omitempty"`
+	Priority       string                 `json:",omitempty"`
 }
 
 func (p *Process) SplitTable() string {
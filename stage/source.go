@@ -10,6 +10,8 @@ type Source struct {
 	URL    string    `json:",omitempty"`
 	Time   time.Time `json:",omitempty"`
 	Status string    `json:",omitempty"`
+	Size   int64     `json:",omitempty"`
+	Chunks []string  `json:",omitempty" description:"line aligned chunks loaded instead of oversized compressed data file"`
 }
 
 //NewSource creates a source
@@ -0,0 +1,195 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/shared"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+//WindowLocker represents batch window lock backend, only one process can own a window
+type WindowLocker interface {
+	//IsLocked returns true if window URL is owned by some process
+	IsLocked(ctx context.Context, URL string) (bool, error)
+
+	//TryLock tries to store window and take its ownership, returns false if window is owned by other process
+	TryLock(ctx context.Context, window *Window) (bool, error)
+}
+
+//generationLocker uses storage generation precondition, only the first upload of the window file succeeds
+type generationLocker struct {
+	fs afs.Service
+}
+
+//IsLocked returns true if window file exists
+func (l *generationLocker) IsLocked(ctx context.Context, URL string) (bool, error) {
+	return l.fs.Exists(ctx, URL, option.NewObjectKind(true))
+}
+
+//TryLock uploads window file if it does not exist, precondition or rate limit error means that other process owns the window
+func (l *generationLocker) TryLock(ctx context.Context, window *Window) (bool, error) {
+	data, err := json.Marshal(window)
+	if err != nil {
+		return false, err
+	}
+	err = l.fs.Upload(ctx, window.URL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, 0))
+	if isPreConditionError(err) || isRateError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to upload: %v", window.URL)
+	}
+	return true, nil
+}
+
+//NewGenerationLocker creates a storage generation precondition window locker (default)
+func NewGenerationLocker(fs afs.Service) WindowLocker {
+	return &generationLocker{fs: fs}
+}
+
+//fileLocker uses exclusive local file creation, intended for standalone bqtail client
+type fileLocker struct {
+	fs  afs.Service
+	mux sync.Mutex
+}
+
+//IsLocked returns true if window file exists
+func (l *fileLocker) IsLocked(ctx context.Context, URL string) (bool, error) {
+	return l.fs.Exists(ctx, URL, option.NewObjectKind(true))
+}
+
+//TryLock creates window file exclusively, for non local URL it serializes lock attempts within the current process
+func (l *fileLocker) TryLock(ctx context.Context, window *Window) (bool, error) {
+	data, err := json.Marshal(window)
+	if err != nil {
+		return false, err
+	}
+	if url.Scheme(window.URL, file.Scheme) != file.Scheme {
+		l.mux.Lock()
+		defer l.mux.Unlock()
+		if exists, _ := l.fs.Exists(ctx, window.URL, option.NewObjectKind(true)); exists {
+			return false, nil
+		}
+		if err = l.fs.Upload(ctx, window.URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+			return false, errors.Wrapf(err, "failed to upload: %v", window.URL)
+		}
+		return true, nil
+	}
+	location := url.Path(window.URL)
+	if err = os.MkdirAll(path.Dir(location), file.DefaultDirOsMode); err != nil {
+		return false, errors.Wrapf(err, "failed to create window location: %v", location)
+	}
+	writer, err := os.OpenFile(location, os.O_CREATE|os.O_EXCL|os.O_WRONLY, file.DefaultFileOsMode)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create: %v", location)
+	}
+	_, err = writer.Write(data)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(location)
+		return false, errors.Wrapf(err, "failed to write: %v", location)
+	}
+	return true, nil
+}
+
+//NewFileLocker creates a local file window locker
+func NewFileLocker(fs afs.Service) WindowLocker {
+	return &fileLocker{fs: fs}
+}
+
+//leaseLocker stores window with a lease expiry, once the lease expired the window is deemed orphaned and can be reclaimed
+type leaseLocker struct {
+	fs       afs.Service
+	duration time.Duration
+}
+
+//IsLocked returns true if window file exists with active lease
+func (l *leaseLocker) IsLocked(ctx context.Context, URL string) (bool, error) {
+	window, _, err := l.load(ctx, URL)
+	if err != nil || window == nil {
+		return false, err
+	}
+	return window.IsLeaseActive(time.Now()), nil
+}
+
+//TryLock uploads window if it does not exist, or reclaims existing window when its lease expired or when the owning event is retried.
+//Reclaim uses generation precondition, thus only one process can take over an orphaned window
+func (l *leaseLocker) TryLock(ctx context.Context, window *Window) (bool, error) {
+	leaseExpiry := window.End.Add(l.duration)
+	window.LeaseExpiry = &leaseExpiry
+	data, err := json.Marshal(window)
+	if err != nil {
+		return false, err
+	}
+	existing, generation, err := l.load(ctx, window.URL)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && existing.IsLeaseActive(time.Now()) && existing.EventID != window.EventID {
+		return false, nil
+	}
+	if existing != nil && shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] reclaiming batch window: %v, owner: %v\n", window.DestTable, window.URL, existing.EventID)
+	}
+	err = l.fs.Upload(ctx, window.URL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, generation))
+	if isPreConditionError(err) || isRateError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to upload: %v", window.URL)
+	}
+	return true, nil
+}
+
+func (l *leaseLocker) load(ctx context.Context, URL string) (*Window, int64, error) {
+	exists, err := l.fs.Exists(ctx, URL, option.NewObjectKind(true))
+	if err != nil || !exists {
+		return nil, 0, err
+	}
+	generation := &option.Generation{}
+	data, err := l.fs.DownloadWithURL(ctx, URL, generation)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read window: %v", URL)
+	}
+	window := &Window{}
+	if err = json.Unmarshal(data, window); err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to unmarshal window: %v", URL)
+	}
+	return window, generation.Generation, nil
+}
+
+//NewLeaseLocker creates a lease window locker, lease expires after window end time plus supplied duration
+func NewLeaseLocker(fs afs.Service, duration time.Duration) WindowLocker {
+	return &leaseLocker{fs: fs, duration: duration}
+}
+
+//NewWindowLocker creates window locker for supplied kind
+func NewWindowLocker(kind string, leaseDuration time.Duration, fs afs.Service) (WindowLocker, error) {
+	switch kind {
+	case "", shared.WindowLockerGeneration:
+		return NewGenerationLocker(fs), nil
+	case shared.WindowLockerFile:
+		return NewFileLocker(fs), nil
+	case shared.WindowLockerLease:
+		if leaseDuration <= 0 {
+			leaseDuration = shared.DefaultWindowLeaseInSec * time.Second
+		}
+		return NewLeaseLocker(fs, leaseDuration), nil
+	}
+	return nil, fmt.Errorf("unsupported window locker: %v", kind)
+}
@@ -0,0 +1,116 @@
+package batch
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/bqtail/stage"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestWindowLocker_TryLock(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	tempDir, err := ioutil.TempDir("", "locker")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(tempDir)
+	now := time.Now()
+
+	var useCases = []struct {
+		description  string
+		locker       WindowLocker
+		URL          string
+		ownerEnd     time.Time
+		ownerEventID string
+		eventID      string
+		expectLocked bool
+		expectOwned  bool
+	}{
+		{
+			description:  "generation locker - window owned by other event",
+			locker:       NewGenerationLocker(fs),
+			URL:          "mem://localhost/locker/generation/t1_1.win",
+			ownerEnd:     now,
+			ownerEventID: "1",
+			eventID:      "2",
+			expectLocked: true,
+		},
+		{
+			description:  "file locker - window owned by other event",
+			locker:       NewFileLocker(fs),
+			URL:          "file://" + path.Join(tempDir, "t1_1.win"),
+			ownerEnd:     now,
+			ownerEventID: "1",
+			eventID:      "2",
+			expectLocked: true,
+		},
+		{
+			description:  "file locker - non local window owned by other event",
+			locker:       NewFileLocker(fs),
+			URL:          "mem://localhost/locker/file/t1_1.win",
+			ownerEnd:     now,
+			ownerEventID: "1",
+			eventID:      "2",
+			expectLocked: true,
+		},
+		{
+			description:  "lease locker - active lease",
+			locker:       NewLeaseLocker(fs, time.Minute),
+			URL:          "mem://localhost/locker/lease/t1_1.win",
+			ownerEnd:     now,
+			ownerEventID: "1",
+			eventID:      "2",
+			expectLocked: true,
+		},
+		{
+			description:  "lease locker - expired lease reclaimed",
+			locker:       NewLeaseLocker(fs, time.Minute),
+			URL:          "mem://localhost/locker/lease/t1_2.win",
+			ownerEnd:     now.Add(-2 * time.Minute),
+			ownerEventID: "1",
+			eventID:      "2",
+			expectLocked: false,
+			expectOwned:  true,
+		},
+		{
+			description:  "lease locker - retried owner event reclaims window",
+			locker:       NewLeaseLocker(fs, time.Minute),
+			URL:          "mem://localhost/locker/lease/t1_3.win",
+			ownerEnd:     now,
+			ownerEventID: "1",
+			eventID:      "1",
+			expectLocked: true,
+			expectOwned:  true,
+		},
+	}
+
+	for _, useCase := range useCases {
+		owner := NewWindow(&stage.Process{EventID: useCase.ownerEventID}, useCase.ownerEnd.Add(-time.Minute), useCase.ownerEnd, useCase.URL)
+		acquired, err := useCase.locker.TryLock(ctx, owner)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.True(t, acquired, useCase.description)
+		locked, err := useCase.locker.IsLocked(ctx, useCase.URL)
+		assert.Nil(t, err, useCase.description)
+		assert.Equal(t, useCase.expectLocked, locked, useCase.description)
+
+		window := NewWindow(&stage.Process{EventID: useCase.eventID}, now.Add(-time.Minute), now, useCase.URL)
+		acquired, err = useCase.locker.TryLock(ctx, window)
+		assert.Nil(t, err, useCase.description)
+		assert.Equal(t, useCase.expectOwned, acquired, useCase.description)
+		if !acquired {
+			continue
+		}
+		actual, err := GetWindow(ctx, useCase.URL, fs)
+		if assert.Nil(t, err, useCase.description) {
+			assert.Equal(t, useCase.eventID, actual.EventID, useCase.description)
+		}
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"time"
 )
 
+const sealCheckInterval = 3 * time.Second
+
 // Service representa a batch service
 type Service interface {
 	//Try to acquire batch window
@@ -32,11 +34,18 @@ type Service interface {
 
 	//AcquireGroup acquired group for the process
 	AcquireGroup(ctx context.Context, process *stage.Process, rule *config.Rule) (*Group, error)
+
+	//IsSealed returns true if window has been sealed by batch limits
+	IsSealed(ctx context.Context, window *Window) bool
+
+	//Wait waits for supplied duration or till window is sealed by batch limits
+	Wait(ctx context.Context, rule *config.Rule, window *Window, duration time.Duration)
 }
 
 type service struct {
 	taskURLProvider func(rule *config.Rule) string
 	fs              afs.Service
+	locker          WindowLocker
 }
 
 // addLocationFile tracks parent locations for a batch
@@ -55,43 +64,113 @@ func (s *service) addLocationFile(ctx context.Context, window *Window, location
 
 // TryAcquireWindow try to acquire window for batched transfer, only one cloud function can acquire window
 func (s *service) TryAcquireWindow(ctx context.Context, process *stage.Process, rule *config.Rule) (info *Info, err error) {
+	rule = s.narrowestAcquired(ctx, process, rule)
+	seq := 0
+	var sealed []int
+	if rule.Batch.HasLimits() {
+		state := NewState(s.stateURL(process, rule), s.fs)
+		if seq, sealed, err = state.Add(ctx, process.Source.URL, process.Source.Size, rule.Batch.MaxFiles, rule.Batch.MaxBytes); err != nil {
+			return nil, err
+		}
+	}
 	err = base.RunWithRetriesOnRetryOrInternalError(func() error {
-		info, err = s.tryAcquireWindow(ctx, process, rule)
+		info, err = s.tryAcquireWindow(ctx, process, rule, seq)
 		return err
 	})
+	if err == nil {
+		for _, sealedSeq := range sealed {
+			sealedURL := s.windowURL(process, rule, sealedSeq)
+			info.SealedWindowURLs = append(info.SealedWindowURLs, sealedURL)
+			if shared.IsInfoLoggingLevel() {
+				shared.LogF("[%v] sealed batch window: %v\n", process.DestTable, sealedURL)
+			}
+		}
+	}
 	return info, err
 
 }
 
-// TryAcquireWindow try to acquire window for batched transfer, only one cloud function can acquire window
-func (s *service) tryAcquireWindow(ctx context.Context, process *stage.Process, rule *config.Rule) (*Info, error) {
+// narrowestAcquired returns rule with the narrowest window already acquired for the source time at any widening level, so that windows do not overlap
+func (s *service) narrowestAcquired(ctx context.Context, process *stage.Process, rule *config.Rule) *config.Rule {
+	if !rule.Batch.IsWidened() {
+		return rule
+	}
+	for level := 0; ; level++ {
+		batch := rule.Batch.Widen(level)
+		if batch.Window.DurationInSec != rule.Batch.Window.DurationInSec {
+			candidate := rule.WithBatch(batch)
+			if locked, _ := s.locker.IsLocked(ctx, s.windowURL(process, candidate, 0)); locked {
+				return candidate
+			}
+		}
+		if batch.Window.DurationInSec >= config.MaxWindowDurationSec {
+			return rule
+		}
+	}
+}
+
+func (s *service) windowDest(process *stage.Process, rule *config.Rule, seq int) string {
 	parentURL, _ := url.Split(process.Source.URL, gs.Scheme)
-	windowDest := process.DestTable
 	ext := path.Ext(process.Source.URL)
-
 	pattenHash := ""
 	if rule.Batch != nil && rule.Batch.UsePatternHash {
 		hash := rule.Dest.PatternHash(process.Source)
 		pattenHash = strconv.Itoa(int(hash))
 	}
-
 	suffixRaw := process.DestTable + rule.When.Suffix + pattenHash + ext
-
 	if !rule.Batch.MultiPath {
 		suffixRaw += parentURL
 	}
-	windowDest = fmt.Sprintf("%v_%v", process.DestTable, base.Hash(suffixRaw))
+	if seq > 0 {
+		suffixRaw += fmt.Sprintf("/%v", seq)
+	}
+	return fmt.Sprintf("%v_%v", process.DestTable, base.Hash(suffixRaw))
+}
+
+// windowURL returns window URL for supplied window sequence
+func (s *service) windowURL(process *stage.Process, rule *config.Rule, seq int) string {
+	return rule.Batch.WindowURL(s.taskURLProvider(rule), s.windowDest(process, rule, seq), process.Source.Time)
+}
+
+// stateURL returns batch window state folder URL, the state is shared by all window sequences within the same window time span
+func (s *service) stateURL(process *stage.Process, rule *config.Rule) string {
+	return strings.Replace(s.windowURL(process, rule, 0), shared.WindowExt, "/state", 1)
+}
+
+// addMemberFile tracks source file for a window sealed by batch limits
+func (s *service) addMemberFile(ctx context.Context, window *Window, source *stage.Source) error {
+	memberFile := fmt.Sprintf("%v%v", base.Hash(source.URL), shared.MemberExt)
+	URL := strings.Replace(window.URL, shared.WindowExt, "/"+memberFile, 1)
+	resource := &Resource{URL: source.URL, ModTime: source.Time, Size: source.Size}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	return s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data))
+}
+
+// TryAcquireWindow try to acquire window for batched transfer, only one cloud function can acquire window
+func (s *service) tryAcquireWindow(ctx context.Context, process *stage.Process, rule *config.Rule, seq int) (*Info, error) {
+	parentURL, _ := url.Split(process.Source.URL, gs.Scheme)
+	windowDest := s.windowDest(process, rule, seq)
 	taskURL := s.taskURLProvider(rule)
 	batch := rule.Batch
 	windowURL := batch.WindowURL(taskURL, windowDest, process.Source.Time)
-	exists, _ := s.fs.Exists(ctx, windowURL, option.NewObjectKind(true))
+	locked, err := s.locker.IsLocked(ctx, windowURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check window lock: %v", windowURL)
+	}
 
 	endTime := batch.WindowEndTime(process.Source.Time)
-	startTime := endTime.Add(-batch.Window.Duration)
-	var err error
+	startTime := batch.WindowStartTime(process.Source.Time)
 	var window *Window
-	if exists {
+	if locked {
 		window = NewWindow(process, startTime, endTime, windowURL)
+		if batch.HasLimits() {
+			if err = s.addMemberFile(ctx, window, process.Source); err != nil {
+				return nil, err
+			}
+		}
 		if rule.Batch.MultiPath {
 			err = s.addLocationFile(ctx, window, parentURL)
 		}
@@ -105,12 +184,19 @@ func (s *service) tryAcquireWindow(ctx context.Context, process *stage.Process,
 		}
 	}
 	window = NewWindow(process, startTime, endTime, windowURL)
-	windowData, _ := json.Marshal(window)
-	err = s.fs.Upload(ctx, windowURL, file.DefaultFileOsMode, bytes.NewReader(windowData), option.NewGeneration(true, 0))
-
-	//If file does exists by  Exists operation, try to upload batch file,
-	//if there is a race condition ignore precondition or rate limit it means batch file exists, - ignore error and quite
-	if isPreConditionError(err) || isRateError(err) {
+	if batch.HasLimits() {
+		window.Seq = seq
+		window.StateURL = s.stateURL(process, rule)
+		if err = s.addMemberFile(ctx, window, process.Source); err != nil {
+			return nil, err
+		}
+	}
+	acquired, err := s.locker.TryLock(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	//if there is a race condition, the window locker reports window as owned by other process - quit
+	if !acquired {
 		window := NewWindow(process, startTime, endTime, windowURL)
 		if rule.Batch.MultiPath {
 			if err = s.addLocationFile(ctx, window, parentURL); err != nil {
@@ -159,24 +245,117 @@ func (s *service) getBaseURLS(ctx context.Context, rule *config.Rule, window *Wi
 // MatchWindowData matches window data, it waits for window to ends if needed
 func (s *service) MatchWindowDataURLs(ctx context.Context, rule *config.Rule, window *Window) (err error) {
 	window.Resources = make([]*Resource, 0)
-	var baseURLS []string
-	err = base.RunWithRetries(func() error {
-		baseURLS, err = s.getBaseURLS(ctx, rule, window)
-		return err
-	})
-	if err != nil {
-		return errors.Wrapf(err, "failed get batch location: %v", window.URL)
-	}
 	var result = make([]string, 0)
-	for _, baseURL := range baseURLS {
-		if err := s.matchData(ctx, window, rule, baseURL, &result); err != nil {
+	if rule.Batch.HasLimits() && window.StateURL != "" {
+		err = base.RunWithRetries(func() error {
+			result = make([]string, 0)
+			window.Resources = make([]*Resource, 0)
+			return s.matchMembers(ctx, window, &result)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed get batch members: %v", window.URL)
+		}
+		if !s.IsSealed(ctx, window) { //the last window in the time span removes the batch state
+			window.Locations = append(window.Locations, window.StateURL)
+		}
+	} else {
+		var baseURLS []string
+		err = base.RunWithRetries(func() error {
+			baseURLS, err = s.getBaseURLS(ctx, rule, window)
 			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed get batch location: %v", window.URL)
+		}
+		for _, baseURL := range baseURLS {
+			if err := s.matchData(ctx, window, rule, baseURL, &result); err != nil {
+				return err
+			}
 		}
 	}
 	window.URIs = result
+	window.Parts = splitResources(window.Resources, rule.Batch)
+	return nil
+}
+
+// matchMembers matches window data with window member files
+func (s *service) matchMembers(ctx context.Context, window *Window, result *[]string) error {
+	URL := strings.Replace(window.URL, shared.WindowExt, "/", 1)
+	objects, err := s.fs.List(ctx, URL)
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if object.IsDir() || path.Ext(object.Name()) != shared.MemberExt {
+			continue
+		}
+		data, err := s.fs.Download(ctx, object)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load member: %v", object.URL())
+		}
+		resource := &Resource{}
+		if err = json.Unmarshal(data, resource); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal member: %v", object.URL())
+		}
+		resource.MemberURL = object.URL()
+		*result = append(*result, resource.URL)
+		window.Resources = append(window.Resources, resource)
+		window.Members = append(window.Members, object.URL())
+	}
 	return nil
 }
 
+// splitResources splits resources into parts if they exceed load job limits, it returns nil if split is not needed
+func splitResources(resources []*Resource, batch *config.Batch) []*Part {
+	maxFiles, maxBytes := batch.LoadLimits()
+	var result = make([]*Part, 0)
+	part := &Part{}
+	var size int64
+	for _, resource := range resources {
+		if len(part.URIs) > 0 && (len(part.URIs) >= maxFiles || size+resource.Size > maxBytes) {
+			result = append(result, part)
+			part = &Part{}
+			size = 0
+		}
+		part.URIs = append(part.URIs, resource.URL)
+		part.Resources = append(part.Resources, resource)
+		size += resource.Size
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return append(result, part)
+}
+
+// IsSealed returns true if window has been sealed by batch limits
+func (s *service) IsSealed(ctx context.Context, window *Window) bool {
+	if window.StateURL == "" {
+		return false
+	}
+	seq, err := NewState(window.StateURL, s.fs).Sequence(ctx)
+	return err == nil && seq > window.Seq
+}
+
+// Wait waits for supplied duration or till window is sealed by batch limits
+func (s *service) Wait(ctx context.Context, rule *config.Rule, window *Window, duration time.Duration) {
+	if !rule.Batch.HasLimits() || window.StateURL == "" {
+		time.Sleep(duration)
+		return
+	}
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		if s.IsSealed(ctx, window) {
+			time.Sleep(shared.StorageListVisibilityDelayMs * time.Millisecond)
+			return
+		}
+		remaining := deadline.Sub(time.Now())
+		if remaining > sealCheckInterval {
+			remaining = sealCheckInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
 func (s *service) matchData(ctx context.Context, window *Window, rule *config.Rule, baseURL string, result *[]string) error {
 
 	objects, err := s.fs.List(ctx, baseURL)
@@ -200,7 +379,7 @@ func (s *service) matchData(ctx context.Context, window *Window, rule *config.Ru
 				continue
 			}
 			*result = append(*result, object.URL())
-			window.Resources = append(window.Resources, &Resource{URL: object.URL(), ModTime: object.ModTime()})
+			window.Resources = append(window.Resources, &Resource{URL: object.URL(), ModTime: object.ModTime(), Size: object.Size()})
 		}
 	}
 	return nil
@@ -230,10 +409,14 @@ func (s *service) AcquireGroup(ctx context.Context, process *stage.Process, rule
 	return group, nil
 }
 
-// New create stage service
-func New(batchURLProvider func(rule *config.Rule) string, storageService afs.Service) Service {
+// New create stage service, if locker is nil storage generation precondition window locker is used
+func New(batchURLProvider func(rule *config.Rule) string, storageService afs.Service, locker WindowLocker) Service {
+	if locker == nil {
+		locker = NewGenerationLocker(storageService)
+	}
 	return &service{
 		taskURLProvider: batchURLProvider,
 		fs:              storageService,
+		locker:          locker,
 	}
 }
@@ -0,0 +1,58 @@
+package batch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"testing"
+)
+
+func Test_splitResources(t *testing.T) {
+	var newResources = func(count int, size int64) []*Resource {
+		var result = make([]*Resource, count)
+		for i := range result {
+			result[i] = &Resource{URL: "gs://bucket/data/file.json", Size: size}
+		}
+		return result
+	}
+	var useCases = []struct {
+		description string
+		batch       *config.Batch
+		resources   []*Resource
+		expectParts []int
+	}{
+		{
+			description: "within limits",
+			batch:       &config.Batch{},
+			resources:   newResources(10, 1024),
+		},
+		{
+			description: "max files",
+			batch:       &config.Batch{MaxFiles: 4},
+			resources:   newResources(10, 1024),
+			expectParts: []int{4, 4, 2},
+		},
+		{
+			description: "max bytes",
+			batch:       &config.Batch{MaxBytes: 3000},
+			resources:   newResources(5, 1024),
+			expectParts: []int{2, 2, 1},
+		},
+		{
+			description: "BigQuery max load URIs",
+			batch:       &config.Batch{},
+			resources:   newResources(shared.MaxLoadURIs+1, 1),
+			expectParts: []int{shared.MaxLoadURIs, 1},
+		},
+	}
+
+	for _, useCase := range useCases {
+		parts := splitResources(useCase.resources, useCase.batch)
+		if !assert.Equal(t, len(useCase.expectParts), len(parts), useCase.description) {
+			continue
+		}
+		for i, part := range parts {
+			assert.Equal(t, useCase.expectParts[i], len(part.URIs), useCase.description)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxStateRetries = 10
+
+//State represents batch window state folder, it tracks files count and size to seal a window early.
+//Each added file writes its own entry ($URL/$seq/$hash_$size.bst) and a sealed window has a create only marker ($URL/$seq.sld),
+//so concurrent events never update the same object, only sealing a window is contended.
+type State struct {
+	URL string
+	fs  afs.Service
+}
+
+//Add adds a file to the current window, it returns file window sequence and sealed window sequences, a file overflowing the current window can seal both the current and its own window
+func (s *State) Add(ctx context.Context, sourceURL string, size int64, maxFiles int, maxBytes int64) (seq int, sealed []int, err error) {
+	retry := base.NewRetry()
+	for ; retry.Count < maxStateRetries; retry.Count++ {
+		if seq, err = s.add(ctx, sourceURL, size, maxFiles, maxBytes, &sealed); err == nil {
+			return seq, sealed, nil
+		}
+		if !(isRateError(err) || base.IsRetryError(err) || base.IsInternalError(err)) {
+			break
+		}
+		time.Sleep(retry.Pause())
+	}
+	return 0, sealed, errors.Wrapf(err, "failed to update batch state: %v", s.URL)
+}
+
+func (s *State) add(ctx context.Context, sourceURL string, size int64, maxFiles int, maxBytes int64, sealed *[]int) (int, error) {
+	entry := fmt.Sprintf("%v_%v%v", base.Hash(sourceURL), size, shared.BatchStateExt)
+	for {
+		seq, err := s.Sequence(ctx)
+		if err != nil {
+			return 0, err
+		}
+		files, bytes, err := s.totals(ctx, seq, entry)
+		if err != nil {
+			return 0, err
+		}
+		if files > 0 && maxBytes > 0 && bytes+size > maxBytes {
+			if err = s.seal(ctx, seq, sealed); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		entryURL := url.Join(s.URL, strconv.Itoa(seq), entry)
+		if err = s.fs.Upload(ctx, entryURL, file.DefaultFileOsMode, strings.NewReader(sourceURL)); err != nil {
+			return 0, err
+		}
+		//window could have been sealed by other process before the entry was written, the file goes to the next window then
+		if isSealed, err := s.fs.Exists(ctx, s.sealURL(seq), option.NewObjectKind(true)); err != nil || isSealed {
+			if err == nil {
+				err = s.fs.Delete(ctx, entryURL)
+			}
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if files, bytes, err = s.totals(ctx, seq, ""); err != nil {
+			return 0, err
+		}
+		if (maxFiles > 0 && files >= maxFiles) || (maxBytes > 0 && bytes >= maxBytes) {
+			err = s.seal(ctx, seq, sealed)
+		}
+		return seq, err
+	}
+}
+
+//seal creates window seal marker, only the process that created the marker reports the window as sealed
+func (s *State) seal(ctx context.Context, seq int, sealed *[]int) error {
+	err := s.fs.Upload(ctx, s.sealURL(seq), file.DefaultFileOsMode, strings.NewReader(""), option.NewGeneration(true, 0))
+	if isPreConditionError(err) {
+		return nil
+	}
+	if err == nil {
+		*sealed = append(*sealed, seq)
+	}
+	return err
+}
+
+func (s *State) sealURL(seq int) string {
+	return url.Join(s.URL, strconv.Itoa(seq)+shared.BatchSealExt)
+}
+
+//totals returns files count and size of the window sequence entries, skipping supplied entry
+func (s *State) totals(ctx context.Context, seq int, skipEntry string) (files int, bytes int64, err error) {
+	objects, err := s.list(ctx, url.Join(s.URL, strconv.Itoa(seq)))
+	for _, object := range objects {
+		name := object.Name()
+		if object.IsDir() || path.Ext(name) != shared.BatchStateExt || name == skipEntry {
+			continue
+		}
+		name = strings.TrimSuffix(name, shared.BatchStateExt)
+		size, err := strconv.ParseInt(name[strings.LastIndex(name, "_")+1:], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid batch state entry: %v", object.URL())
+		}
+		files++
+		bytes += size
+	}
+	return files, bytes, err
+}
+
+func (s *State) list(ctx context.Context, URL string) ([]storage.Object, error) {
+	exists, err := s.fs.Exists(ctx, URL)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return s.fs.List(ctx, URL)
+}
+
+//Sequence returns current window sequence, the one following the last sealed window
+func (s *State) Sequence(ctx context.Context) (int, error) {
+	objects, err := s.list(ctx, s.URL)
+	seq := 0
+	for _, object := range objects {
+		if object.IsDir() || path.Ext(object.Name()) != shared.BatchSealExt {
+			continue
+		}
+		sealedSeq, err := strconv.Atoi(strings.TrimSuffix(object.Name(), shared.BatchSealExt))
+		if err != nil {
+			continue
+		}
+		if sealedSeq >= seq {
+			seq = sealedSeq + 1
+		}
+	}
+	return seq, err
+}
+
+//NewState creates a batch window state
+func NewState(URL string, fs afs.Service) *State {
+	return &State{URL: URL, fs: fs}
+}
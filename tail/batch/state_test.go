@@ -0,0 +1,105 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"sync"
+	"testing"
+)
+
+func TestState_Add(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+
+	var useCases = []struct {
+		description  string
+		maxFiles     int
+		maxBytes     int64
+		sizes        []int64
+		expectSeqs   []int
+		expectSealed [][]int
+	}{
+		{
+			description:  "max files",
+			maxFiles:     2,
+			sizes:        []int64{10, 10, 10, 10, 10},
+			expectSeqs:   []int{0, 0, 1, 1, 2},
+			expectSealed: [][]int{nil, {0}, nil, {1}, nil},
+		},
+		{
+			description:  "max bytes - window sealed before overflow",
+			maxBytes:     100,
+			sizes:        []int64{60, 30, 20, 80},
+			expectSeqs:   []int{0, 0, 1, 1},
+			expectSealed: [][]int{nil, nil, {0}, {1}},
+		},
+		{
+			description:  "max bytes - oversized file",
+			maxBytes:     100,
+			sizes:        []int64{200, 10},
+			expectSeqs:   []int{0, 1},
+			expectSealed: [][]int{{0}, nil},
+		},
+		{
+			description:  "max bytes - oversized file seals both overflowed and its own window",
+			maxBytes:     100,
+			sizes:        []int64{60, 150, 10},
+			expectSeqs:   []int{0, 1, 2},
+			expectSealed: [][]int{nil, {0, 1}, nil},
+		},
+	}
+
+	for i, useCase := range useCases {
+		state := NewState("mem://localhost/batch/state/"+string(rune('a'+i)), fs)
+		for j, size := range useCase.sizes {
+			seq, sealed, err := state.Add(ctx, fmt.Sprintf("gs://bucket/data/%v.json", j), size, useCase.maxFiles, useCase.maxBytes)
+			if !assert.Nil(t, err, useCase.description) {
+				break
+			}
+			assert.Equal(t, useCase.expectSeqs[j], seq, useCase.description)
+			assert.Equal(t, useCase.expectSealed[j], sealed, useCase.description)
+		}
+	}
+}
+
+func TestState_Add_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	state := NewState("mem://localhost/batch/state/concurrent", fs)
+	const files, maxFiles = 200, 20
+
+	var mux sync.Mutex
+	var seqs = make(map[int]int)
+	var sealed = make(map[int]int)
+	var waitGroup sync.WaitGroup
+	for i := 0; i < files; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			seq, sealedSeqs, err := state.Add(ctx, fmt.Sprintf("gs://bucket/data/%v.json", i), 10, maxFiles, 0)
+			assert.Nil(t, err)
+			mux.Lock()
+			defer mux.Unlock()
+			seqs[seq]++
+			for _, sealedSeq := range sealedSeqs {
+				sealed[sealedSeq]++
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+
+	total := 0
+	for seq, count := range seqs {
+		total += count
+		assert.True(t, count >= maxFiles || sealed[seq] == 0, fmt.Sprintf("window %v sealed with %v files", seq, count))
+	}
+	assert.Equal(t, files, total)
+	for seq, count := range sealed {
+		assert.Equal(t, 1, count, fmt.Sprintf("window %v sealed once", seq))
+	}
+	current, err := state.Sequence(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, len(sealed), current)
+}
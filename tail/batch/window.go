@@ -3,6 +3,7 @@ package batch
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/viant/afs"
 	"github.com/viant/bqtail/stage"
@@ -14,11 +15,21 @@ type Info struct {
 	*Window
 	WindowURL    string
 	OwnerEventID string
+	//SealedWindowURLs window URLs sealed by batch limits with the process
+	SealedWindowURLs []string
 }
 
 type Resource struct {
-	URL     string
-	ModTime time.Time
+	URL       string
+	ModTime   time.Time
+	Size      int64  `json:",omitempty"`
+	MemberURL string `json:",omitempty"`
+}
+
+//Part represents a window part loaded with a separate load job
+type Part struct {
+	URIs      []string
+	Resources []*Resource
 }
 
 //Window represent batching window
@@ -30,6 +41,56 @@ type Window struct {
 	URIs      []string    `json:",omitempty"`
 	Resources []*Resource `json:",omitempty"`
 	Locations []string    `json:",omitempty"`
+	//LeaseExpiry window ownership lease expiry, used by lease window locker
+	LeaseExpiry *time.Time `json:",omitempty"`
+	//Seq window sequence within window time span, incremented every time a window is sealed by batch limits
+	Seq      int      `json:",omitempty"`
+	StateURL string   `json:",omitempty"`
+	Members  []string `json:",omitempty"`
+	//Parts window parts, set when window exceeds load job limits
+	Parts []*Part `json:",omitempty"`
+}
+
+//LoadWindows returns windows to load, oversized window is split into part windows, each part gets its own event ID
+func (w *Window) LoadWindows() []*Window {
+	if len(w.Parts) <= 1 {
+		return []*Window{w}
+	}
+	var result = make([]*Window, 0)
+	for i, part := range w.Parts {
+		window := *w
+		process := *w.Process
+		if i > 0 {
+			process.EventID = fmt.Sprintf("%vp%v", w.EventID, i)
+		}
+		process.Params = make(map[string]interface{})
+		for k, v := range w.Params {
+			process.Params[k] = v
+		}
+		window.Process = &process
+		window.URIs = part.URIs
+		window.Resources = part.Resources
+		window.Parts = nil
+		window.Members = make([]string, 0)
+		for _, resource := range part.Resources {
+			if resource.MemberURL != "" {
+				window.Members = append(window.Members, resource.MemberURL)
+			}
+		}
+		if i > 0 { //only the first part cleans up window location files
+			window.Locations = nil
+		}
+		result = append(result, &window)
+	}
+	return result
+}
+
+//IsLeaseActive returns true if window ownership has not expired, window without lease never expires
+func (w *Window) IsLeaseActive(now time.Time) bool {
+	if w.LeaseExpiry == nil {
+		return true
+	}
+	return now.Before(*w.LeaseExpiry)
 }
 
 //NewWindow create a stage batch window
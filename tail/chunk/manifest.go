@@ -0,0 +1,19 @@
+package chunk
+
+import "time"
+
+//Manifest represents data file split summary
+type Manifest struct {
+	SourceURL     string
+	SourceSize    int64
+	SourceModTime time.Time
+	Chunks        []string
+	//Rows data rows, excluding repeated CSV leading rows
+	Rows    int
+	Created time.Time
+}
+
+//Matches returns true if manifest was created for the same source object version
+func (m *Manifest) Matches(size int64, modTime time.Time) bool {
+	return m.SourceSize == size && m.SourceModTime.Equal(modTime) && len(m.Chunks) > 0
+}
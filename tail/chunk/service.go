@@ -0,0 +1,225 @@
+package chunk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	//MaxCompressedSize BigQuery gzip compressed CSV or JSON data file size limit
+	MaxCompressedSize = int64(4) * 1024 * 1024 * 1024
+	gzipExt           = ".gz"
+	manifestFile      = "manifest.json"
+)
+
+//Service represents oversized compressed data file chunking service
+type Service interface {
+	//Split splits gzip data file into line aligned uncompressed chunks, chunks of the same source version are reused
+	Split(ctx context.Context, request *Request) (*Manifest, error)
+}
+
+//Request represents split request
+type Request struct {
+	Rule      *config.Rule
+	DestTable string
+	Source    storage.Object
+	//BaseURL chunks base location, data file is split into BaseURL/$bucket/$path/
+	BaseURL string
+	//ChunkSize max uncompressed chunk size in bytes
+	ChunkSize int64
+}
+
+type service struct {
+	fs afs.Service
+}
+
+//Split splits gzip data file into line aligned uncompressed chunks, chunks of the same source version are reused
+func (s *service) Split(ctx context.Context, request *Request) (*Manifest, error) {
+	source := request.Source
+	location := Location(request.BaseURL, source.URL())
+	manifestURL := url.Join(location, manifestFile)
+	if manifest, _ := s.loadManifest(ctx, manifestURL); manifest != nil && manifest.Matches(source.Size(), source.ModTime()) {
+		return manifest, nil
+	}
+	if ok, _ := s.fs.Exists(ctx, location); ok {
+		_ = s.fs.Delete(ctx, location)
+	}
+	manifest := &Manifest{
+		SourceURL:     source.URL(),
+		SourceSize:    source.Size(),
+		SourceModTime: source.ModTime(),
+	}
+	if err := s.split(ctx, request, location, manifest); err != nil {
+		_ = s.fs.Delete(ctx, location)
+		return nil, errors.Wrapf(err, "failed to split %v", source.URL())
+	}
+	manifest.Created = time.Now().UTC()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.fs.Upload(ctx, manifestURL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return nil, errors.Wrapf(err, "failed to upload chunk manifest: %v", manifestURL)
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] split %v rows into %v chunks: %v\n", request.DestTable, manifest.Rows, len(manifest.Chunks), source.URL())
+	}
+	return manifest, nil
+}
+
+func (s *service) loadManifest(ctx context.Context, URL string) (*Manifest, error) {
+	if ok, err := s.fs.Exists(ctx, URL); err != nil || !ok {
+		return nil, err
+	}
+	data, err := s.fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	return manifest, json.Unmarshal(data, manifest)
+}
+
+//split streams decompressed data file into chunks, CSV leading rows are repeated in every chunk
+func (s *service) split(ctx context.Context, request *Request, location string, manifest *Manifest) error {
+	reader, err := s.fs.OpenURL(ctx, manifest.SourceURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %v", manifest.SourceURL)
+	}
+	defer reader.Close()
+	gzReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer gzReader.Close()
+	lineReader := bufio.NewReaderSize(gzReader, 1024*1024)
+	header := new(bytes.Buffer)
+	for i := 0; i < int(request.Rule.Dest.SkipLeadingRows); i++ {
+		line, err := lineReader.ReadBytes('\n')
+		header.Write(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	ext := path.Ext(strings.TrimSuffix(manifest.SourceURL, gzipExt))
+	var current *writer
+	for {
+		line, err := lineReader.ReadBytes('\n')
+		if len(line) > 0 {
+			if current == nil {
+				chunkURL := url.Join(location, fmt.Sprintf("%05d%v", len(manifest.Chunks), ext))
+				var writerErr error
+				if current, writerErr = s.newWriter(ctx, chunkURL, header.Bytes()); writerErr != nil {
+					return writerErr
+				}
+				manifest.Chunks = append(manifest.Chunks, chunkURL)
+			}
+			if writeErr := current.write(line); writeErr != nil {
+				return current.close(writeErr)
+			}
+			manifest.Rows++
+			if current.size >= request.ChunkSize {
+				if closeErr := current.close(nil); closeErr != nil {
+					return closeErr
+				}
+				current = nil
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if current != nil {
+				_ = current.close(err)
+			}
+			return err
+		}
+	}
+	if current != nil {
+		return current.close(nil)
+	}
+	return nil
+}
+
+//writer represents chunk streamed to storage
+type writer struct {
+	URL  string
+	pipe *io.PipeWriter
+	done chan error
+	size int64
+}
+
+func (w *writer) write(data []byte) error {
+	n, err := w.pipe.Write(data)
+	w.size += int64(n)
+	return err
+}
+
+//close completes upload, it returns err or upload error
+func (w *writer) close(err error) error {
+	_ = w.pipe.CloseWithError(err)
+	if uploadErr := <-w.done; err == nil && uploadErr != nil {
+		err = errors.Wrapf(uploadErr, "failed to upload chunk: %v", w.URL)
+	}
+	return err
+}
+
+func (s *service) newWriter(ctx context.Context, URL string, header []byte) (*writer, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	result := &writer{URL: URL, pipe: pipeWriter, done: make(chan error, 1)}
+	go func() {
+		err := s.fs.Upload(ctx, URL, file.DefaultFileOsMode, pipeReader, option.NewSkipChecksum(true))
+		_ = pipeReader.CloseWithError(err)
+		result.done <- err
+	}()
+	if len(header) > 0 {
+		if err := result.write(header); err != nil {
+			return nil, result.close(err)
+		}
+	}
+	return result, nil
+}
+
+//IsOversized returns true if gzip data file exceeds BigQuery compressed file limit
+func IsOversized(URL string, size int64) bool {
+	return strings.HasSuffix(URL, gzipExt) && size > MaxCompressedSize
+}
+
+//IsSupported returns true if data file can be split line by line
+func IsSupported(dest *config.Destination) bool {
+	switch strings.ToUpper(dest.SourceFormat) {
+	case "NEWLINE_DELIMITED_JSON":
+		return true
+	case "", "CSV":
+		return !dest.AllowQuotedNewlines
+	}
+	return false
+}
+
+//Location returns data file chunks location
+func Location(baseURL, sourceURL string) string {
+	return url.Join(baseURL, url.Host(sourceURL), url.Path(sourceURL))
+}
+
+//New creates chunk service
+func New(fs afs.Service) Service {
+	return &service{fs: fs}
+}
@@ -0,0 +1,138 @@
+package chunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestService_Split(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/chunk"
+
+	useCases := []struct {
+		description  string
+		dest         *config.Destination
+		URL          string
+		data         string
+		chunkSize    int64
+		expectChunks []string
+		expectRows   int
+	}{
+		{
+			description:  "JSON line aligned chunks",
+			dest:         &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			URL:          "mem://localhost/data/events.json.gz",
+			data:         "{\"id\":1}\n{\"id\":2}\n{\"id\":3}",
+			chunkSize:    12,
+			expectChunks: []string{"{\"id\":1}\n{\"id\":2}\n", "{\"id\":3}"},
+			expectRows:   3,
+		},
+		{
+			description:  "CSV header repeated in every chunk",
+			dest:         &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SkipLeadingRows: 1}},
+			URL:          "mem://localhost/data/events.csv.gz",
+			data:         "id,name\n1,a\n2,b\n3,c\n",
+			chunkSize:    12,
+			expectChunks: []string{"id,name\n1,a\n", "id,name\n2,b\n", "id,name\n3,c\n"},
+			expectRows:   3,
+		},
+	}
+
+	srv := New(fs)
+	for _, useCase := range useCases {
+		compressed := new(bytes.Buffer)
+		writer := gzip.NewWriter(compressed)
+		_, _ = writer.Write([]byte(useCase.data))
+		_ = writer.Close()
+		if !assert.Nil(t, fs.Upload(ctx, useCase.URL, 0644, compressed), useCase.description) {
+			continue
+		}
+		source, err := fs.Object(ctx, useCase.URL, option.NewObjectKind(true))
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		request := &Request{Rule: &config.Rule{Dest: useCase.dest}, Source: source, BaseURL: baseURL, ChunkSize: useCase.chunkSize}
+		manifest, err := srv.Split(ctx, request)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectRows, manifest.Rows, useCase.description)
+		if !assert.EqualValues(t, len(useCase.expectChunks), len(manifest.Chunks), useCase.description) {
+			continue
+		}
+		for i, chunkURL := range manifest.Chunks {
+			data, err := fs.DownloadWithURL(ctx, chunkURL)
+			assert.Nil(t, err, useCase.description)
+			assert.EqualValues(t, useCase.expectChunks[i], string(data), useCase.description)
+		}
+		reused, err := srv.Split(ctx, request)
+		assert.Nil(t, err, useCase.description)
+		assert.EqualValues(t, manifest.Created, reused.Created, useCase.description)
+	}
+	assert.True(t, IsOversized("gs://bucket/data.json.gz", MaxCompressedSize+1))
+	assert.False(t, IsOversized("gs://bucket/data.json", MaxCompressedSize+1))
+}
+
+func TestService_Split_Streaming(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	URL := "mem://localhost/data/large.json.gz"
+	data := new(bytes.Buffer)
+	for i := 0; i < 10000; i++ {
+		data.WriteString(fmt.Sprintf("{\"id\":%v}\n", i))
+	}
+	compressed := new(bytes.Buffer)
+	writer := gzip.NewWriter(compressed)
+	_, _ = writer.Write(data.Bytes())
+	_ = writer.Close()
+	if !assert.Nil(t, fs.Upload(ctx, URL, 0644, compressed)) {
+		return
+	}
+	source, err := fs.Object(ctx, URL, option.NewObjectKind(true))
+	if !assert.Nil(t, err) {
+		return
+	}
+	chunkSize := int64(data.Len() / 4)
+	srv := New(&boundedFs{Service: fs, maxBuffered: int(chunkSize / 10)})
+	dest := &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}}
+	manifest, err := srv.Split(ctx, &Request{Rule: &config.Rule{Dest: dest}, Source: source, BaseURL: "mem://localhost/chunk/streaming", ChunkSize: chunkSize})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.EqualValues(t, 10000, manifest.Rows)
+	assert.True(t, len(manifest.Chunks) >= 4)
+}
+
+//boundedFs emulates gs upload buffering the whole reader to compute checksum unless it is skipped
+type boundedFs struct {
+	afs.Service
+	maxBuffered int
+}
+
+func (f *boundedFs) Upload(ctx context.Context, URL string, mode os.FileMode, reader io.Reader, options ...storage.Option) error {
+	skipChecksum := &option.SkipChecksum{}
+	if _, ok := option.Assign(options, &skipChecksum); ok && skipChecksum.Skip {
+		return f.Service.Upload(ctx, URL, mode, reader, options...)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if len(data) > f.maxBuffered {
+		return fmt.Errorf("buffered %v bytes exceeded %v limit: %v", len(data), f.maxBuffered, URL)
+	}
+	return f.Service.Upload(ctx, URL, mode, bytes.NewReader(data), options...)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	_ "github.com/viant/afsc/s3"
+	"github.com/viant/bqtail/tail/cmd"
+	"os"
+)
+
+//Version app version
+var Version string
+
+func main() {
+	cmd.RunClient(Version, os.Args[1:])
+}
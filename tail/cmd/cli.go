@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
+	"github.com/viant/afsc/gs"
+	"github.com/viant/bqtail/auth"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail"
+	"github.com/viant/bqtail/tail/source"
+	"github.com/viant/toolbox"
+	goption "google.golang.org/api/option"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//RunClient runs tail worker until interrupted
+func RunClient(Version string, args []string) {
+	options := &Options{}
+	_, err := flags.ParseArgs(options, args)
+	if isHelOption(args) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if options.Version {
+		shared.LogF("BqTail: Version: %v\n", Version)
+		return
+	}
+	options.Init()
+	if err = options.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	client, err := auth.ClientFromURL(options.ClientURL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	useGsUtilAuth := toolbox.AsBoolean(os.Getenv("GCLOUD_AUTH"))
+	authService := auth.New(client, useGsUtilAuth, options.ProjectID, auth.Scopes...)
+	setDefaultAuth(authService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	config, err := tail.NewConfig(ctx, options.ConfigURL)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to load config: %v", options.ConfigURL))
+	}
+	service, err := tail.New(ctx, config)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to create tail service with: %v", options.ConfigURL))
+	}
+	sourceConfig := options.SourceConfig()
+	if sourceConfig.ProjectID == "" {
+		sourceConfig.ProjectID = config.ProjectID
+	}
+	var clientOptions []goption.ClientOption
+	if httpClient, _ := auth.DefaultHTTPClientProvider(ctx, auth.Scopes); httpClient != nil {
+		clientOptions = append(clientOptions, goption.WithHTTPClient(httpClient))
+	}
+	eventSource, err := source.New(ctx, sourceConfig, clientOptions...)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to create %v event source", options.Source))
+	}
+	shared.LogF("tailing %v events\n", options.Source)
+	if err = source.NewWorker(service, eventSource, options.Concurrency, options.MaxAttempts).Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func setDefaultAuth(authService auth.Service) {
+	auth.DefaultHTTPClientProvider = authService.AuthHTTPClient
+	auth.DefaultProjectProvider = authService.ProjectID
+	gs.DefaultHTTPClientProvider = authService.AuthHTTPClient
+	gs.DefaultProjectProvider = authService.ProjectID
+}
+
+func isHelOption(args []string) bool {
+	for _, arg := range args {
+		if arg == "-h" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/source"
+)
+
+//Options represents tail worker options
+type Options struct {
+	ConfigURL    string   `short:"c" long:"cfg" description:"Serverless BqTail config URL"`
+	Source       string   `short:"s" long:"source" description:"event source" choice:"pubsub" choice:"kafkarest" choice:"dir"`
+	Subscription string   `short:"S" long:"subscription" description:"Pub/Sub pull subscription"`
+	ProxyURL     string   `short:"x" long:"proxy" description:"Kafka REST proxy URL"`
+	Topics       []string `short:"t" long:"topic" description:"Kafka topic"`
+	Group        string   `short:"g" long:"group" description:"Kafka consumer group"`
+	Dir          string   `short:"d" long:"dir" description:"watched local directory"`
+	ScanExisting bool     `short:"e" long:"existing" description:"emit files already present in watched directory"`
+	Concurrency  int      `short:"n" long:"concurrency" description:"max concurrently processed events"`
+	MaxAttempts  int      `short:"m" long:"attempts" description:"max data file processing attempts, 0 - no limit"`
+	ProjectID    string   `short:"p" long:"project" description:"Google Cloud Project"`
+	Client       string   `short:"a" long:"aclient" description:"GCP OAuth client url"`
+	Version      bool     `short:"v" long:"version" description:"bqtail version"`
+}
+
+//Init initialises options
+func (o *Options) Init() {
+	if o.Concurrency == 0 {
+		o.Concurrency = 4
+	}
+}
+
+//Validate checks if options are valid
+func (o *Options) Validate() error {
+	if o.ConfigURL == "" {
+		return errors.Errorf("configURL was empty")
+	}
+	return o.SourceConfig().Validate()
+}
+
+//SourceConfig returns event source config
+func (o *Options) SourceConfig() *source.Config {
+	return &source.Config{
+		Kind:         o.Source,
+		ProjectID:    o.ProjectID,
+		Subscription: o.Subscription,
+		ProxyURL:     o.ProxyURL,
+		Topics:       o.Topics,
+		Group:        o.Group,
+		Dir:          o.Dir,
+		ScanExisting: o.ScanExisting,
+	}
+}
+
+//ClientURL returns clientURL
+func (o *Options) ClientURL() string {
+	if o.Client == "" {
+		o.Client = shared.ClientSecretURL
+	}
+	return o.Client
+}
@@ -7,8 +7,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/viant/afs"
 	"github.com/viant/afs/cache"
+	"github.com/viant/afs/url"
 	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
 	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/toolbox"
 	"os"
 	"strings"
 )
@@ -21,6 +24,32 @@ type Config struct {
 	Disabled *bool
 	//Async if set it globally changes status for all rule
 	Async *bool
+	//WindowLocker batch window lock backend: generation (default), file or lease
+	WindowLocker string `json:",omitempty"`
+	//WindowLeaseInSec lease duration after batch window end time, once expired an orphaned window can be reclaimed (lease window locker only)
+	WindowLeaseInSec int `json:",omitempty"`
+	//LedgerURL ingestion ledger URL, used by rules with Dedupe.Ledger setting (JournalURL/ledger by default)
+	LedgerURL string `json:",omitempty"`
+	//SchemaAuditURL schema evolution audit URL, used by rules with Dest.SchemaEvolution setting (JournalURL/schema by default)
+	SchemaAuditURL string `json:",omitempty"`
+	//QuarantineURL corrupted data dead letter URL, used by rules with Quarantine setting (JournalURL/quarantine by default)
+	QuarantineURL string `json:",omitempty"`
+	//StagingJournalURL external data file staging progress journal URL, used by rules with Staging setting (JournalURL/staging by default)
+	StagingJournalURL string `json:",omitempty"`
+	//ChunkURL location where gzip data file exceeding BigQuery compressed file limit is split into line aligned chunks (JournalURL/chunks by default)
+	ChunkURL string `json:",omitempty"`
+	//ChunkSizeMb max uncompressed chunk size in MB (1024 by default)
+	ChunkSizeMb int `json:",omitempty"`
+	//CostURL daily per rule BigQuery usage records URL, data files paused by exceeded rule budget are moved to CostURL/paused (JournalURL/cost by default)
+	CostURL string `json:",omitempty"`
+	//TableQuotaURL daily destination table load, copy and query jobs count URL (JournalURL/quota by default)
+	TableQuotaURL string `json:",omitempty"`
+	//BalancerURL round robin project balancer sequence URL (JournalURL/balancer by default)
+	BalancerURL string `json:",omitempty"`
+	//MaxTableJobsPerDay max jobs per destination table per day (1500 by default)
+	MaxTableJobsPerDay int `json:",omitempty"`
+	//TableQuotaThreshold fraction of MaxTableJobsPerDay after which batch window is widened for the table (0.8 by default)
+	TableQuotaThreshold float64 `json:",omitempty"`
 }
 
 //init initializes config
@@ -29,6 +58,43 @@ func (c *Config) Init(ctx context.Context, fs afs.Service) error {
 	if err != nil {
 		return err
 	}
+	if c.LedgerURL == "" && c.JournalURL != "" {
+		c.LedgerURL = url.Join(c.JournalURL, shared.LedgerFolder)
+	}
+	if c.SchemaAuditURL == "" && c.JournalURL != "" {
+		c.SchemaAuditURL = url.Join(c.JournalURL, shared.SchemaAuditFolder)
+	}
+	if c.QuarantineURL == "" && c.JournalURL != "" {
+		c.QuarantineURL = url.Join(c.JournalURL, shared.QuarantineFolder)
+	}
+	if c.StagingJournalURL == "" && c.JournalURL != "" {
+		c.StagingJournalURL = url.Join(c.JournalURL, shared.StagingFolder)
+	}
+	if c.ChunkURL == "" && c.JournalURL != "" {
+		c.ChunkURL = url.Join(c.JournalURL, shared.ChunkFolder)
+	}
+	if c.CostURL == "" && c.JournalURL != "" {
+		c.CostURL = url.Join(c.JournalURL, shared.CostFolder)
+	}
+	if c.TableQuotaURL == "" && c.JournalURL != "" {
+		c.TableQuotaURL = url.Join(c.JournalURL, shared.QuotaFolder)
+	}
+	if c.BalancerURL == "" && c.JournalURL != "" {
+		c.BalancerURL = url.Join(c.JournalURL, shared.BalancerFolder)
+	}
+	if c.MaxTableJobsPerDay == 0 {
+		c.MaxTableJobsPerDay = defaultMaxTableJobsPerDay
+	}
+	if c.TableQuotaThreshold == 0 {
+		c.TableQuotaThreshold = defaultTableQuotaThreshold
+	}
+	if c.ChunkSizeMb < 0 {
+		return fmt.Errorf("invalid ChunkSizeMb: %v", c.ChunkSizeMb)
+	}
+	if c.ChunkSizeMb == 0 {
+		c.ChunkSizeMb = defaultChunkSizeMb
+	}
+	c.ChunkSizeMb = capChunkSizeMb(c.ChunkSizeMb, toolbox.AsInt(os.Getenv(functionMemoryEnvKey)))
 	if err = c.Ruleset.Init(ctx, fs, c.ProjectID); err != nil {
 		return err
 	}
@@ -36,6 +102,14 @@ func (c *Config) Init(ctx context.Context, fs afs.Service) error {
 	return nil
 }
 
+//capChunkSizeMb caps chunk size at half of function memory, so that a chunk fits in memory even if storage connector buffers it
+func capChunkSizeMb(chunkSizeMb, memoryMb int) int {
+	if maxChunkSizeMb := memoryMb / 2; maxChunkSizeMb > 0 && chunkSizeMb > maxChunkSizeMb {
+		return maxChunkSizeMb
+	}
+	return chunkSizeMb
+}
+
 //Match matches rule
 func (c Config) Match(URL string) []*config.Rule {
 	matched := c.Ruleset.Match(URL)
@@ -97,9 +171,31 @@ func (c *Config) Validate() error {
 	if c.Ruleset.UsesBatchInSyncMode() && c.SyncTaskURL == "" {
 		return fmt.Errorf("syncTaskURL were empty")
 	}
+	switch c.WindowLocker {
+	case "", shared.WindowLockerGeneration, shared.WindowLockerFile, shared.WindowLockerLease:
+	default:
+		return fmt.Errorf("unsupported windowLocker: %v", c.WindowLocker)
+	}
+	if err = c.validateStaging(); err != nil {
+		return err
+	}
 	return c.Ruleset.Validate()
 }
 
+//validateStaging checks that staged copies do not land in the trigger bucket, otherwise each staged copy would trigger ingestion again
+func (c *Config) validateStaging() error {
+	triggerBucket := url.Host(c.TriggerBucketURL())
+	for _, rule := range c.Rules {
+		if rule.Staging == nil || rule.Staging.URL == "" {
+			continue
+		}
+		if url.Host(rule.Staging.URL) == triggerBucket {
+			return fmt.Errorf("invalid rule: %v, staging.URL %v can not use trigger bucket: %v", rule.Info.URL, rule.Staging.URL, triggerBucket)
+		}
+	}
+	return nil
+}
+
 //NewConfigFromEnv creates config from env
 func NewConfigFromEnv(ctx context.Context, key string) (*Config, error) {
 	if key == "" {
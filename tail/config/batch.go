@@ -30,6 +30,12 @@ type (
 
 		//Group batch grouping setting
 		Group *Group
+
+		//MaxFiles max number of files in a batch window, once reached the window is sealed early and a new window is started
+		MaxFiles int `json:",omitempty"`
+
+		//MaxBytes max total size of files in a batch window, once reached the window is sealed early and a new window is started
+		MaxBytes int64 `json:",omitempty"`
 	}
 
 	//Group represent batch group
@@ -59,9 +65,29 @@ func (b *Batch) MaxDelayMs(minInMs int) int {
 
 // Validate checks if batch configuration is valid
 func (b *Batch) Validate() error {
+	if b.MaxFiles < 0 || b.MaxBytes < 0 {
+		return fmt.Errorf("invalid batch limits, maxFiles: %v, maxBytes: %v", b.MaxFiles, b.MaxBytes)
+	}
 	return b.Window.Validate()
 }
 
+// HasLimits returns true if batch window can be sealed by files count or size
+func (b *Batch) HasLimits() bool {
+	return b.MaxFiles > 0 || b.MaxBytes > 0
+}
+
+// LoadLimits returns max files and bytes per load job, capped by BigQuery load job limits
+func (b *Batch) LoadLimits() (int, int64) {
+	maxFiles, maxBytes := shared.MaxLoadURIs, int64(shared.MaxLoadBytes)
+	if b.MaxFiles > 0 && b.MaxFiles < maxFiles {
+		maxFiles = b.MaxFiles
+	}
+	if b.MaxBytes > 0 && b.MaxBytes < maxBytes {
+		maxBytes = b.MaxBytes
+	}
+	return maxFiles, maxBytes
+}
+
 // IsWithinFirstHalf returns true if source time is within the first half window
 func (b *Batch) IsWithinFirstHalf(sourceTime time.Time) bool {
 	halfDuration := b.Window.DurationInSec / 2
@@ -69,13 +95,59 @@ func (b *Batch) IsWithinFirstHalf(sourceTime time.Time) bool {
 	return remainder < halfDuration
 }
 
-// WindowEndTime returns window end time
+// WindowEndTime returns window end time, widened window ends no later than the configured window holding the end of the day (UTC)
 func (b *Batch) WindowEndTime(sourceTime time.Time) time.Time {
 	windowDuration := b.Window.DurationInSec
 	sourceUnixTimestamp := sourceTime.Unix()
 	remainder := int(sourceUnixTimestamp) % windowDuration
 	endTimeWindowDelta := windowDuration - remainder
-	return time.Unix(sourceUnixTimestamp+int64(endTimeWindowDelta), 0).UTC()
+	endTime := time.Unix(sourceUnixTimestamp+int64(endTimeWindowDelta), 0).UTC()
+	if !b.IsWidened() {
+		return endTime
+	}
+	baseBatch := NewBatch(b.Window.BaseDurationInSec)
+	midnight := sourceTime.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if limit := baseBatch.WindowStartTime(midnight); endTime.After(limit) {
+		if limit.After(sourceTime) {
+			return limit
+		}
+		return baseBatch.WindowEndTime(sourceTime)
+	}
+	return endTime
+}
+
+// WindowStartTime returns window start time, widened window starts with configured window holding source time,
+// so that it does not overlap with windows acquired before widening
+func (b *Batch) WindowStartTime(sourceTime time.Time) time.Time {
+	if !b.IsWidened() {
+		return b.WindowEndTime(sourceTime).Add(-b.Window.Duration)
+	}
+	baseBatch := NewBatch(b.Window.BaseDurationInSec)
+	return baseBatch.WindowEndTime(sourceTime).Add(-baseBatch.Window.Duration)
+}
+
+// Widen returns batch with window duration multiplied by 2^level, capped at one day
+func (b *Batch) Widen(level int) *Batch {
+	baseDuration := b.Window.DurationInSec
+	if b.IsWidened() {
+		baseDuration = b.Window.BaseDurationInSec
+	}
+	duration := MaxWindowDurationSec
+	if level < 0 {
+		level = 0
+	}
+	if level < 32 && baseDuration<<level < MaxWindowDurationSec {
+		duration = baseDuration << level
+	}
+	result := *b
+	result.Window = &Window{DurationInSec: duration, BaseDurationInSec: baseDuration}
+	result.Window.Init()
+	return &result
+}
+
+// IsWidened returns true if window was widened to stay within table jobs quota
+func (b *Batch) IsWidened() bool {
+	return b.Window.BaseDurationInSec > 0
 }
 
 // WindowEndTime returns window end time
@@ -31,6 +31,24 @@ func TestBatch_WindowEndTime(t *testing.T) {
 			modTime:     time.Unix(21, 0),
 			expect:      30,
 		},
+		{
+			description: "widened window",
+			batch:       NewBatch(10).Widen(2),
+			modTime:     time.Unix(24, 0),
+			expect:      40,
+		},
+		{
+			description: "widened window ends with configured window holding midnight",
+			batch:       NewBatch(7).Widen(3),
+			modTime:     time.Unix(86380, 0),
+			expect:      86394,
+		},
+		{
+			description: "widened window within configured window holding midnight",
+			batch:       NewBatch(7).Widen(3),
+			modTime:     time.Unix(86396, 0),
+			expect:      86401,
+		},
 	}
 
 	for _, useCase := range useCases {
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"github.com/viant/bqtail/task"
+)
+
+//Budget modes
+const (
+	//BudgetModePause data files are moved to paused location until budget resets
+	BudgetModePause = "pause"
+	//BudgetModeCopy transient transform/query steps are downgraded to plain copy
+	BudgetModeCopy = "copy"
+)
+
+//Budget represents daily rule BigQuery usage limits
+type Budget struct {
+	//MaxBytesPerDay max billed bytes per day (processed bytes if billed bytes are not reported)
+	MaxBytesPerDay int64 `json:",omitempty"`
+	//MaxSlotMsPerDay max slot milliseconds per day
+	MaxSlotMsPerDay int64 `json:",omitempty"`
+	//Mode once exceeded: pause (default) or copy
+	Mode string `json:",omitempty"`
+	//OnExceeded actions run once a day when budget gets exceeded, i.e. notify rule owner
+	OnExceeded []*task.Action `json:",omitempty"`
+}
+
+//Init initialises budget defaults
+func (b *Budget) Init() {
+	if b.Mode == "" {
+		b.Mode = BudgetModePause
+	}
+}
+
+//IsExceeded returns true if bytes or slot milliseconds exceed budget
+func (b *Budget) IsExceeded(bytes, slotMs int64) bool {
+	if b.MaxBytesPerDay > 0 && bytes > b.MaxBytesPerDay {
+		return true
+	}
+	return b.MaxSlotMsPerDay > 0 && slotMs > b.MaxSlotMsPerDay
+}
+
+//Validate checks if budget is valid
+func (b *Budget) Validate() error {
+	if b.MaxBytesPerDay <= 0 && b.MaxSlotMsPerDay <= 0 {
+		return fmt.Errorf("budget.MaxBytesPerDay or budget.MaxSlotMsPerDay was empty")
+	}
+	switch b.Mode {
+	case "", BudgetModePause, BudgetModeCopy:
+	default:
+		return fmt.Errorf("unsupported budget.Mode: %v, expected %v or %v", b.Mode, BudgetModePause, BudgetModeCopy)
+	}
+	return nil
+}
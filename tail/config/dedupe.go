@@ -0,0 +1,9 @@
+package config
+
+//Dedupe represents source data deduplication settings
+type Dedupe struct {
+	//Ledger if set, ingestion ledger is consulted to prevent the same source object generation from being ingested more than once
+	Ledger bool `json:",omitempty"`
+	//FlagOnly if set, duplicated source object is still ingested, but the response is flagged with duplicate status
+	FlagOnly bool `json:",omitempty"`
+}
@@ -41,11 +41,15 @@ type Destination struct {
 	TransientDataset   string            `json:",omitempty"`
 	Transient          *Transient        `json:",omitempty"`
 	UniqueColumns      []string          `json:",omitempty"`
+	DedupeOrderBy      *OrderBy          `json:",omitempty" description:"optional UniqueColumns deduplication ordering, the first whole row per key wins"`
+	Dedupe             *LookbackDedupe   `json:",omitempty" description:"optional deduplication against rows already loaded to destination within lookback"`
 	Transform          map[string]string `json:",omitempty" description:"optional map of the source column to dest expression"`
 	SideInputs         []*SideInput      `json:",omitempty"`
 	Override           *bool
 	AllowFieldAddition bool   `json:",omitempty"`
 	Expiry             string `json:",omitempty"`
+	//SchemaEvolution optional destination schema evolution policy
+	SchemaEvolution *SchemaEvolution `json:",omitempty"`
 }
 
 // HasTemplate
@@ -94,10 +98,13 @@ func (d Destination) Clone() *Destination {
 		TransientDataset:     d.TransientDataset,
 		Transient:            d.Transient,
 		UniqueColumns:        d.UniqueColumns,
+		DedupeOrderBy:        d.DedupeOrderBy,
+		Dedupe:               d.Dedupe,
 		SideInputs:           d.SideInputs,
 		Override:             d.Override,
 		AllowFieldAddition:   d.AllowFieldAddition,
 		Expiry:               d.Expiry,
+		SchemaEvolution:      d.SchemaEvolution,
 	}
 
 	if len(d.Transform) > 0 {
@@ -144,12 +151,15 @@ func (d Destination) Validate() error {
 
 		if d.Transient.CopyMethod != nil {
 			switch *d.Transient.CopyMethod {
-			case shared.CopyMethodCopy, shared.CopyMethodDML, shared.CopyMethodQuery:
+			case shared.CopyMethodCopy, shared.CopyMethodDML, shared.CopyMethodQuery, shared.CopyMethodMerge:
 			default:
 				return errors.Errorf("invalid Transient.CopyMethod: %v, valid:[%v]", *d.Transient.CopyMethod, []string{
-					shared.CopyMethodCopy, shared.CopyMethodDML, shared.CopyMethodQuery,
+					shared.CopyMethodCopy, shared.CopyMethodDML, shared.CopyMethodQuery, shared.CopyMethodMerge,
 				})
 			}
+			if *d.Transient.CopyMethod == shared.CopyMethodMerge && len(d.UniqueColumns) == 0 {
+				return errors.Errorf("Transient.CopyMethod %v requires UniqueColumns", shared.CopyMethodMerge)
+			}
 		}
 	}
 
@@ -169,6 +179,27 @@ func (d Destination) Validate() error {
 			return errors.Wrapf(err, "invalid schema.template: %v", d.Schema.Template)
 		}
 	}
+	if d.DedupeOrderBy != nil {
+		if len(d.UniqueColumns) == 0 {
+			return errors.Errorf("dest.DedupeOrderBy requires dest.UniqueColumns")
+		}
+		if err := d.DedupeOrderBy.Validate(); err != nil {
+			return err
+		}
+	}
+	if d.Dedupe != nil {
+		if d.Transient == nil || d.Transient.Dataset == "" {
+			return errors.Errorf("dest.Dedupe requires dest.Transient.Dataset")
+		}
+		if err := d.Dedupe.Validate(); err != nil {
+			return err
+		}
+	}
+	if d.SchemaEvolution != nil {
+		if err := d.SchemaEvolution.Validate(&d); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -191,6 +222,18 @@ func (d *Destination) Init() error {
 	if len(d.Transform) == 0 {
 		d.Transform = make(map[string]string)
 	}
+	if d.SchemaEvolution != nil {
+		d.SchemaEvolution.Init(d)
+	}
+	if d.Dedupe != nil {
+		d.Dedupe.Init(d.UniqueColumns)
+	}
+	if d.DedupeOrderBy != nil {
+		d.DedupeOrderBy.Init()
+	}
+	if d.Schema.Split != nil && d.Schema.Split.Dynamic != nil {
+		d.Schema.Split.Dynamic.Init()
+	}
 	if d.AllowFieldAddition && (d.SourceFormat == "AVRO" || d.SourceFormat == "PARQUET" || d.SourceFormat == "NEWLINE_DELIMITED_JSON") {
 		if len(d.SchemaUpdateOptions) == 0 {
 			d.SchemaUpdateOptions = []string{"ALLOW_FIELD_ADDITION", "ALLOW_FIELD_RELAXATION"}
@@ -230,7 +273,7 @@ func (d *Destination) IsCopyMethodQuery() bool {
 
 // HasTransformation returns true if dest requires transformation
 func (d *Destination) HasTransformation() bool {
-	return len(d.SideInputs) > 0 || len(d.Transform) > 0 || d.Schema.Split != nil || len(d.UniqueColumns) > 0
+	return len(d.SideInputs) > 0 || len(d.Transform) > 0 || d.Schema.Split != nil || len(d.UniqueColumns) > 0 || d.Dedupe != nil
 }
 
 // ExpandTable returns expanded table
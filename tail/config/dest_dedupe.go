@@ -0,0 +1,72 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	//LookbackPartition lookback scoped to destination table partition
+	LookbackPartition = "partition"
+	//PartitionTimeColumn ingestion time partition pseudo column
+	PartitionTimeColumn = "_PARTITIONTIME"
+)
+
+var lookbackDuration = regexp.MustCompile(`^(\d+)\s*(m|min|mins|minute|minutes|h|hour|hours|d|day|days)$`)
+
+//LookbackDedupe represents deduplication of transient data against rows already loaded to destination table
+type LookbackDedupe struct {
+	//Lookback destination lookback: 'partition' (dest table partition), duration (i.e. 6h, 7d) or SQL criteria using 'd.' dest alias
+	Lookback string
+	//Column destination time column scoped by lookback (_PARTITIONTIME by default)
+	Column string `json:",omitempty"`
+	//Columns deduplication key columns, Dest.UniqueColumns by default
+	Columns []string `json:",omitempty"`
+}
+
+//Init initialises dedupe
+func (d *LookbackDedupe) Init(uniqueColumns []string) {
+	if d.Column == "" {
+		d.Column = PartitionTimeColumn
+	}
+	if len(d.Columns) == 0 {
+		d.Columns = uniqueColumns
+	}
+}
+
+//Validate checks if dedupe is valid
+func (d *LookbackDedupe) Validate() error {
+	if d.Lookback == "" {
+		return errors.New("dest.Dedupe.Lookback was empty")
+	}
+	if len(d.Columns) == 0 {
+		return errors.New("dest.Dedupe requires Columns or dest.UniqueColumns")
+	}
+	return nil
+}
+
+//IsPartition returns true if lookback is scoped to destination partition
+func (d *LookbackDedupe) IsPartition() bool {
+	return strings.EqualFold(strings.TrimSpace(d.Lookback), LookbackPartition)
+}
+
+//Duration returns lookback duration with BigQuery interval unit (MINUTE, HOUR or DAY), ok is false for SQL criteria lookback
+func (d *LookbackDedupe) Duration() (int, string, bool) {
+	matched := lookbackDuration.FindStringSubmatch(strings.ToLower(strings.TrimSpace(d.Lookback)))
+	if len(matched) == 0 {
+		return 0, "", false
+	}
+	value, err := strconv.Atoi(matched[1])
+	if err != nil {
+		return 0, "", false
+	}
+	switch matched[2][0] {
+	case 'm':
+		return value, "MINUTE", true
+	case 'h':
+		return value, "HOUR", true
+	}
+	return value, "DAY", true
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	//DefaultDynamicSplitMaxTables default max number of dynamic split tables
+	DefaultDynamicSplitMaxTables = 20
+	//MaxDynamicSplitTables max number of dynamic split tables
+	MaxDynamicSplitTables = 99
+)
+
+//DynamicSplit represents split to one destination table per distinct column value discovered in transient table
+type DynamicSplit struct {
+	//Column split column
+	Column string
+	//Then destination table with ${Column} placeholder replaced with column value, i.e. mydataset.events_${event_type}
+	Then string
+	//Values optional column values allow-list
+	Values []string `json:",omitempty"`
+	//Else optional destination table for values not in the allow-list and NULL values, otherwise these rows are skipped
+	Else string `json:",omitempty"`
+	//MaxTables max number of distinct values (destination tables), split fails if exceeded
+	MaxTables int `json:",omitempty"`
+}
+
+//Init initialises dynamic split
+func (s *DynamicSplit) Init() {
+	if s.MaxTables == 0 {
+		s.MaxTables = DefaultDynamicSplitMaxTables
+	}
+}
+
+//Placeholder returns destination table column value placeholder
+func (s *DynamicSplit) Placeholder() string {
+	return "${" + s.Column + "}"
+}
+
+//Validate checks if dynamic split is valid
+func (s *DynamicSplit) Validate() error {
+	if s.Column == "" {
+		return errors.New("dynamic split column was empty")
+	}
+	if !strings.Contains(s.Then, s.Placeholder()) {
+		return errors.Errorf("dynamic split then: %v, missing %v placeholder", s.Then, s.Placeholder())
+	}
+	if s.MaxTables < 0 || s.MaxTables > MaxDynamicSplitTables {
+		return errors.Errorf("invalid dynamic split maxTables: %v, valid range: 1-%v", s.MaxTables, MaxDynamicSplitTables)
+	}
+	return nil
+}
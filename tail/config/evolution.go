@@ -0,0 +1,105 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/schema"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+)
+
+//SchemaEvolution represents destination schema evolution policy, source schema is detected from data file first record
+type SchemaEvolution struct {
+	//AddColumns if set, columns present in the source but missing in the destination are added (NULLABLE)
+	AddColumns bool `json:",omitempty"`
+	//RelaxRequired if set, REQUIRED destination columns missing in the source are relaxed to NULLABLE
+	RelaxRequired bool `json:",omitempty"`
+	//WidenTypes if set, top level INT64 destination columns with FLOAT64 or NUMERIC source values are widen to source type
+	WidenTypes bool `json:",omitempty"`
+	//Aliases maps renamed source column to existing destination column, requires transient dataset
+	Aliases map[string]string `json:",omitempty"`
+	//Reject if set, any source schema change is rejected, data file is moved to invalid schema location
+	Reject bool `json:",omitempty"`
+	//ApprovalURL if set, destructive change (relax, widen) requires approval file under this URL
+	ApprovalURL string `json:",omitempty"`
+}
+
+//Allows returns true if policy allows schema change
+func (e *SchemaEvolution) Allows(change *schema.Change) bool {
+	if e.Reject {
+		return false
+	}
+	switch change.Kind {
+	case schema.ChangeAdd:
+		return e.AddColumns
+	case schema.ChangeRelax:
+		return e.RelaxRequired
+	case schema.ChangeWiden:
+		return e.WidenTypes && !strings.Contains(change.Field, ".")
+	}
+	return false
+}
+
+//RequiresApproval returns true if schema change requires approval
+func (e *SchemaEvolution) RequiresApproval(change *schema.Change) bool {
+	return e.ApprovalURL != "" && change.IsDestructive()
+}
+
+//Validate checks if policy is valid
+func (e *SchemaEvolution) Validate(dest *Destination) error {
+	if e.Reject && (e.AddColumns || e.RelaxRequired || e.WidenTypes) {
+		return errors.Errorf("dest.SchemaEvolution.Reject can not be used with other evolution options")
+	}
+	if len(e.Aliases) == 0 {
+		return nil
+	}
+	if dest.Transient == nil || dest.Transient.Dataset == "" {
+		return errors.Errorf("dest.SchemaEvolution.Aliases requires dest.Transient.Dataset")
+	}
+	for source, target := range e.Aliases {
+		if strings.Contains(source, ".") || strings.Contains(target, ".") {
+			return errors.Errorf("invalid dest.SchemaEvolution.Aliases: %v: %v, only top level columns can be renamed", source, target)
+		}
+	}
+	return nil
+}
+
+//AliasFields returns copy of the fields with destination columns renamed to aliased source columns
+func (e *SchemaEvolution) AliasFields(fields []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	var mapping = make(map[string]string)
+	for source, target := range e.Aliases {
+		mapping[strings.ToLower(target)] = source
+	}
+	return renameFields(fields, mapping)
+}
+
+//TargetFields returns copy of the fields with aliased source columns renamed to destination columns
+func (e *SchemaEvolution) TargetFields(fields []*bigquery.TableFieldSchema) []*bigquery.TableFieldSchema {
+	var mapping = make(map[string]string)
+	for source, target := range e.Aliases {
+		mapping[strings.ToLower(source)] = target
+	}
+	return renameFields(fields, mapping)
+}
+
+func renameFields(fields []*bigquery.TableFieldSchema, mapping map[string]string) []*bigquery.TableFieldSchema {
+	result := schema.CloneFields(fields)
+	for _, field := range result {
+		if name, ok := mapping[strings.ToLower(field.Name)]; ok {
+			field.Name = name
+		}
+	}
+	return result
+}
+
+//Init maps aliased source columns to destination columns with transform expressions
+func (e *SchemaEvolution) Init(dest *Destination) {
+	if len(e.Aliases) == 0 || dest.Transient == nil {
+		return
+	}
+	for source, target := range e.Aliases {
+		if _, ok := dest.Transform[target]; ok {
+			continue
+		}
+		dest.Transform[target] = dest.Transient.Alias + "." + source
+	}
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/toolbox"
+	"gopkg.in/yaml.v2"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	extendsKey = "Extends"
+	includeKey = "Include"
+	//fragmentPrefix rules folder file or folder name prefix marking reusable rule fragments, these are not loaded as rules
+	fragmentPrefix = "_"
+)
+
+//listMergeKeys keys which list values are appended rather than replaced when merging fragments
+var listMergeKeys = []string{"OnSuccess", "OnFailure"}
+
+//fragment represents tracked rule fragment
+type fragment struct {
+	modTime time.Time
+	rules   map[string]bool
+}
+
+//resolver resolves rule Extends and Include fragments
+type resolver struct {
+	fs           afs.Service
+	dependencies map[string]bool
+}
+
+//resolve deep merges Extends base, then Include fragments in order, then the document itself
+func (r *resolver) resolve(ctx context.Context, URL string, document map[string]interface{}, visited []string) (map[string]interface{}, error) {
+	references, err := fragmentReferences(document)
+	if err != nil || len(references) == 0 {
+		return document, err
+	}
+	visited = append(visited, URL)
+	result := map[string]interface{}{}
+	for _, reference := range references {
+		fragmentURL := reference
+		if !strings.Contains(reference, "://") {
+			parent, _ := url.Split(URL, file.Scheme)
+			baseURL, URLPath := url.Base(url.Join(parent, reference), file.Scheme)
+			fragmentURL = url.Join(baseURL, path.Clean(URLPath))
+		}
+		for _, candidate := range visited {
+			if candidate == fragmentURL {
+				return nil, errors.Errorf("cyclic rule fragment reference: %v -> %v", strings.Join(visited, " -> "), fragmentURL)
+			}
+		}
+		r.dependencies[fragmentURL] = true
+		data, err := r.fs.DownloadWithURL(ctx, fragmentURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load rule fragment: %v", fragmentURL)
+		}
+		documents, err := decodeDocuments(data, path.Ext(fragmentURL))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode rule fragment: %v", fragmentURL)
+		}
+		if len(documents) != 1 {
+			return nil, errors.Errorf("invalid rule fragment: %v, expected a single document, but had: %v", fragmentURL, len(documents))
+		}
+		resolved, err := r.resolve(ctx, fragmentURL, documents[0], visited)
+		if err != nil {
+			return nil, err
+		}
+		deleteKey(resolved, extendsKey)
+		deleteKey(resolved, includeKey)
+		mergeDocument(result, resolved)
+	}
+	mergeDocument(result, document)
+	return result, nil
+}
+
+func (r *resolver) dependencyURLs() []string {
+	var result = make([]string, 0, len(r.dependencies))
+	for URL := range r.dependencies {
+		result = append(result, URL)
+	}
+	return result
+}
+
+//fragmentReferences returns Extends base followed by Include fragments
+func fragmentReferences(document map[string]interface{}) ([]string, error) {
+	var result = make([]string, 0)
+	if extends, ok := getKey(document, extendsKey); ok && extends != nil {
+		text, ok := extends.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid %v: %v, expected URL", extendsKey, extends)
+		}
+		if text != "" {
+			result = append(result, text)
+		}
+	}
+	if include, ok := getKey(document, includeKey); ok && include != nil {
+		items, ok := include.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid %v: %v, expected URL list", includeKey, include)
+		}
+		for _, item := range items {
+			result = append(result, toolbox.AsString(item))
+		}
+	}
+	return result, nil
+}
+
+//mergeDocument deep merges source into dest, maps are merged recursively, OnSuccess/OnFailure lists are appended, other values are replaced
+func mergeDocument(dest, source map[string]interface{}) {
+	for key, value := range source {
+		destKey := key
+		for candidate := range dest {
+			if strings.EqualFold(candidate, key) {
+				destKey = candidate
+				break
+			}
+		}
+		existing, ok := dest[destKey]
+		if !ok {
+			dest[destKey] = value
+			continue
+		}
+		if existingMap, ok := existing.(map[string]interface{}); ok {
+			if valueMap, ok := value.(map[string]interface{}); ok {
+				merged := map[string]interface{}{}
+				mergeDocument(merged, existingMap)
+				mergeDocument(merged, valueMap)
+				dest[destKey] = merged
+				continue
+			}
+		}
+		if isListMergeKey(key) {
+			existingList, isExistingList := existing.([]interface{})
+			valueList, isValueList := value.([]interface{})
+			if isExistingList && isValueList {
+				dest[destKey] = append(append([]interface{}{}, existingList...), valueList...)
+				continue
+			}
+		}
+		dest[destKey] = value
+	}
+}
+
+func isListMergeKey(key string) bool {
+	for _, candidate := range listMergeKeys {
+		if strings.EqualFold(candidate, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func getKey(document map[string]interface{}, key string) (interface{}, bool) {
+	for k, v := range document {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func deleteKey(document map[string]interface{}, key string) {
+	for k := range document {
+		if strings.EqualFold(k, key) {
+			delete(document, k)
+		}
+	}
+}
+
+//hasFragments returns true if any document references fragments
+func hasFragments(documents []map[string]interface{}) bool {
+	for _, document := range documents {
+		if _, ok := getKey(document, extendsKey); ok {
+			return true
+		}
+		if _, ok := getKey(document, includeKey); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//decodeDocuments decodes JSON or YAML rule(s) as generic documents
+func decodeDocuments(data []byte, ext string) ([]map[string]interface{}, error) {
+	var decoded interface{}
+	var err error
+	if ext == shared.YAMLExt {
+		err = yaml.Unmarshal(data, &decoded)
+	} else {
+		err = json.Unmarshal(data, &decoded)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch actual := normalizeDocument(decoded).(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{actual}, nil
+	case []interface{}:
+		var result = make([]map[string]interface{}, 0, len(actual))
+		for _, item := range actual {
+			document, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unsupported rule: %v", item)
+			}
+			result = append(result, document)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported rule document: %T", decoded)
+}
+
+//normalizeDocument converts YAML maps into string keyed maps
+func normalizeDocument(value interface{}) interface{} {
+	switch actual := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(actual))
+		for k, v := range actual {
+			result[toolbox.AsString(k)] = normalizeDocument(v)
+		}
+		return result
+	case map[string]interface{}:
+		for k, v := range actual {
+			actual[k] = normalizeDocument(v)
+		}
+		return actual
+	case []interface{}:
+		for i, v := range actual {
+			actual[i] = normalizeDocument(v)
+		}
+		return actual
+	}
+	return value
+}
+
+//resolveRules resolves rule documents fragments, it returns rules and fragment URLs rules depend on
+func resolveRules(ctx context.Context, fs afs.Service, URL string, documents []map[string]interface{}) ([]*Rule, []string, error) {
+	resolver := &resolver{fs: fs, dependencies: map[string]bool{}}
+	var rules = make([]*Rule, 0, len(documents))
+	for _, document := range documents {
+		resolved, err := resolver.resolve(ctx, URL, document, nil)
+		if err != nil {
+			return nil, resolver.dependencyURLs(), err
+		}
+		rule := &Rule{}
+		if path.Ext(URL) == shared.YAMLExt {
+			err = toolbox.DefaultConverter.AssignConverted(&rule, resolved)
+		} else {
+			var data []byte
+			if data, err = json.Marshal(resolved); err == nil {
+				err = json.Unmarshal(data, rule)
+			}
+		}
+		if err != nil {
+			return nil, resolver.dependencyURLs(), err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, resolver.dependencyURLs(), nil
+}
+
+//Resolve returns rule data with Extends and Include fragments merged, so that the rule can be copied to another location
+func Resolve(ctx context.Context, fs afs.Service, URL string, data []byte) ([]byte, error) {
+	ext := path.Ext(URL)
+	documents, err := decodeDocuments(data, ext)
+	if err != nil || !hasFragments(documents) {
+		return data, nil
+	}
+	resolver := &resolver{fs: fs, dependencies: map[string]bool{}}
+	var resolved = make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		merged, err := resolver.resolve(ctx, URL, document, nil)
+		if err != nil {
+			return nil, err
+		}
+		deleteKey(merged, extendsKey)
+		deleteKey(merged, includeKey)
+		resolved = append(resolved, merged)
+	}
+	var result interface{} = resolved
+	if len(resolved) == 1 {
+		result = resolved[0]
+	}
+	if ext == shared.YAMLExt {
+		return yaml.Marshal(result)
+	}
+	buffer := new(bytes.Buffer)
+	err = json.NewEncoder(buffer).Encode(result)
+	return buffer.Bytes(), err
+}
+
+//IsFragment returns true if URL under rules base URL is a rule fragment (file or folder name starts with underscore)
+func IsFragment(baseURL, URL string) bool {
+	relative := strings.TrimPrefix(url.Path(URL), url.Path(baseURL))
+	for _, element := range strings.Split(relative, "/") {
+		if strings.HasPrefix(element, fragmentPrefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRuleset_Fragments(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/fragments/rules"
+
+	assets := map[string]string{
+		"_base/transient.yaml": `Async: true
+Dest:
+  Transient:
+    Dataset: temp
+  SourceFormat: NEWLINE_DELIMITED_JSON
+Batch:
+  Window:
+    DurationInSec: 60
+OnSuccess:
+  - Action: delete
+`,
+		"_base/notify.yaml": `OnFailure:
+  - Action: notify
+    Request:
+      Channels: ["#e2e"]
+`,
+		"_shared/partition.json": `{"Extends": "../_base/transient.yaml", "Dest": {"Partition": "$Date"}}`,
+		"events.yaml": `Extends: _shared/partition.json
+Include:
+  - _base/notify.yaml
+When:
+  Prefix: /data/events
+Dest:
+  Table: mydataset.events
+Batch:
+  Window:
+    DurationInSec: 120
+OnSuccess:
+  - Action: query
+    Request:
+      SQL: SELECT 1
+`,
+		"clicks.json": `{"Extends": "_base/transient.yaml", "When": {"Prefix": "/data/clicks"}, "Dest": {"Table": "mydataset.clicks"}}`,
+	}
+	for name, content := range assets {
+		assert.Nil(t, fs.Upload(ctx, baseURL+"/"+name, file.DefaultFileOsMode, strings.NewReader(content)))
+	}
+
+	ruleset := &Ruleset{RulesURL: baseURL, CheckInMs: 1}
+	if !assert.Nil(t, ruleset.Init(ctx, fs, "")) {
+		return
+	}
+	if !assert.EqualValues(t, 2, len(ruleset.Rules)) {
+		return
+	}
+	events := ruleset.Rule(ctx, baseURL+"/events.yaml")
+	if !assert.NotNil(t, events) {
+		return
+	}
+	assert.True(t, events.Async)
+	assert.EqualValues(t, "mydataset.events", events.Dest.Table)
+	assert.EqualValues(t, "temp", events.Dest.Transient.Dataset)
+	assert.EqualValues(t, "$Date", events.Dest.Partition)
+	assert.EqualValues(t, "NEWLINE_DELIMITED_JSON", events.Dest.SourceFormat)
+	assert.EqualValues(t, 120, events.Batch.Window.DurationInSec)
+	if assert.EqualValues(t, 2, len(events.OnSuccess)) {
+		assert.EqualValues(t, "delete", events.OnSuccess[0].Action)
+		assert.EqualValues(t, "query", events.OnSuccess[1].Action)
+	}
+	if assert.EqualValues(t, 1, len(events.OnFailure)) {
+		assert.EqualValues(t, "notify", events.OnFailure[0].Action)
+	}
+
+	//base fragment change reloads all dependent rules
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, fs.Upload(ctx, baseURL+"/_base/transient.yaml", file.DefaultFileOsMode, strings.NewReader(strings.Replace(assets["_base/transient.yaml"], "temp", "transient", 1))))
+	time.Sleep(5 * time.Millisecond)
+	changed, err := ruleset.ReloadIfNeeded(ctx, fs)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.EqualValues(t, 2, len(ruleset.Rules))
+	for _, URL := range []string{baseURL + "/events.yaml", baseURL + "/clicks.json"} {
+		rule := ruleset.Rule(ctx, URL)
+		if assert.NotNil(t, rule, URL) {
+			assert.EqualValues(t, "transient", rule.Dest.Transient.Dataset, URL)
+		}
+	}
+	//unchanged fragments shared by rules do not trigger another reload
+	time.Sleep(5 * time.Millisecond)
+	changed, err = ruleset.ReloadIfNeeded(ctx, fs)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	//cyclic reference
+	cyclicURL := "mem://localhost/fragments/cyclic/rule.json"
+	assert.Nil(t, fs.Upload(ctx, cyclicURL, file.DefaultFileOsMode, strings.NewReader(`{"Extends": "base.json", "Dest": {"Table": "mydataset.cyclic"}}`)))
+	assert.Nil(t, fs.Upload(ctx, "mem://localhost/fragments/cyclic/base.json", file.DefaultFileOsMode, strings.NewReader(`{"Extends": "rule.json"}`)))
+	_, _, err = ruleset.loadRule(ctx, fs, cyclicURL)
+	assert.NotNil(t, err)
+}
+
+func TestRuleset_ReloadIfNeeded_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/fragments/concurrent"
+	fragment := "Dest:\n  Transient:\n    Dataset: %v\n"
+	assert.Nil(t, fs.Upload(ctx, baseURL+"/_base/transient.yaml", file.DefaultFileOsMode, strings.NewReader(fmt.Sprintf(fragment, "temp"))))
+	for _, name := range []string{"events", "clicks"} {
+		rule := fmt.Sprintf("Extends: _base/transient.yaml\nWhen:\n  Prefix: /data/%v\nDest:\n  Table: mydataset.%v\n", name, name)
+		assert.Nil(t, fs.Upload(ctx, baseURL+"/"+name+".yaml", file.DefaultFileOsMode, strings.NewReader(rule)))
+	}
+	ruleset := &Ruleset{RulesURL: baseURL, CheckInMs: 1}
+	if !assert.Nil(t, ruleset.Init(ctx, fs, "")) {
+		return
+	}
+	waitGroup := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			for j := 0; j < 20; j++ {
+				if i == 0 && j%5 == 0 {
+					_ = fs.Upload(ctx, baseURL+"/_base/transient.yaml", file.DefaultFileOsMode, strings.NewReader(fmt.Sprintf(fragment, fmt.Sprintf("temp%v", j))))
+				}
+				_, err := ruleset.ReloadIfNeeded(ctx, fs)
+				assert.Nil(t, err)
+				time.Sleep(time.Millisecond)
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+	assert.EqualValues(t, 2, len(ruleset.Rules))
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	//SortDesc descending order
+	SortDesc = "DESC"
+	//SortAsc ascending order
+	SortAsc = "ASC"
+)
+
+//OrderBy represents deduplication ordering, the first row per unique key wins
+type OrderBy struct {
+	//Column ordering column
+	Column string
+	//Direction ASC or DESC (default, the latest row wins)
+	Direction string `json:",omitempty"`
+}
+
+//Init initialises ordering
+func (o *OrderBy) Init() {
+	o.Direction = strings.ToUpper(strings.TrimSpace(o.Direction))
+	if o.Direction == "" {
+		o.Direction = SortDesc
+	}
+}
+
+//Validate checks if ordering is valid
+func (o *OrderBy) Validate() error {
+	if o.Column == "" {
+		return errors.New("dest.DedupeOrderBy.Column was empty")
+	}
+	switch strings.ToUpper(o.Direction) {
+	case "", SortAsc, SortDesc:
+		return nil
+	}
+	return errors.Errorf("invalid dest.DedupeOrderBy.Direction: %v, valid: [%v %v]", o.Direction, SortAsc, SortDesc)
+}
+
+//Expression returns ORDER BY expression with optional table alias
+func (o *OrderBy) Expression(alias string) string {
+	column := o.Column
+	if alias != "" && !strings.Contains(column, ".") {
+		column = alias + "." + column
+	}
+	direction := strings.ToUpper(o.Direction)
+	if direction == "" {
+		direction = SortDesc
+	}
+	return fmt.Sprintf("%v %v", column, direction)
+}
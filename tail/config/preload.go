@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"github.com/viant/afs/url"
+	"github.com/viant/afsc/gs"
+	"github.com/viant/bqtail/task/expr"
+	"strings"
+)
+
+//Pre load record operations
+const (
+	OpRename     = "rename"
+	OpNormalize  = "normalize"
+	OpDrop       = "drop"
+	OpFlatten    = "flatten"
+	OpCast       = "cast"
+	OpFilter     = "filter"
+	OpMapToArray = "mapToArray"
+)
+
+const (
+	defaultFlattenSeparator = "_"
+	defaultMapKeyName       = "key"
+	defaultMapValueName     = "value"
+)
+
+//PreLoad represents data file record transformation applied while streaming source to transformed copy, BigQuery loads the transformed copy
+type PreLoad struct {
+	//URL Google Storage location, data file is transformed into URL/$bucket/$path, it should not be matched by the rule
+	URL string `json:",omitempty"`
+	//KeepTransformed if set, transformed copies are not removed after OnSuccess actions
+	KeepTransformed bool `json:",omitempty"`
+	//Operations record operations applied in order
+	Operations []*Operation `json:",omitempty"`
+}
+
+//Operation represents declarative record operation
+type Operation struct {
+	//Op operation: rename, normalize, drop, flatten, cast, filter or mapToArray
+	Op string
+	//Fields rename: source to dest field, cast: field to type (STRING, INTEGER, FLOAT, BOOLEAN, TIMESTAMP)
+	Fields map[string]string `json:",omitempty"`
+	//Keys drop: dropped fields, flatten and mapToArray: map fields (flatten uses all nested maps if empty)
+	Keys []string `json:",omitempty"`
+	//Expr filter predicate, records evaluating to false are skipped, i.e. status != 'test' && amount > 0
+	Expr string `json:",omitempty"`
+	//Separator flatten nested key separator (_ by default)
+	Separator string `json:",omitempty"`
+	//KeyName mapToArray element key field name (key by default)
+	KeyName string `json:",omitempty"`
+	//ValueName mapToArray element value field name (value by default)
+	ValueName string `json:",omitempty"`
+}
+
+//Init initialises operation defaults
+func (o *Operation) Init() {
+	switch o.Op {
+	case OpFlatten:
+		if o.Separator == "" {
+			o.Separator = defaultFlattenSeparator
+		}
+	case OpMapToArray:
+		if o.KeyName == "" {
+			o.KeyName = defaultMapKeyName
+		}
+		if o.ValueName == "" {
+			o.ValueName = defaultMapValueName
+		}
+	}
+}
+
+//Validate checks if operation is valid
+func (o *Operation) Validate(format string) error {
+	switch o.Op {
+	case OpRename:
+		if len(o.Fields) == 0 {
+			return fmt.Errorf("%v fields were empty", o.Op)
+		}
+	case OpCast:
+		if len(o.Fields) == 0 {
+			return fmt.Errorf("%v fields were empty", o.Op)
+		}
+		for field, fieldType := range o.Fields {
+			switch strings.ToUpper(fieldType) {
+			case "STRING", "INTEGER", "INT64", "FLOAT", "FLOAT64", "NUMERIC", "BOOLEAN", "BOOL", "TIMESTAMP":
+			default:
+				return fmt.Errorf("unsupported %v type: %v for %v", o.Op, fieldType, field)
+			}
+		}
+	case OpDrop, OpMapToArray:
+		if len(o.Keys) == 0 {
+			return fmt.Errorf("%v keys were empty", o.Op)
+		}
+	case OpFilter:
+		if o.Expr == "" {
+			return fmt.Errorf("%v expr was empty", o.Op)
+		}
+		if _, err := expr.Parse(o.Expr); err != nil {
+			return fmt.Errorf("invalid %v expr: %v, %v", o.Op, o.Expr, err)
+		}
+	case OpNormalize, OpFlatten:
+	default:
+		return fmt.Errorf("unsupported preLoad operation: '%v'", o.Op)
+	}
+	if format == "CSV" && (o.Op == OpFlatten || o.Op == OpMapToArray) {
+		return fmt.Errorf("unsupported %v operation for CSV format", o.Op)
+	}
+	return nil
+}
+
+//Init initialises pre load defaults
+func (p *PreLoad) Init() {
+	for _, operation := range p.Operations {
+		operation.Init()
+	}
+}
+
+//IsTransformed returns true if URL is a transformed copy
+func (p *PreLoad) IsTransformed(URL string) bool {
+	return strings.HasPrefix(URL, strings.TrimRight(p.URL, "/")+"/")
+}
+
+//Validate checks if pre load is valid
+func (p *PreLoad) Validate(dest *Destination) error {
+	if p.URL == "" {
+		return fmt.Errorf("preLoad.URL was empty")
+	}
+	if scheme := url.Scheme(p.URL, ""); scheme != gs.Scheme {
+		return fmt.Errorf("unsupported preLoad.URL scheme: %v, expected %v", scheme, gs.Scheme)
+	}
+	if len(p.Operations) == 0 {
+		return fmt.Errorf("preLoad.operations were empty")
+	}
+	format := strings.ToUpper(dest.SourceFormat)
+	switch format {
+	case "", "CSV":
+		format = "CSV"
+		if dest.SkipLeadingRows < 1 {
+			return fmt.Errorf("preLoad requires CSV header, skipLeadingRows was: %v", dest.SkipLeadingRows)
+		}
+	case "NEWLINE_DELIMITED_JSON":
+	default:
+		return fmt.Errorf("unsupported preLoad source format: %v", dest.SourceFormat)
+	}
+	for i, operation := range p.Operations {
+		if err := operation.Validate(format); err != nil {
+			return fmt.Errorf("invalid preLoad.operations[%v]: %v", i, err)
+		}
+	}
+	return nil
+}
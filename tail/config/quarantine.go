@@ -0,0 +1,13 @@
+package config
+
+//Quarantine represents corrupted data file quarantine settings, corrupted file is split into clean part that is reloaded and rejected rows moved to dead letter location
+type Quarantine struct {
+	//URL dead letter location for clean parts, rejected rows and manifests (QuarantineURL by default)
+	URL string `json:",omitempty"`
+	//Validate if set, every NEWLINE_DELIMITED_JSON or CSV line is validated locally, otherwise only load job error positions are used
+	Validate bool `json:",omitempty"`
+	//KeepOriginal if set, corrupted file is moved to corrupted file location, otherwise it is removed once split
+	KeepOriginal bool `json:",omitempty"`
+	//MaxRejectedRows if rejected rows count exceeds the limit, the whole file is treated as corrupted (0 - no limit)
+	MaxRejectedRows int `json:",omitempty"`
+}
@@ -0,0 +1,19 @@
+package config
+
+import "fmt"
+
+//Reconcile represents source to destination row reconciliation settings
+type Reconcile struct {
+	//Tolerance allowed row count difference as fraction of source records, i.e. 0.001 (exact match by default)
+	Tolerance float64 `json:",omitempty"`
+	//ManifestExt optional sidecar manifest extension, i.e. .manifest, used instead of counting source file records when manifest exists
+	ManifestExt string `json:",omitempty"`
+}
+
+//Validate checks if reconcile is valid
+func (r *Reconcile) Validate() error {
+	if r.Tolerance < 0 || r.Tolerance >= 1 {
+		return fmt.Errorf("invalid Reconcile.Tolerance: %v, expected [0,1)", r.Tolerance)
+	}
+	return nil
+}
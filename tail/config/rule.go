@@ -32,6 +32,15 @@ type Rule struct {
 	InvalidSchemaURL      string         `json:",omitempty"`
 	CounterURL            string         `json:",omitempty"`
 	MaxReload             *int           `json:",omitempty"`
+	Dedupe                *Dedupe        `json:",omitempty"`
+	Quarantine            *Quarantine    `json:",omitempty"`
+	Staging               *Staging       `json:",omitempty"`
+	Reconcile             *Reconcile     `json:",omitempty" description:"source to destination row reconciliation"`
+	PreLoad               *PreLoad       `json:",omitempty" description:"record transformation applied before loading"`
+	Budget                *Budget        `json:",omitempty" description:"daily BigQuery usage limits"`
+	Priority              string         `json:",omitempty" description:"dispatcher priority class name (letters and digits only), carried in BigQuery job ID"`
+	Extends               string         `json:",omitempty" description:"base rule fragment URL, relative URL is resolved against the rule location"`
+	Include               []string       `json:",omitempty" description:"rule fragment URLs merged in order after Extends base"`
 }
 
 //Name returns rule name derived from name
@@ -53,6 +62,23 @@ func (r Rule) StalledDuration() time.Duration {
 
 }
 
+//isPriorityName returns true if priority class name can be encoded in BigQuery job ID
+func isPriorityName(name string) bool {
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+//WithBatch returns shallow rule copy using supplied batch setting
+func (r *Rule) WithBatch(batch *Batch) *Rule {
+	result := *r
+	result.Batch = batch
+	return &result
+}
+
 //MaxReloadAttempts returns max reload attempts
 func (r *Rule) MaxReloadAttempts() int {
 	if r.MaxReload == nil {
@@ -81,6 +107,11 @@ func (r *Rule) IsAppend() bool {
 	return r.Dest.WriteDisposition == "" || r.Dest.WriteDisposition == "WRITE_APPEND"
 }
 
+//UsesLedger returns true if ingestion ledger is enabled
+func (r *Rule) UsesLedger() bool {
+	return r.Dedupe != nil && r.Dedupe.Ledger
+}
+
 //IsDMLCopy returns true if dml append flag is true
 func (r *Rule) IsDMLCopy() bool {
 	if r.Dest == nil || r.Dest.Transient == nil {
@@ -92,6 +123,17 @@ func (r *Rule) IsDMLCopy() bool {
 	return strings.ToUpper(*r.Dest.Transient.CopyMethod) == shared.CopyMethodDML
 }
 
+//IsMergeCopy returns true if transient data is merged into dest table
+func (r *Rule) IsMergeCopy() bool {
+	if r.Dest == nil || r.Dest.Transient == nil {
+		return false
+	}
+	if r.Dest.Transient.CopyMethod == nil {
+		return false
+	}
+	return strings.ToUpper(*r.Dest.Transient.CopyMethod) == shared.CopyMethodMerge
+}
+
 //DestTable returns dest table
 func (r *Rule) DestTable(URL string, modTime time.Time) string {
 	if r.Dest.Table == "" {
@@ -117,6 +159,35 @@ func (r Rule) Validate() error {
 	if r.Dest == nil {
 		return fmt.Errorf("dest was empty")
 	}
+	if r.Staging != nil {
+		if !r.Async {
+			return fmt.Errorf("staging is only supported in async mode")
+		}
+		if err := r.Staging.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Reconcile != nil {
+		if err := r.Reconcile.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Budget != nil {
+		if err := r.Budget.Validate(); err != nil {
+			return err
+		}
+	}
+	if !isPriorityName(r.Priority) {
+		return fmt.Errorf("invalid priority: %v, only letters and digits are allowed", r.Priority)
+	}
+	if r.PreLoad != nil {
+		if !r.Async {
+			return fmt.Errorf("preLoad is only supported in async mode")
+		}
+		if err := r.PreLoad.Validate(r.Dest); err != nil {
+			return err
+		}
+	}
 	return r.Dest.Validate()
 }
 
@@ -126,6 +197,37 @@ func (r *Rule) Init(ctx context.Context, fs afs.Service) error {
 	if r.Dest.Pattern != "" && r.When.Filter == "" {
 		r.When.Filter = r.Dest.Pattern
 	}
+	if r.Staging != nil {
+		r.Staging.Init()
+		if !r.Staging.KeepStaged && !hasAction(r.OnSuccess, shared.ActionDelete, shared.ActionMove) {
+			r.OnSuccess = append(r.OnSuccess, &task.Action{Action: shared.ActionDelete})
+			actions = r.Actions()
+		}
+	}
+	if r.PreLoad != nil {
+		r.PreLoad.Init()
+		if !r.PreLoad.KeepTransformed && !hasAction(r.OnSuccess, shared.ActionDelete, shared.ActionMove) {
+			r.OnSuccess = append(r.OnSuccess, &task.Action{Action: shared.ActionDelete})
+			actions = r.Actions()
+		}
+	}
+	if r.Budget != nil {
+		r.Budget.Init()
+		if err := task.NewActions(r.Budget.OnExceeded, nil).Init(ctx, fs); err != nil {
+			return err
+		}
+	}
 	err := actions.Init(ctx, fs)
 	return err
 }
+
+func hasAction(actions []*task.Action, names ...string) bool {
+	for _, action := range actions {
+		for _, name := range names {
+			if action.Action == name {
+				return true
+			}
+		}
+	}
+	return false
+}
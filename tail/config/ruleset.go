@@ -12,6 +12,7 @@ import (
 	"gopkg.in/yaml.v2"
 	"log"
 	"path"
+	"sync"
 	"time"
 )
 
@@ -21,13 +22,21 @@ type Ruleset struct {
 	CheckInMs int
 	Rules     []*Rule
 	*base.Loader
+	//mux serializes rules reload and fragments tracking for concurrent Tail callers
+	mux                *sync.Mutex
+	fragments          map[string]*fragment
+	nextFragmentsCheck time.Time
 }
 
 func (r *Ruleset) modify(ctx context.Context, fs afs.Service, URL string) {
-	loaded, err := r.loadRule(ctx, fs, URL)
+	if IsFragment(r.RulesURL, URL) {
+		return
+	}
+	loaded, dependencies, err := r.loadRule(ctx, fs, URL)
 	if err != nil {
 		log.Printf("failed to load rule: %v: %v", URL, err)
 	}
+	r.trackFragments(ctx, fs, URL, dependencies)
 	var temp = make([]*Rule, 0)
 	rules := r.Rules
 	for i, rule := range rules {
@@ -41,6 +50,7 @@ func (r *Ruleset) modify(ctx context.Context, fs afs.Service, URL string) {
 }
 
 func (r *Ruleset) remove(ctx context.Context, fs afs.Service, URL string) {
+	r.trackFragments(ctx, fs, URL, nil)
 	var temp = make([]*Rule, 0)
 	rules := r.Rules
 	for i, rule := range rules {
@@ -150,24 +160,95 @@ func (r *Ruleset) Init(ctx context.Context, fs afs.Service, projectID string) er
 		return err
 	}
 	checkFrequency := time.Duration(r.CheckInMs) * time.Millisecond
+	r.mux = &sync.Mutex{}
 	r.Loader = base.NewLoader(r.RulesURL, checkFrequency, fs, r.modify, r.remove)
 	_, err := r.Loader.Notify(ctx, fs)
 	return err
 }
 
-//ReloadIfNeeded reloads rule if there is a change
+//ReloadIfNeeded reloads rule if there is a change, rules extending or including changed fragment are reloaded too
 func (r *Ruleset) ReloadIfNeeded(ctx context.Context, fs afs.Service) (bool, error) {
-	return r.Loader.Notify(ctx, fs)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	changed, err := r.Loader.Notify(ctx, fs)
+	if err != nil {
+		return changed, err
+	}
+	return r.reloadDependents(ctx, fs) || changed, nil
+}
+
+//trackFragments replaces fragments rule URL depends on
+func (r *Ruleset) trackFragments(ctx context.Context, fs afs.Service, URL string, dependencies []string) {
+	if r.fragments == nil {
+		r.fragments = make(map[string]*fragment)
+	}
+	for fragmentURL, tracked := range r.fragments {
+		delete(tracked.rules, URL)
+		if len(tracked.rules) == 0 {
+			delete(r.fragments, fragmentURL)
+		}
+	}
+	for _, fragmentURL := range dependencies {
+		tracked, ok := r.fragments[fragmentURL]
+		if !ok {
+			tracked = &fragment{rules: make(map[string]bool)}
+			if object, err := fs.Object(ctx, fragmentURL); err == nil {
+				tracked.modTime = object.ModTime()
+			}
+			r.fragments[fragmentURL] = tracked
+		}
+		tracked.rules[URL] = true
+	}
+}
+
+//reloadDependents reloads rules which fragments have changed since the last check
+func (r *Ruleset) reloadDependents(ctx context.Context, fs afs.Service) bool {
+	now := time.Now()
+	if len(r.fragments) == 0 || now.Before(r.nextFragmentsCheck) {
+		return false
+	}
+	checkFrequency := time.Duration(r.CheckInMs) * time.Millisecond
+	if checkFrequency == 0 {
+		checkFrequency = time.Minute
+	}
+	r.nextFragmentsCheck = now.Add(checkFrequency)
+	var modified = make(map[string]bool)
+	for fragmentURL, tracked := range r.fragments {
+		modTime := time.Time{}
+		if object, err := fs.Object(ctx, fragmentURL); err == nil {
+			modTime = object.ModTime()
+		}
+		if modTime.Equal(tracked.modTime) {
+			continue
+		}
+		tracked.modTime = modTime
+		for ruleURL := range tracked.rules {
+			modified[ruleURL] = true
+		}
+	}
+	for ruleURL := range modified {
+		if shared.IsInfoLoggingLevel() {
+			shared.LogF("reloading rule with modified fragment: %v\n", ruleURL)
+		}
+		r.modify(ctx, fs, ruleURL)
+	}
+	return len(modified) > 0
 }
 
-func (r *Ruleset) loadRule(ctx context.Context, fs afs.Service, URL string) ([]*Rule, error) {
+func (r *Ruleset) loadRule(ctx context.Context, fs afs.Service, URL string) ([]*Rule, []string, error) {
 	data, err := fs.DownloadWithURL(ctx, URL)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to load resource: %v", URL)
+		return nil, nil, errors.Wrapf(err, "failed to load resource: %v", URL)
+	}
+	var rules []*Rule
+	var dependencies []string
+	if documents, decodeErr := decodeDocuments(data, path.Ext(URL)); decodeErr == nil && hasFragments(documents) {
+		rules, dependencies, err = resolveRules(ctx, fs, URL, documents)
+	} else {
+		rules, err = loadRules(data, path.Ext(URL))
 	}
-	rules, err := loadRules(data, path.Ext(URL))
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to decode: %v", URL)
+		return nil, dependencies, errors.Wrapf(err, "failed to decode: %v", URL)
 	}
 	transientRoutes := Ruleset{Rules: rules}
 	_, name := url.Split(URL, "")
@@ -181,18 +262,18 @@ func (r *Ruleset) loadRule(ctx context.Context, fs afs.Service, URL string) ([]*
 			rules[i].Info.Workflow = name
 		}
 		if err := rules[i].Dest.Init(); err != nil {
-			return nil, err
+			return nil, dependencies, err
 		}
 		if err := rules[i].Init(ctx, fs); err != nil {
-			return nil, errors.Wrap(err, "failed to initialises pose action")
+			return nil, dependencies, errors.Wrap(err, "failed to initialises pose action")
 		}
 	}
 
 	if err := transientRoutes.Validate(); err != nil {
-		return nil, errors.Wrapf(err, "invalid rule: %v", URL)
+		return nil, dependencies, errors.Wrapf(err, "invalid rule: %v", URL)
 	}
 
-	return rules, nil
+	return rules, dependencies, nil
 }
 
 func loadRules(data []byte, ext string) ([]*Rule, error) {
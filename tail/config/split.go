@@ -9,10 +9,17 @@ type Split struct {
 	TimeColumn     string
 	ClusterColumns []string
 	Mapping        []*TableMapping
+	Dynamic        *DynamicSplit `json:",omitempty" description:"optional split to one destination table per distinct column value"`
 }
 
 //Validate checks if split is valid
 func (s *Split) Validate() error {
+	if s.Dynamic != nil {
+		if len(s.Mapping) > 0 {
+			return errors.Errorf("mapping and dynamic split are mutually exclusive")
+		}
+		return s.Dynamic.Validate()
+	}
 	if len(s.Mapping) == 0 {
 		return errors.Errorf("mapping were empty")
 	}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"github.com/viant/afs/url"
+	"github.com/viant/afsc/gs"
+)
+
+const (
+	defaultStagingConcurrency = 4
+	defaultStagingPartSizeMb  = 32
+)
+
+//Staging represents external (s3://, azure://) data file staging to Google Storage settings, BigQuery loads the staged copy
+type Staging struct {
+	//URL Google Storage staging location, data file is staged as URL/$bucket/$path
+	URL string `json:",omitempty"`
+	//Concurrency max number of parts transferred in parallel (4 by default)
+	Concurrency int `json:",omitempty"`
+	//PartSizeMb transfer part size in MB (32 by default)
+	PartSizeMb int `json:",omitempty"`
+	//KeepStaged if set, staged copies are not removed after OnSuccess actions
+	KeepStaged bool `json:",omitempty"`
+}
+
+//Init initialises staging defaults
+func (s *Staging) Init() {
+	if s.Concurrency == 0 {
+		s.Concurrency = defaultStagingConcurrency
+	}
+	if s.PartSizeMb == 0 {
+		s.PartSizeMb = defaultStagingPartSizeMb
+	}
+}
+
+//PartSize returns part size in bytes
+func (s *Staging) PartSize() int64 {
+	return int64(s.PartSizeMb) * 1024 * 1024
+}
+
+//IsRequired returns true if source URL has to be staged before loading
+func (s *Staging) IsRequired(sourceURL string) bool {
+	return url.Scheme(sourceURL, gs.Scheme) != gs.Scheme
+}
+
+//Validate checks if staging is valid
+func (s *Staging) Validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("staging.URL was empty")
+	}
+	if scheme := url.Scheme(s.URL, ""); scheme != gs.Scheme {
+		return fmt.Errorf("unsupported staging.URL scheme: %v, expected %v", scheme, gs.Scheme)
+	}
+	if s.Concurrency < 0 || s.PartSizeMb < 0 {
+		return fmt.Errorf("invalid staging concurrency: %v or part size: %v", s.Concurrency, s.PartSizeMb)
+	}
+	return nil
+}
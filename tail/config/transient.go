@@ -2,7 +2,6 @@ package config
 
 import (
 	"errors"
-	"github.com/viant/bqtail/dispatch/contract"
 	"github.com/viant/bqtail/tail/config/transient"
 )
 
@@ -17,6 +16,8 @@ type Transient struct {
 	CopyMethod *string
 	Criteria   string `json:",omitempty" description:"optional dml copy criteria "`
 	Balancer   *transient.Balancer
+	Merge      *transient.Merge  `json:",omitempty" description:"optional MERGE copy method settings"`
+	Assert     *transient.Assert `json:",omitempty" description:"optional post load data quality assertions"`
 }
 
 //Validate checks if transient is valid
@@ -24,14 +25,20 @@ func (t Transient) Validate() error {
 	if t.Dataset == "" {
 		return errors.New("Transient.Dataset was empty")
 	}
+	if err := t.Assert.Validate(); err != nil {
+		return err
+	}
+	if err := t.Balancer.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
 //JobProjectID return job IDs
-func (t Transient) JobProjectID(performance contract.ProjectPerformance) string {
+func (t Transient) JobProjectID(selection *transient.Selection) string {
 	if t.Balancer == nil {
 		return t.ProjectID
 	}
 
-	return t.Balancer.ProjectID(performance)
+	return t.Balancer.ProjectID(selection)
 }
@@ -0,0 +1,56 @@
+package transient
+
+import (
+	"fmt"
+)
+
+//Assert represents post load data quality assertions, run against temp table before data is copied to dest table
+type Assert struct {
+	//QuarantineDataset optional dataset where temp table is copied on violation
+	QuarantineDataset string `json:",omitempty"`
+	//Assertions assertion SQLs with $TempTable and thresholds
+	Assertions []*Assertion
+}
+
+//Assertion represents SQL returning single value (value column or the only column) checked against thresholds
+type Assertion struct {
+	Name string `json:",omitempty"`
+	//SQL assertion SQL, i.e. SELECT SAFE_DIVIDE(COUNTIF(id IS NULL), COUNT(1)) AS value FROM $TempTable
+	SQL string
+	//Min optional inclusive min threshold
+	Min *float64 `json:",omitempty"`
+	//Max optional inclusive max threshold
+	Max *float64 `json:",omitempty"`
+	//Warn logs violation without failing
+	Warn bool `json:",omitempty"`
+}
+
+//Validate checks if assert is valid
+func (a *Assert) Validate() error {
+	if a == nil {
+		return nil
+	}
+	if len(a.Assertions) == 0 {
+		return fmt.Errorf("invalid Transient.Assert: assertions were empty")
+	}
+	for i, assertion := range a.Assertions {
+		if err := assertion.Validate(); err != nil {
+			return fmt.Errorf("invalid Transient.Assert: assertion[%v]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+//Validate checks if assertion is valid
+func (a *Assertion) Validate() error {
+	if a.SQL == "" {
+		return fmt.Errorf("SQL was empty")
+	}
+	if a.Min == nil && a.Max == nil {
+		return fmt.Errorf("min and max were empty")
+	}
+	if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+		return fmt.Errorf("min %v is greater than max %v", *a.Min, *a.Max)
+	}
+	return nil
+}
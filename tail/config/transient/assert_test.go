@@ -0,0 +1,47 @@
+package transient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAssert_Validate(t *testing.T) {
+	zero, max := 0.0, 0.01
+	var useCases = []struct {
+		description string
+		assert      *Assert
+		hasError    bool
+	}{
+		{
+			description: "nil assert",
+		},
+		{
+			description: "valid assertion",
+			assert:      &Assert{Assertions: []*Assertion{{SQL: "SELECT 1", Min: &zero, Max: &max}}},
+		},
+		{
+			description: "empty assertions",
+			assert:      &Assert{QuarantineDataset: "quarantine"},
+			hasError:    true,
+		},
+		{
+			description: "missing thresholds",
+			assert:      &Assert{Assertions: []*Assertion{{SQL: "SELECT 1"}}},
+			hasError:    true,
+		},
+		{
+			description: "min greater than max",
+			assert:      &Assert{Assertions: []*Assertion{{SQL: "SELECT 1", Min: &max, Max: &zero}}},
+			hasError:    true,
+		},
+		{
+			description: "missing SQL",
+			assert:      &Assert{Assertions: []*Assertion{{Max: &max}}},
+			hasError:    true,
+		},
+	}
+	for _, useCase := range useCases {
+		err := useCase.assert.Validate()
+		assert.EqualValues(t, useCase.hasError, err != nil, useCase.description)
+	}
+}
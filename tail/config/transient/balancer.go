@@ -1,39 +1,82 @@
 package transient
 
 import (
+	"fmt"
 	"github.com/viant/bqtail/dispatch/contract"
 	"github.com/viant/bqtail/shared"
+	"hash/fnv"
 	"math/rand"
 	"time"
 )
 
+//Selection represents project selection input
+type Selection struct {
+	Performance contract.ProjectPerformance
+	//DestTable destination table, used by sticky strategy
+	DestTable string
+	//Sequence journal persisted selection sequence, used by roundRobin strategy
+	Sequence int
+}
+
 //Balancer represents projects balancer
 type Balancer struct {
-	//BalancingStrategy - rand - randomly selects project, fallback select next project in the list if previous project reached
+	//BalancingStrategy - rand - randomly selects project, fallback select next project in the list if previous project reached,
+	//weighted - randomly selects project proportionally to its weight, leastLoaded - selects project with the lowest active jobs count,
+	//sticky - selects project by destination table hash, roundRobin - selects next project in the list
 	Strategy string `json:",omitempty"`
 
 	//ProjectIDs
 	ProjectIDs []string
 
-	//MaxLoadJobs max load job for fallback strategy
+	//MaxLoadJobs max load job for fallback strategy, rand and weighted strategy skip project reaching it
 	MaxLoadJobs int `json:",omitempty"`
+
+	//Weights per project weight for weighted strategy, project without weight has weight 1
+	Weights map[string]int `json:",omitempty"`
+}
+
+//Validate checks if balancer is valid
+func (t *Balancer) Validate() error {
+	if t == nil {
+		return nil
+	}
+	switch t.Strategy {
+	case "", shared.BalancerStrategyRand, shared.BalancerStrategyFallback, shared.BalancerStrategyWeighted,
+		shared.BalancerStrategyLeastLoaded, shared.BalancerStrategySticky, shared.BalancerStrategyRoundRobin:
+	default:
+		return fmt.Errorf("unsupported Balancer.Strategy: %v", t.Strategy)
+	}
+	for projectID, weight := range t.Weights {
+		if weight < 0 {
+			return fmt.Errorf("invalid Balancer.Weights[%v]: %v", projectID, weight)
+		}
+	}
+	return nil
 }
 
 //ProjectID returns project ID
-func (t Balancer) ProjectID(performance contract.ProjectPerformance) string {
+func (t Balancer) ProjectID(selection *Selection) string {
 	switch len(t.ProjectIDs) {
 	case 0:
 		return ""
 	case 1:
 		return t.ProjectIDs[0]
 	}
+	performance := selection.Performance
 	switch t.Strategy {
 	case shared.BalancerStrategyFallback:
 		if projectID := t.selectPrioritizedProject(performance); projectID != "" {
 			return projectID
 		}
 		return t.selectRandomProject()
-
+	case shared.BalancerStrategyWeighted:
+		return t.selectWeightedProject(performance)
+	case shared.BalancerStrategyLeastLoaded:
+		return t.selectLeastLoadedProject(performance)
+	case shared.BalancerStrategySticky:
+		return t.selectStickyProject(selection.DestTable)
+	case shared.BalancerStrategyRoundRobin:
+		return t.ProjectIDs[uint(selection.Sequence)%uint(len(t.ProjectIDs))]
 	}
 
 	projectID := t.selectRandomProject()
@@ -74,3 +117,79 @@ func (t Balancer) selectPrioritizedProject(performance contract.ProjectPerforman
 	}
 	return ""
 }
+
+//selectWeightedProject randomly selects project proportionally to its weight, projects reaching MaxLoadJobs are skipped unless all reached it
+func (t Balancer) selectWeightedProject(performance contract.ProjectPerformance) string {
+	var candidates = make([]string, 0, len(t.ProjectIDs))
+	for _, projectID := range t.ProjectIDs {
+		if t.weight(projectID) == 0 {
+			continue
+		}
+		if perf, ok := performance[projectID]; ok && t.MaxLoadJobs > 0 && perf.ActiveLoadCount() >= t.MaxLoadJobs {
+			continue
+		}
+		candidates = append(candidates, projectID)
+	}
+	if len(candidates) == 0 {
+		for _, projectID := range t.ProjectIDs {
+			if t.weight(projectID) > 0 {
+				candidates = append(candidates, projectID)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return t.selectRandomProject()
+	}
+	total := 0
+	for _, projectID := range candidates {
+		total += t.weight(projectID)
+	}
+	point := int(uint(rand.NewSource(time.Now().UnixNano()).Int63()) % uint(total))
+	for _, projectID := range candidates {
+		if point -= t.weight(projectID); point < 0 {
+			return projectID
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (t Balancer) weight(projectID string) int {
+	if weight, ok := t.Weights[projectID]; ok {
+		return weight
+	}
+	return 1
+}
+
+//selectLeastLoadedProject selects project with the lowest pending and running load, query and copy jobs count, ties are resolved by the list order
+func (t Balancer) selectLeastLoadedProject(performance contract.ProjectPerformance) string {
+	result := t.ProjectIDs[0]
+	minCount := -1
+	for _, projectID := range t.ProjectIDs {
+		count := activeJobCount(performance[projectID])
+		if minCount == -1 || count < minCount {
+			result = projectID
+			minCount = count
+		}
+	}
+	return result
+}
+
+func activeJobCount(perf *contract.Performance) int {
+	if perf == nil {
+		return 0
+	}
+	result := 0
+	for _, metrics := range []*contract.Metrics{perf.Pending, perf.Running} {
+		if metrics != nil {
+			result += metrics.LoadJobs + metrics.QueryJobs + metrics.CopyJobs
+		}
+	}
+	return result
+}
+
+//selectStickyProject selects project by destination table hash, so that the table jobs always use the same project
+func (t Balancer) selectStickyProject(destTable string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(destTable))
+	return t.ProjectIDs[hash.Sum32()%uint32(len(t.ProjectIDs))]
+}
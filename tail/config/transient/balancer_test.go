@@ -0,0 +1,111 @@
+package transient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/dispatch/contract"
+	"github.com/viant/bqtail/shared"
+	"testing"
+)
+
+func TestBalancer_ProjectID(t *testing.T) {
+
+	var useCases = []struct {
+		description string
+		balancer    *Balancer
+		selection   *Selection
+		expect      string
+		expectAny   map[string]bool
+	}{
+		{
+			description: "weighted strategy skips zero weight project",
+			balancer: &Balancer{
+				Strategy:   shared.BalancerStrategyWeighted,
+				ProjectIDs: []string{"p1", "p2", "p3"},
+				Weights:    map[string]int{"p1": 0, "p2": 3, "p3": 1},
+			},
+			selection: &Selection{},
+			expectAny: map[string]bool{"p2": true, "p3": true},
+		},
+		{
+			description: "weighted strategy skips project reaching max load jobs",
+			balancer: &Balancer{
+				Strategy:    shared.BalancerStrategyWeighted,
+				ProjectIDs:  []string{"p1", "p2"},
+				Weights:     map[string]int{"p1": 10, "p2": 1},
+				MaxLoadJobs: 5,
+			},
+			selection: &Selection{Performance: contract.ProjectPerformance{
+				"p1": &contract.Performance{Running: &contract.Metrics{LoadJobs: 5}},
+				"p2": &contract.Performance{Running: &contract.Metrics{LoadJobs: 1}},
+			}},
+			expect: "p2",
+		},
+		{
+			description: "least loaded strategy counts pending and running load, query and copy jobs",
+			balancer: &Balancer{
+				Strategy:   shared.BalancerStrategyLeastLoaded,
+				ProjectIDs: []string{"p1", "p2", "p3"},
+			},
+			selection: &Selection{Performance: contract.ProjectPerformance{
+				"p1": &contract.Performance{Running: &contract.Metrics{LoadJobs: 2}, Pending: &contract.Metrics{QueryJobs: 2}},
+				"p2": &contract.Performance{Running: &contract.Metrics{CopyJobs: 1}, Pending: &contract.Metrics{LoadJobs: 2}},
+				"p3": &contract.Performance{Running: &contract.Metrics{QueryJobs: 5}},
+			}},
+			expect: "p2",
+		},
+		{
+			description: "least loaded strategy project without performance",
+			balancer: &Balancer{
+				Strategy:   shared.BalancerStrategyLeastLoaded,
+				ProjectIDs: []string{"p1", "p2"},
+			},
+			selection: &Selection{Performance: contract.ProjectPerformance{
+				"p1": &contract.Performance{Running: &contract.Metrics{LoadJobs: 1}},
+			}},
+			expect: "p2",
+		},
+		{
+			description: "least loaded strategy tie",
+			balancer: &Balancer{
+				Strategy:   shared.BalancerStrategyLeastLoaded,
+				ProjectIDs: []string{"p1", "p2"},
+			},
+			selection: &Selection{},
+			expect:    "p1",
+		},
+		{
+			description: "round robin strategy",
+			balancer: &Balancer{
+				Strategy:   shared.BalancerStrategyRoundRobin,
+				ProjectIDs: []string{"p1", "p2", "p3"},
+			},
+			selection: &Selection{Sequence: 5},
+			expect:    "p3",
+		},
+	}
+
+	for _, useCase := range useCases {
+		if len(useCase.expectAny) > 0 {
+			for i := 0; i < 100; i++ {
+				actual := useCase.balancer.ProjectID(useCase.selection)
+				assert.True(t, useCase.expectAny[actual], useCase.description)
+			}
+			continue
+		}
+		actual := useCase.balancer.ProjectID(useCase.selection)
+		assert.Equal(t, useCase.expect, actual, useCase.description)
+	}
+}
+
+func TestBalancer_Sticky(t *testing.T) {
+	balancer := &Balancer{Strategy: shared.BalancerStrategySticky, ProjectIDs: []string{"p1", "p2", "p3"}}
+	var selected = make(map[string]bool)
+	for _, table := range []string{"db.t1", "db.t2", "db.t3", "db.t4", "db.t5", "db.t6"} {
+		projectID := balancer.ProjectID(&Selection{DestTable: table})
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, projectID, balancer.ProjectID(&Selection{DestTable: table}), table)
+		}
+		selected[projectID] = true
+	}
+	assert.True(t, len(selected) > 1)
+}
@@ -0,0 +1,27 @@
+package transient
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Merge represents MERGE copy method settings
+type Merge struct {
+	//OrderBy optional ordering column/expression selecting the latest row version per unique key, i.e. t.updated (DESC is default)
+	OrderBy string `json:",omitempty"`
+	//Delete optional delete flag expression, matched dest rows are removed for source rows evaluating to true, i.e. t.op = 'D'
+	Delete string `json:",omitempty"`
+}
+
+//OrderByExpression returns ordering expression with direction, latest (DESC) by default
+func (m *Merge) OrderByExpression() string {
+	if m == nil || m.OrderBy == "" {
+		return ""
+	}
+	orderBy := strings.TrimSpace(m.OrderBy)
+	upper := strings.ToUpper(orderBy)
+	if strings.HasSuffix(upper, " DESC") || strings.HasSuffix(upper, " ASC") {
+		return orderBy
+	}
+	return fmt.Sprintf("%v DESC", orderBy)
+}
@@ -84,14 +84,14 @@ func TestTransient_JobProjectID(t *testing.T) {
 
 		if len(useCase.expectRand) > 0 {
 			for i := 0; i < 10 && len(useCase.expectRand) > 0; i++ {
-				actual := useCase.transient.JobProjectID(useCase.performance)
+				actual := useCase.transient.JobProjectID(&transient.Selection{Performance: useCase.performance})
 				delete(useCase.expectRand, actual)
 			}
 			assert.True(t, len(useCase.expectRand) == 0, useCase.description)
 			continue
 		}
 
-		actual := useCase.transient.JobProjectID(useCase.performance)
+		actual := useCase.transient.JobProjectID(&transient.Selection{Performance: useCase.performance})
 		assert.Equal(t, useCase.expect, actual, useCase.description)
 	}
 }
@@ -10,12 +10,16 @@ const (
 	MinWindowDuration = 10 * time.Second
 	//DefaultWindowDurationSec default window duration
 	DefaultWindowDurationSec = 95
+	//MaxWindowDurationSec max window duration widened to stay within table jobs quota
+	MaxWindowDurationSec = 24 * 60 * 60
 )
 
 //Window represents batching window
 type Window struct {
 	time.Duration `json:",omitempty"`
 	DurationInSec int `json:",omitempty"`
+	//BaseDurationInSec configured window duration, set only for window widened to stay within table jobs quota
+	BaseDurationInSec int `json:"-"`
 }
 
 //Init initialises window
@@ -0,0 +1,39 @@
+package tail
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCapChunkSizeMb(t *testing.T) {
+
+	var useCases = []struct {
+		description string
+		chunkSizeMb int
+		memoryMb    int
+		expect      int
+	}{
+		{
+			description: "unknown function memory",
+			chunkSizeMb: 1024,
+			expect:      1024,
+		},
+		{
+			description: "chunk size capped by function memory",
+			chunkSizeMb: 1024,
+			memoryMb:    512,
+			expect:      256,
+		},
+		{
+			description: "chunk size within function memory",
+			chunkSizeMb: 128,
+			memoryMb:    2048,
+			expect:      128,
+		},
+	}
+
+	for _, useCase := range useCases {
+		actual := capChunkSizeMb(useCase.chunkSizeMb, useCase.memoryMb)
+		assert.Equal(t, useCase.expect, actual, useCase.description)
+	}
+}
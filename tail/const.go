@@ -1,3 +1,11 @@
 package tail
 
-const notFoundURLFragment = "Not found: URI "
+const (
+	notFoundURLFragment = "Not found: URI "
+	defaultChunkSizeMb  = 1024
+	//functionMemoryEnvKey cloud function memory limit env variable
+	functionMemoryEnvKey = "FUNCTION_MEMORY_MB"
+	//defaultMaxTableJobsPerDay BigQuery load jobs per table per day limit
+	defaultMaxTableJobsPerDay  = 1500
+	defaultTableQuotaThreshold = 0.8
+)
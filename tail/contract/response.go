@@ -2,8 +2,11 @@ package contract
 
 import (
 	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/schema"
 	"github.com/viant/bqtail/stage"
 	"github.com/viant/bqtail/tail/batch"
+	"github.com/viant/bqtail/tail/ledger"
+	"github.com/viant/bqtail/tail/quarantine"
 	"github.com/viant/bqtail/tail/status"
 )
 
@@ -20,19 +23,33 @@ type Response struct {
 	BatchingEventID string `json:",omitempty"`
 	WindowURL       string `json:",omitempty"`
 	TriggerURL      string
-	ScheduledURL    string         `json:",omitempty"`
-	Window          *batch.Window  `json:",omitempty"`
-	Process         *stage.Process `json:",omitempty"`
-	ListOpCount     int            `json:",omitempty"`
-	StorageRetries  map[int]int    `json:",omitempty"`
-	Retriable       bool           `json:",omitempty"`
-	RetryError      string         `json:",omitempty"`
-	RuleError       string         `json:",omitempty"`
-	LoadError       string         `json:",omitempty"`
-	RetryCount      int            `json:",omitempty"`
-	MoveError       string         `json:",omitempty"`
-	CounterError    string         `json:",omitempty"`
-	DownloadError   string         `json:",omitempty"`
+	ScheduledURL    string                 `json:",omitempty"`
+	Window          *batch.Window          `json:",omitempty"`
+	Process         *stage.Process         `json:",omitempty"`
+	ListOpCount     int                    `json:",omitempty"`
+	StorageRetries  map[int]int            `json:",omitempty"`
+	Retriable       bool                   `json:",omitempty"`
+	RetryError      string                 `json:",omitempty"`
+	RuleError       string                 `json:",omitempty"`
+	LoadError       string                 `json:",omitempty"`
+	RetryCount      int                    `json:",omitempty"`
+	MoveError       string                 `json:",omitempty"`
+	CounterError    string                 `json:",omitempty"`
+	DownloadError   string                 `json:",omitempty"`
+	Duplicate       *ledger.Entry          `json:",omitempty"`
+	SchemaError     string                 `json:",omitempty"`
+	SchemaChanges   []*schema.Change       `json:",omitempty"`
+	Quarantined     []*quarantine.Manifest `json:",omitempty"`
+	StagedURL       string                 `json:",omitempty"`
+	TransformedURL  string                 `json:",omitempty"`
+	FilteredRows    int                    `json:",omitempty"`
+	Chunks          []string               `json:",omitempty"`
+	PausedURL       string                 `json:",omitempty"`
+	Downgraded      bool                   `json:",omitempty"`
+	TableJobs       int                    `json:",omitempty"`
+	WindowInSec     int                    `json:",omitempty"`
+	QuotaError      string                 `json:",omitempty"`
+	UsageError      string                 `json:",omitempty"`
 }
 
 //NewResponse creates a new response
@@ -0,0 +1,58 @@
+package cost
+
+import (
+	"google.golang.org/api/bigquery/v2"
+	"time"
+)
+
+//Usage represents BigQuery jobs usage
+type Usage struct {
+	Jobs                int
+	TotalBytesProcessed int64
+	TotalBytesBilled    int64
+	TotalSlotMs         int64
+	//ChargedBytes billed bytes, or processed bytes for jobs not reporting billed bytes
+	ChargedBytes int64
+}
+
+//Add adds job statistics
+func (u *Usage) Add(stats *bigquery.JobStatistics) {
+	u.Jobs++
+	u.TotalBytesProcessed += stats.TotalBytesProcessed
+	u.TotalSlotMs += stats.TotalSlotMs
+	if stats.Query != nil && stats.Query.TotalBytesBilled > 0 {
+		u.TotalBytesBilled += stats.Query.TotalBytesBilled
+		u.ChargedBytes += stats.Query.TotalBytesBilled
+		return
+	}
+	u.ChargedBytes += stats.TotalBytesProcessed
+}
+
+//Record represents daily rule usage record
+type Record struct {
+	Date    string
+	RuleURL string
+	Usage
+	//Tables usage by destination table
+	Tables map[string]*Usage `json:",omitempty"`
+	//Exceeded set once rule budget has been exceeded
+	Exceeded bool `json:",omitempty"`
+	Updated  time.Time
+}
+
+//Add adds job statistics to rule and destination table usage
+func (r *Record) Add(destTable string, stats *bigquery.JobStatistics) {
+	r.Usage.Add(stats)
+	if destTable == "" {
+		return
+	}
+	if r.Tables == nil {
+		r.Tables = make(map[string]*Usage)
+	}
+	usage, ok := r.Tables[destTable]
+	if !ok {
+		usage = &Usage{}
+		r.Tables[destTable] = usage
+	}
+	usage.Add(stats)
+}
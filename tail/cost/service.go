@@ -0,0 +1,156 @@
+package cost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	maxRetries = 5
+	resumedExt = ".resumed"
+)
+
+//Service represents daily rule cost accounting service
+type Service interface {
+	//Add adds job usage to the current day rule record, it returns updated record and true if the rule budget got exceeded by this job
+	Add(ctx context.Context, request *Request) (*Record, bool, error)
+	//Get returns the current day rule record, or empty record
+	Get(ctx context.Context, ruleURL string) (*Record, error)
+	//Resume claims the current day rule paused data files resume, it returns true only for the first caller of the day
+	Resume(ctx context.Context, ruleURL string) (bool, error)
+}
+
+//Request represents job usage request
+type Request struct {
+	RuleURL   string
+	DestTable string
+	Budget    *config.Budget
+	Job       *bigquery.Job
+}
+
+type service struct {
+	baseURL string
+	fs      afs.Service
+	mux     sync.Mutex
+	resumed map[string]string
+}
+
+//Add adds job usage to the current day rule record with generation precondition, conflicting update is retried
+func (s *service) Add(ctx context.Context, request *Request) (*Record, bool, error) {
+	if request.Job == nil || request.Job.Statistics == nil {
+		return nil, false, nil
+	}
+	URL := s.recordURL(request.RuleURL, time.Now())
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		record, generation, loadErr := s.load(ctx, URL)
+		if loadErr != nil {
+			return nil, false, loadErr
+		}
+		if record == nil {
+			record = newRecord(request.RuleURL, time.Now())
+		}
+		record.Add(request.DestTable, request.Job.Statistics)
+		exceeded := false
+		if request.Budget != nil && !record.Exceeded && request.Budget.IsExceeded(record.ChargedBytes, record.TotalSlotMs) {
+			record.Exceeded = true
+			exceeded = true
+		}
+		record.Updated = time.Now().UTC()
+		data, marshalErr := json.Marshal(record)
+		if marshalErr != nil {
+			return nil, false, marshalErr
+		}
+		if err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, generation)); err == nil {
+			return record, exceeded, nil
+		}
+		if !base.IsPreConditionError(err) {
+			break
+		}
+	}
+	return nil, false, errors.Wrapf(err, "failed to update cost record: %v", URL)
+}
+
+//Get returns the current day rule record, or empty record
+func (s *service) Get(ctx context.Context, ruleURL string) (*Record, error) {
+	record, _, err := s.load(ctx, s.recordURL(ruleURL, time.Now()))
+	if record == nil && err == nil {
+		record = newRecord(ruleURL, time.Now())
+	}
+	return record, err
+}
+
+//Resume claims the current day rule paused data files resume with create only marker upload, claimed day is cached to skip storage checks
+func (s *service) Resume(ctx context.Context, ruleURL string) (bool, error) {
+	date := time.Now().UTC().Format(dateLayout)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.resumed[ruleURL] == date {
+		return false, nil
+	}
+	URL := url.Join(s.baseURL, date, RuleKey(ruleURL)+resumedExt)
+	if exists, _ := s.fs.Exists(ctx, URL, option.NewObjectKind(true)); exists {
+		s.resumed[ruleURL] = date
+		return false, nil
+	}
+	err := s.fs.Upload(ctx, URL, file.DefaultFileOsMode, strings.NewReader(date), option.NewGeneration(true, 0))
+	if base.IsPreConditionError(err) {
+		s.resumed[ruleURL] = date
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to claim paused data files resume: %v", URL)
+	}
+	s.resumed[ruleURL] = date
+	return true, nil
+}
+
+func (s *service) load(ctx context.Context, URL string) (*Record, int64, error) {
+	exists, err := s.fs.Exists(ctx, URL, option.NewObjectKind(true))
+	if err != nil || !exists {
+		return nil, 0, err
+	}
+	generation := &option.Generation{}
+	data, err := s.fs.DownloadWithURL(ctx, URL, generation)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to load cost record: %v", URL)
+	}
+	record := &Record{}
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to unmarshal cost record: %v", URL)
+	}
+	return record, generation.Generation, nil
+}
+
+//recordURL returns daily rule record URL: baseURL/$date/$rulePath.json
+func (s *service) recordURL(ruleURL string, date time.Time) string {
+	return url.Join(s.baseURL, date.UTC().Format(dateLayout), RuleKey(ruleURL)+shared.JSONExt)
+}
+
+//RuleKey returns rule key derived from rule URL path
+func RuleKey(ruleURL string) string {
+	return strings.Trim(strings.Replace(url.Path(ruleURL), "/", "_", -1), "_")
+}
+
+func newRecord(ruleURL string, date time.Time) *Record {
+	return &Record{RuleURL: ruleURL, Date: date.UTC().Format(dateLayout)}
+}
+
+//New creates afs storage based cost accounting service
+func New(baseURL string, fs afs.Service) Service {
+	return &service{baseURL: baseURL, fs: fs, resumed: make(map[string]string)}
+}
@@ -0,0 +1,100 @@
+package cost
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"testing"
+)
+
+func TestService_Add(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	ruleURL := "mem://localhost/config/rule/events.yaml"
+
+	useCases := []struct {
+		description    string
+		destTable      string
+		stats          *bigquery.JobStatistics
+		expectJobs     int
+		expectBytes    int64
+		expectSlotMs   int64
+		expectExceeded bool
+		expectNotify   bool
+	}{
+		{
+			description:  "processed bytes used without billed bytes",
+			destTable:    "proj:ds.events",
+			stats:        &bigquery.JobStatistics{TotalBytesProcessed: 40, TotalSlotMs: 10},
+			expectJobs:   1,
+			expectBytes:  40,
+			expectSlotMs: 10,
+		},
+		{
+			description:    "billed bytes exceed budget",
+			destTable:      "proj:ds.events",
+			stats:          &bigquery.JobStatistics{TotalBytesProcessed: 60, TotalSlotMs: 5, Query: &bigquery.JobStatistics2{TotalBytesBilled: 70}},
+			expectJobs:     2,
+			expectBytes:    110,
+			expectSlotMs:   15,
+			expectExceeded: true,
+			expectNotify:   true,
+		},
+		{
+			description:    "exceeded budget notified once a day",
+			destTable:      "proj:ds.sessions",
+			stats:          &bigquery.JobStatistics{TotalBytesProcessed: 10},
+			expectJobs:     3,
+			expectBytes:    120,
+			expectSlotMs:   15,
+			expectExceeded: true,
+		},
+	}
+
+	srv := New("mem://localhost/cost", fs)
+	budget := &config.Budget{MaxBytesPerDay: 100}
+	budget.Init()
+	for _, useCase := range useCases {
+		record, notify, err := srv.Add(ctx, &Request{RuleURL: ruleURL, DestTable: useCase.destTable, Budget: budget, Job: &bigquery.Job{Statistics: useCase.stats}})
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectNotify, notify, useCase.description)
+		actual, err := srv.Get(ctx, ruleURL)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, record, actual, useCase.description)
+		assert.EqualValues(t, useCase.expectJobs, actual.Jobs, useCase.description)
+		assert.EqualValues(t, useCase.expectBytes, actual.ChargedBytes, useCase.description)
+		assert.EqualValues(t, useCase.expectSlotMs, actual.TotalSlotMs, useCase.description)
+		assert.EqualValues(t, useCase.expectExceeded, actual.Exceeded, useCase.description)
+		assert.NotNil(t, actual.Tables[useCase.destTable], useCase.description)
+	}
+}
+
+func TestService_Resume(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	ruleURL := "mem://localhost/config/rule/resumed.yaml"
+	useCases := []struct {
+		description string
+		srv         Service
+		expect      bool
+	}{
+		{description: "first caller of the day", srv: New("mem://localhost/cost/resume", fs), expect: true},
+		{description: "same instance caller", expect: false},
+		{description: "other instance caller", srv: New("mem://localhost/cost/resume", fs), expect: false},
+	}
+	var srv Service
+	for _, useCase := range useCases {
+		if useCase.srv != nil {
+			srv = useCase.srv
+		}
+		claimed, err := srv.Resume(ctx, ruleURL)
+		assert.Nil(t, err, useCase.description)
+		assert.EqualValues(t, useCase.expect, claimed, useCase.description)
+	}
+}
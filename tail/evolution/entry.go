@@ -0,0 +1,17 @@
+package evolution
+
+import (
+	"github.com/viant/bqtail/schema"
+	"time"
+)
+
+//Entry represents schema evolution audit entry
+type Entry struct {
+	Table       string
+	EventID     string
+	RuleURL     string
+	SourceURLs  []string
+	Changes     []*schema.Change
+	ApprovalURL string `json:",omitempty"`
+	Applied     time.Time
+}
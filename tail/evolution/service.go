@@ -0,0 +1,301 @@
+package evolution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/schema"
+	"github.com/viant/bqtail/service/bq"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage"
+	"github.com/viant/bqtail/stage/activity"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"time"
+)
+
+const (
+	pendingFolder = "pending"
+	auditLayout   = "20060102150405"
+)
+
+//Service represents schema evolution service
+type Service interface {
+	//Evolve detects source data schema changes and applies them to destination tables according to the rule schema evolution policy
+	Evolve(ctx context.Context, request *Request) (*Response, error)
+}
+
+//Request represents schema evolution request
+type Request struct {
+	Rule       *config.Rule
+	EventID    string
+	DestTable  string
+	SourceURLs []string
+	//Fields optional source fields, i.e. detected from load job error
+	Fields []*bigquery.TableFieldSchema
+}
+
+//Response represents schema evolution response
+type Response struct {
+	Changes  []*schema.Change
+	Accepted []string
+	Rejected []string
+}
+
+type table struct {
+	name     string
+	table    *bigquery.Table
+	original []*bigquery.TableFieldSchema
+	changes  []*schema.Change
+}
+
+type service struct {
+	bq       bq.Service
+	fs       afs.Service
+	auditURL string
+}
+
+//Evolve applies allowed changes to the destination and template tables, data files with not allowed changes are rejected.
+//If a destructive change requires approval that has not been granted yet, pending change is written next to audit entries and an error is returned
+func (s *service) Evolve(ctx context.Context, request *Request) (*Response, error) {
+	policy := request.Rule.Dest.SchemaEvolution
+	response := &Response{Accepted: request.SourceURLs}
+	if policy == nil {
+		return response, nil
+	}
+	tables, err := s.loadTables(ctx, request)
+	if err != nil || len(tables) == 0 {
+		return response, err
+	}
+	response.Accepted = make([]string, 0, len(request.SourceURLs))
+	for _, URL := range request.SourceURLs {
+		fields, err := SourceFields(ctx, s.fs, URL, request.Rule.Dest)
+		if err != nil && shared.IsInfoLoggingLevel() {
+			shared.LogF("failed to detect source schema: %v, %v\n", URL, err)
+		}
+		if len(request.Fields) > 0 {
+			fields = schema.MergeFields(fields, request.Fields)
+		}
+		if len(fields) == 0 {
+			response.Accepted = append(response.Accepted, URL)
+			continue
+		}
+		accepted, err := s.evolve(ctx, request, URL, fields, tables)
+		if err != nil {
+			return nil, err
+		}
+		if accepted {
+			response.Accepted = append(response.Accepted, URL)
+		} else {
+			response.Rejected = append(response.Rejected, URL)
+		}
+	}
+	unique := map[string]bool{}
+	for _, candidate := range tables {
+		if len(candidate.changes) == 0 {
+			continue
+		}
+		if err = s.apply(ctx, request, candidate, response.Accepted); err != nil {
+			return nil, err
+		}
+		for _, change := range candidate.changes {
+			if !unique[change.String()] {
+				unique[change.String()] = true
+				response.Changes = append(response.Changes, change)
+			}
+		}
+	}
+	return response, nil
+}
+
+//evolve checks source fields against all tables, it returns false if any change is not allowed by the policy
+func (s *service) evolve(ctx context.Context, request *Request, URL string, fields []*bigquery.TableFieldSchema, tables []*table) (bool, error) {
+	policy := request.Rule.Dest.SchemaEvolution
+	aliases := policy.Aliases
+	changes := make([][]*schema.Change, len(tables))
+	for i, candidate := range tables {
+		var err error
+		if changes[i], err = schema.Diff(candidate.table.Schema.Fields, fields, aliases); err != nil {
+			shared.LogF("[%v] rejected %v: %v\n", candidate.name, URL, err)
+			return false, nil
+		}
+		for _, change := range changes[i] {
+			if !policy.Allows(change) {
+				shared.LogF("[%v] rejected %v: schema change not allowed: %v\n", candidate.name, URL, change)
+				return false, nil
+			}
+		}
+		if err = s.checkApproval(ctx, request, candidate.name, changes[i]); err != nil {
+			return false, err
+		}
+	}
+	for i, candidate := range tables {
+		if len(changes[i]) == 0 {
+			continue
+		}
+		candidate.table.Schema.Fields = schema.Evolve(candidate.table.Schema.Fields, changes[i])
+		candidate.changes = append(candidate.changes, changes[i]...)
+	}
+	return true, nil
+}
+
+//checkApproval checks if approval file exists for destructive changes, otherwise it writes pending changes and returns an error
+func (s *service) checkApproval(ctx context.Context, request *Request, tableName string, changes []*schema.Change) error {
+	policy := request.Rule.Dest.SchemaEvolution
+	var destructive = make([]string, 0)
+	for _, change := range changes {
+		if policy.RequiresApproval(change) {
+			destructive = append(destructive, change.String())
+		}
+	}
+	if len(destructive) == 0 {
+		return nil
+	}
+	name := fmt.Sprintf("%v%v", base.Hash(strings.Join(destructive, ",")), shared.JSONExt)
+	approvalURL := url.Join(policy.ApprovalURL, tableName, name)
+	if approved, _ := s.fs.Exists(ctx, approvalURL, option.NewObjectKind(true)); approved {
+		return nil
+	}
+	pending := &Entry{
+		Table:       tableName,
+		EventID:     request.EventID,
+		RuleURL:     request.Rule.Info.URL,
+		SourceURLs:  request.SourceURLs,
+		Changes:     changes,
+		ApprovalURL: approvalURL,
+	}
+	pendingURL := url.Join(s.auditURL, pendingFolder, tableName, name)
+	if err := s.upload(ctx, pendingURL, pending); err != nil {
+		return err
+	}
+	return errors.Errorf("schema change of %v requires approval: %v, pending: %v, approval file: %v", tableName, destructive, pendingURL, approvalURL)
+}
+
+//apply patches table schema with added and relaxed columns, widens column types with DDL and writes audit entry
+func (s *service) apply(ctx context.Context, request *Request, candidate *table, sourceURLs []string) error {
+	var patched, widen = make([]*schema.Change, 0), make([]*schema.Change, 0)
+	for _, change := range candidate.changes {
+		if change.Kind == schema.ChangeWiden {
+			widen = append(widen, change)
+			continue
+		}
+		patched = append(patched, change)
+	}
+	if len(patched) > 0 {
+		candidate.table.Schema.Fields = schema.Evolve(candidate.original, patched)
+		candidate.table.ExpirationTime = 0
+		_, err := s.bq.Patch(ctx, &bq.PatchRequest{
+			Table:         candidate.name,
+			TemplateTable: candidate.table,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to evolve %v schema: %v", candidate.name, patched)
+		}
+	}
+	for i, change := range widen {
+		if err := s.alterColumn(ctx, request, candidate, i+1, change); err != nil {
+			return errors.Wrapf(err, "failed to evolve %v schema: %v", candidate.name, change)
+		}
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] evolved schema: %v\n", candidate.name, candidate.changes)
+	}
+	entry := &Entry{
+		Table:      candidate.name,
+		EventID:    request.EventID,
+		RuleURL:    request.Rule.Info.URL,
+		SourceURLs: sourceURLs,
+		Changes:    candidate.changes,
+		Applied:    time.Now().UTC(),
+	}
+	policy := request.Rule.Dest.SchemaEvolution
+	for _, change := range candidate.changes {
+		if policy.RequiresApproval(change) {
+			entry.ApprovalURL = policy.ApprovalURL
+			break
+		}
+	}
+	auditURL := url.Join(s.auditURL, candidate.name, fmt.Sprintf("%v_%v%v", entry.Applied.Format(auditLayout), request.EventID, shared.JSONExt))
+	return s.upload(ctx, auditURL, entry)
+}
+
+//alterColumn changes column type with DDL statement, table patch does not allow type changes
+func (s *service) alterColumn(ctx context.Context, request *Request, candidate *table, step int, change *schema.Change) error {
+	ref := candidate.table.TableReference
+	SQL := fmt.Sprintf("ALTER TABLE `%v` ALTER COLUMN %v SET DATA TYPE %v", base.EncodeTableReference(ref, true), change.Field, change.To)
+	action := &task.Action{
+		Action:  shared.ActionQuery,
+		Actions: &task.Actions{},
+		Meta: &activity.Meta{
+			Process: stage.Process{
+				EventID:   request.EventID,
+				DestTable: candidate.name,
+				ProjectID: ref.ProjectId,
+				Region:    candidate.table.Location,
+			},
+			Action: shared.ActionQuery,
+			Mode:   shared.StepModeNop,
+			Step:   step,
+		},
+	}
+	_, err := s.bq.Query(ctx, &bq.QueryRequest{SQL: SQL}, action)
+	return err
+}
+
+func (s *service) upload(ctx context.Context, URL string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return errors.Wrapf(err, "failed to upload schema evolution entry: %v", URL)
+	}
+	return nil
+}
+
+//loadTables loads destination, destination template and transient template tables, tables that do not exist yet are skipped
+func (s *service) loadTables(ctx context.Context, request *Request) ([]*table, error) {
+	dest := request.Rule.Dest
+	names := []string{request.DestTable, dest.Schema.Template}
+	if dest.Transient != nil {
+		names = append(names, dest.Transient.Template)
+	}
+	var result = make([]*table, 0)
+	unique := map[string]bool{}
+	for _, name := range names {
+		if name == "" || unique[name] {
+			continue
+		}
+		unique[name] = true
+		ref, err := base.NewTableReference(name)
+		if err != nil {
+			return nil, err
+		}
+		candidate, err := s.bq.Table(ctx, ref)
+		if base.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if candidate.Schema == nil {
+			continue
+		}
+		result = append(result, &table{name: name, table: candidate, original: schema.CloneFields(candidate.Schema.Fields)})
+	}
+	return result, nil
+}
+
+//New creates schema evolution service
+func New(bqService bq.Service, fs afs.Service, auditURL string) Service {
+	return &service{bq: bqService, fs: fs, auditURL: auditURL}
+}
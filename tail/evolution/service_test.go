@@ -0,0 +1,114 @@
+package evolution
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/service/bq/emulator"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+)
+
+func TestService_Evolve(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	var useCases = []struct {
+		description    string
+		fields         []*bigquery.TableFieldSchema
+		policy         *config.SchemaEvolution
+		data           string
+		expectChanges  []string
+		expectRejected int
+		expectFields   map[string]string
+		expectPending  bool
+		expectError    bool
+	}{
+		{
+			description:   "patch added column",
+			fields:        []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}},
+			policy:        &config.SchemaEvolution{AddColumns: true},
+			data:          `{"id":1,"name":"a"}`,
+			expectChanges: []string{"add name STRING"},
+			expectFields:  map[string]string{"id": "INT64", "name": "STRING"},
+		},
+		{
+			description:   "widen column type",
+			fields:        []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}, {Name: "amount", Type: "INT64"}},
+			policy:        &config.SchemaEvolution{WidenTypes: true},
+			data:          `{"id":1,"amount":1.5}`,
+			expectChanges: []string{"widen amount INT64 -> FLOAT64"},
+			expectFields:  map[string]string{"id": "INT64", "amount": "FLOAT64"},
+		},
+		{
+			description:    "rejected change",
+			fields:         []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}},
+			policy:         &config.SchemaEvolution{Reject: true},
+			data:           `{"id":1,"name":"a"}`,
+			expectRejected: 1,
+			expectFields:   map[string]string{"id": "INT64"},
+		},
+		{
+			description:   "pending approval",
+			fields:        []*bigquery.TableFieldSchema{{Name: "id", Type: "INT64"}, {Name: "amount", Type: "INT64"}},
+			policy:        &config.SchemaEvolution{WidenTypes: true, ApprovalURL: "mem://localhost/evolution/test/approval"},
+			data:          `{"id":1,"amount":1.5}`,
+			expectFields:  map[string]string{"id": "INT64", "amount": "INT64"},
+			expectPending: true,
+			expectError:   true,
+		},
+	}
+
+	for i, useCase := range useCases {
+		baseURL := fmt.Sprintf("mem://localhost/evolution/test/%v", i)
+		auditURL := url.Join(baseURL, "audit")
+		bqEmulator := emulator.New(url.Join(baseURL, "bq"), fs)
+		bqService, err := bqEmulator.Service(ctx, task.NewRegistry(), "myproject", fs, base.Config{ProjectID: "myproject", ErrorURL: url.Join(baseURL, "errors")})
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.Nil(t, bqService.CreateDatasetIfNotExist(ctx, "", &bigquery.DatasetReference{DatasetId: "mydataset"}), useCase.description)
+		ref := &bigquery.TableReference{ProjectId: "myproject", DatasetId: "mydataset", TableId: "events"}
+		assert.Nil(t, bqService.CreateTableIfNotExist(ctx, &bigquery.Table{TableReference: ref, Schema: &bigquery.TableSchema{Fields: useCase.fields}}, false), useCase.description)
+		dataURL := url.Join(baseURL, "data/events.json")
+		assert.Nil(t, fs.Upload(ctx, dataURL, file.DefaultFileOsMode, strings.NewReader(useCase.data+"\n")), useCase.description)
+
+		rule := &config.Rule{Dest: &config.Destination{Table: "mydataset.events", SchemaEvolution: useCase.policy}}
+		rule.Dest.SourceFormat = "NEWLINE_DELIMITED_JSON"
+		srv := New(bqService, fs, auditURL)
+		response, err := srv.Evolve(ctx, &Request{Rule: rule, EventID: "101", DestTable: "mydataset.events", SourceURLs: []string{dataURL}})
+		pending, _ := fs.List(ctx, url.Join(auditURL, pendingFolder, "mydataset.events"), option.NewObjectKind(true))
+		assert.EqualValues(t, useCase.expectPending, len(pending) > 0, useCase.description)
+		if useCase.expectError {
+			assert.NotNil(t, err, useCase.description)
+		} else if assert.Nil(t, err, useCase.description) {
+			var changes = make([]string, 0)
+			for _, change := range response.Changes {
+				changes = append(changes, change.String())
+			}
+			if len(useCase.expectChanges) > 0 {
+				assert.EqualValues(t, useCase.expectChanges, changes, useCase.description)
+			} else {
+				assert.Empty(t, changes, useCase.description)
+			}
+			assert.EqualValues(t, useCase.expectRejected, len(response.Rejected), useCase.description)
+			assert.EqualValues(t, 1-useCase.expectRejected, len(response.Accepted), useCase.description)
+		}
+		table, err := bqService.Table(ctx, ref)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		var actualFields = make(map[string]string)
+		for _, field := range table.Schema.Fields {
+			actualFields[field.Name] = field.Type
+		}
+		assert.EqualValues(t, useCase.expectFields, actualFields, useCase.description)
+	}
+}
@@ -0,0 +1,165 @@
+package evolution
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/viant/afs"
+	"github.com/viant/bqtail/schema"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonFormat    = "NEWLINE_DELIMITED_JSON"
+	csvFormat     = "CSV"
+	maxLineLength = 16 * 1024 * 1024
+)
+
+//SourceFields returns schema fields detected from the data file first record, CSV file requires a header row, other formats are not supported
+func SourceFields(ctx context.Context, fs afs.Service, URL string, dest *config.Destination) ([]*bigquery.TableFieldSchema, error) {
+	format := strings.ToUpper(dest.SourceFormat)
+	if format != jsonFormat && !(format == "" || format == csvFormat) {
+		return nil, nil
+	}
+	if format != jsonFormat && dest.SkipLeadingRows == 0 {
+		return nil, nil
+	}
+	reader, err := fs.OpenURL(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var source io.Reader = reader
+	if strings.HasSuffix(URL, ".gz") {
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gzReader.Close()
+		source = gzReader
+	}
+	if format == jsonFormat {
+		return jsonFields(source)
+	}
+	return csvFields(source, dest.FieldDelimiter)
+}
+
+func jsonFields(reader io.Reader) ([]*bigquery.TableFieldSchema, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		record := map[string]interface{}{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		return recordFields(record), nil
+	}
+	return nil, scanner.Err()
+}
+
+func csvFields(reader io.Reader, delimiter string) ([]*bigquery.TableFieldSchema, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	switch delimiter {
+	case "", ",":
+	case "\\t", "tab":
+		csvReader.Comma = '\t'
+	default:
+		csvReader.Comma = []rune(delimiter)[0]
+	}
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row, err := csvReader.Read()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var result = make([]*bigquery.TableFieldSchema, 0, len(header))
+	for i, name := range header {
+		field := &bigquery.TableFieldSchema{Name: strings.TrimSpace(name), Type: schema.FieldTypeString, Mode: schema.ModeNullable}
+		if i < len(row) {
+			field.Type = valueType(row[i])
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+func valueType(value string) string {
+	if value == "" {
+		return schema.FieldTypeString
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return schema.FieldTypeInt
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return schema.FieldTypeFloat
+	}
+	if value == "true" || value == "false" {
+		return schema.FieldTypeBool
+	}
+	return schema.FieldTypeString
+}
+
+//recordFields returns record schema fields, null values are skipped
+func recordFields(record map[string]interface{}) []*bigquery.TableFieldSchema {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result = make([]*bigquery.TableFieldSchema, 0, len(keys))
+	for _, key := range keys {
+		if field := valueField(key, record[key]); field != nil {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+func valueField(name string, value interface{}) *bigquery.TableFieldSchema {
+	field := &bigquery.TableFieldSchema{Name: name, Mode: schema.ModeNullable}
+	switch actual := value.(type) {
+	case nil:
+		return nil
+	case json.Number:
+		field.Type = schema.FieldTypeInt
+		if strings.ContainsAny(string(actual), ".eE") {
+			field.Type = schema.FieldTypeFloat
+		}
+	case bool:
+		field.Type = schema.FieldTypeBool
+	case map[string]interface{}:
+		field.Type = schema.FieldTypeRecord
+		field.Fields = recordFields(actual)
+	case []interface{}:
+		for _, item := range actual {
+			if item == nil {
+				continue
+			}
+			if itemField := valueField(name, item); itemField != nil {
+				itemField.Mode = schema.ModeRepeated
+				return itemField
+			}
+		}
+		return nil
+	default:
+		field.Type = schema.FieldTypeString
+	}
+	return field
+}
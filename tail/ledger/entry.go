@@ -0,0 +1,34 @@
+package ledger
+
+import (
+	"github.com/viant/afs/storage"
+	gstorage "google.golang.org/api/storage/v1"
+	"time"
+)
+
+//Entry represents ingestion ledger entry, an entry is identified by source URL, object generation and destination table
+type Entry struct {
+	URL        string
+	Generation int64
+	MD5        string `json:",omitempty"`
+	DestTable  string
+	EventID    string
+	JobID      string `json:",omitempty"`
+	Created    time.Time
+}
+
+//NewEntry creates a ledger entry for supplied source object, for non gs storage object modification time is used as generation
+func NewEntry(object storage.Object, destTable, eventID string) *Entry {
+	result := &Entry{
+		URL:        object.URL(),
+		Generation: object.ModTime().UnixNano(),
+		DestTable:  destTable,
+		EventID:    eventID,
+		Created:    time.Now(),
+	}
+	if gsObject, ok := object.Sys().(*gstorage.Object); ok {
+		result.Generation = gsObject.Generation
+		result.MD5 = gsObject.Md5Hash
+	}
+	return result
+}
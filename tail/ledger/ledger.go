@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"strings"
+)
+
+//Ledger represents exactly-once ingestion ledger
+type Ledger interface {
+	//Register registers source object ingestion, it returns previously registered entry if the same object generation has been already ingested by other event
+	Register(ctx context.Context, entry *Entry) (*Entry, error)
+
+	//Update updates registered entry, i.e. with load job ID
+	Update(ctx context.Context, entry *Entry) error
+
+	//Release removes entry, so the source object can be ingested again
+	Release(ctx context.Context, entry *Entry) error
+
+	//ReleaseURLs removes destination table entries of any generation of supplied source URLs, it is used once load job fails after the ingesting event completed
+	ReleaseURLs(ctx context.Context, destTable string, URLs []string) error
+}
+
+type service struct {
+	baseURL string
+	fs      afs.Service
+}
+
+func (s *service) entryURL(entry *Entry) string {
+	return url.Join(s.baseURL, entry.DestTable, fmt.Sprintf("%v_%v%v", base.Hash(entry.URL), entry.Generation, shared.JSONExt))
+}
+
+//Register registers an entry with storage generation precondition, thus only one event can register an object generation
+func (s *service) Register(ctx context.Context, entry *Entry) (*Entry, error) {
+	URL := s.entryURL(entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, 0))
+	if err == nil {
+		return nil, nil
+	}
+	if !base.IsPreConditionError(err) {
+		return nil, errors.Wrapf(err, "failed to register ledger entry: %v", URL)
+	}
+	if data, err = s.fs.DownloadWithURL(ctx, URL); err != nil {
+		return nil, errors.Wrapf(err, "failed to load ledger entry: %v", URL)
+	}
+	registered := &Entry{}
+	if err = json.Unmarshal(data, registered); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal ledger entry: %v", URL)
+	}
+	if registered.EventID == entry.EventID { //the same event redelivered after failure
+		return nil, nil
+	}
+	return registered, nil
+}
+
+//Update updates an entry
+func (s *service) Update(ctx context.Context, entry *Entry) error {
+	URL := s.entryURL(entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return errors.Wrapf(err, "failed to update ledger entry: %v", URL)
+	}
+	return nil
+}
+
+//Release deletes an entry
+func (s *service) Release(ctx context.Context, entry *Entry) error {
+	URL := s.entryURL(entry)
+	if ok, _ := s.fs.Exists(ctx, URL, option.NewObjectKind(true)); !ok {
+		return nil
+	}
+	return s.fs.Delete(ctx, URL, option.NewObjectKind(true))
+}
+
+//ReleaseURLs deletes destination table entries matching source URLs hash
+func (s *service) ReleaseURLs(ctx context.Context, destTable string, URLs []string) error {
+	if len(URLs) == 0 {
+		return nil
+	}
+	baseURL := url.Join(s.baseURL, destTable)
+	if ok, _ := s.fs.Exists(ctx, baseURL); !ok {
+		return nil
+	}
+	objects, err := s.fs.List(ctx, baseURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list ledger entries: %v", baseURL)
+	}
+	var prefixes = make([]string, 0, len(URLs))
+	for _, URL := range URLs {
+		prefixes = append(prefixes, fmt.Sprintf("%v_", base.Hash(URL)))
+	}
+	for _, object := range objects {
+		if object.IsDir() {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(object.Name(), prefix) {
+				continue
+			}
+			if err = s.fs.Delete(ctx, object.URL(), option.NewObjectKind(true)); err != nil {
+				return errors.Wrapf(err, "failed to release ledger entry: %v", object.URL())
+			}
+			break
+		}
+	}
+	return nil
+}
+
+//New creates afs storage based ingestion ledger
+func New(baseURL string, fs afs.Service) Ledger {
+	return &service{baseURL: baseURL, fs: fs}
+}
@@ -0,0 +1,364 @@
+package preload
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/task/expr"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//operation transforms a record in place, it returns false if record has to be skipped
+type operation func(record map[string]interface{}) (bool, error)
+
+//transformer represents compiled pre load operations
+type transformer struct {
+	operations []operation
+	config     []*config.Operation
+}
+
+//Apply applies operations in order, it returns false if record was filtered out
+func (t *transformer) Apply(record map[string]interface{}) (bool, error) {
+	for i, op := range t.operations {
+		ok, err := op(record)
+		if err != nil {
+			return false, errors.Wrapf(err, "%v failed", t.config[i].Op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+//Columns returns transformed CSV header
+func (t *transformer) Columns(columns []string) []string {
+	var result = append([]string{}, columns...)
+	for _, op := range t.config {
+		switch op.Op {
+		case config.OpRename:
+			for i, column := range result {
+				if renamed, ok := op.Fields[column]; ok {
+					result[i] = renamed
+				}
+			}
+		case config.OpNormalize:
+			for i, column := range result {
+				result[i] = NormalizeKey(column)
+			}
+		case config.OpDrop:
+			dropped := make(map[string]bool)
+			for _, key := range op.Keys {
+				dropped[key] = true
+			}
+			var retained = make([]string, 0, len(result))
+			for _, column := range result {
+				if !dropped[column] {
+					retained = append(retained, column)
+				}
+			}
+			result = retained
+		}
+	}
+	return result
+}
+
+func newTransformer(operations []*config.Operation) (*transformer, error) {
+	result := &transformer{config: operations}
+	for _, op := range operations {
+		compiled, err := newOperation(op)
+		if err != nil {
+			return nil, err
+		}
+		result.operations = append(result.operations, compiled)
+	}
+	return result, nil
+}
+
+func newOperation(op *config.Operation) (operation, error) {
+	switch op.Op {
+	case config.OpRename:
+		return func(record map[string]interface{}) (bool, error) {
+			for from, to := range op.Fields {
+				if value, ok := remove(record, from); ok {
+					put(record, to, value)
+				}
+			}
+			return true, nil
+		}, nil
+	case config.OpNormalize:
+		return func(record map[string]interface{}) (bool, error) {
+			normalize(record)
+			return true, nil
+		}, nil
+	case config.OpDrop:
+		return func(record map[string]interface{}) (bool, error) {
+			for _, key := range op.Keys {
+				remove(record, key)
+			}
+			return true, nil
+		}, nil
+	case config.OpFlatten:
+		return func(record map[string]interface{}) (bool, error) {
+			keys := op.Keys
+			if len(keys) == 0 {
+				keys = nestedKeys(record)
+			}
+			for _, key := range keys {
+				if value, ok := get(record, key); ok {
+					if aMap, ok := value.(map[string]interface{}); ok {
+						remove(record, key)
+						flatten(record, key, op.Separator, aMap)
+					}
+				}
+			}
+			return true, nil
+		}, nil
+	case config.OpCast:
+		return func(record map[string]interface{}) (bool, error) {
+			for field, fieldType := range op.Fields {
+				value, ok := get(record, field)
+				if !ok {
+					continue
+				}
+				casted, err := cast(value, fieldType)
+				if err != nil {
+					return false, errors.Wrapf(err, "failed to cast %v to %v", field, fieldType)
+				}
+				put(record, field, casted)
+			}
+			return true, nil
+		}, nil
+	case config.OpFilter:
+		expression, err := expr.Parse(op.Expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid filter expr: %v", op.Expr)
+		}
+		return func(record map[string]interface{}) (bool, error) {
+			return expression.Bool(record)
+		}, nil
+	case config.OpMapToArray:
+		return func(record map[string]interface{}) (bool, error) {
+			for _, key := range op.Keys {
+				value, ok := get(record, key)
+				if !ok {
+					continue
+				}
+				if aMap, ok := value.(map[string]interface{}); ok {
+					put(record, key, mapToArray(aMap, op.KeyName, op.ValueName))
+				}
+			}
+			return true, nil
+		}, nil
+	}
+	return nil, errors.Errorf("unsupported preLoad operation: %v", op.Op)
+}
+
+//NormalizeKey replaces characters not allowed in BigQuery column name with underscore, key starting with digit is prefixed with underscore
+func NormalizeKey(key string) string {
+	var result = make([]byte, 0, len(key)+1)
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				result = append(result, '_')
+			}
+		default:
+			c = '_'
+		}
+		result = append(result, c)
+	}
+	if len(result) == 0 {
+		return "_"
+	}
+	return string(result)
+}
+
+func normalize(value interface{}) interface{} {
+	switch actual := value.(type) {
+	case map[string]interface{}:
+		for key, item := range actual {
+			normalized := NormalizeKey(key)
+			if normalized != key {
+				delete(actual, key)
+			}
+			actual[normalized] = normalize(item)
+		}
+	case []interface{}:
+		for i, item := range actual {
+			actual[i] = normalize(item)
+		}
+	}
+	return value
+}
+
+func nestedKeys(record map[string]interface{}) []string {
+	var result = make([]string, 0)
+	for key, value := range record {
+		if _, ok := value.(map[string]interface{}); ok {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func flatten(record map[string]interface{}, prefix, separator string, aMap map[string]interface{}) {
+	for key, value := range aMap {
+		name := prefix + separator + key
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(record, name, separator, nested)
+			continue
+		}
+		record[name] = value
+	}
+}
+
+func mapToArray(aMap map[string]interface{}, keyName, valueName string) []interface{} {
+	var keys = make([]string, 0, len(aMap))
+	for key := range aMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result = make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, map[string]interface{}{keyName: key, valueName: aMap[key]})
+	}
+	return result
+}
+
+//parent returns map holding the field, field name takes precedence over dotted path
+func parent(record map[string]interface{}, field string, create bool) (map[string]interface{}, string) {
+	if _, ok := record[field]; ok || !strings.Contains(field, ".") {
+		return record, field
+	}
+	elements := strings.Split(field, ".")
+	aMap := record
+	for _, element := range elements[:len(elements)-1] {
+		value, ok := aMap[element]
+		if !ok && create {
+			value = map[string]interface{}{}
+			aMap[element] = value
+		}
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		aMap = nested
+	}
+	return aMap, elements[len(elements)-1]
+}
+
+func get(record map[string]interface{}, field string) (interface{}, bool) {
+	aMap, key := parent(record, field, false)
+	if aMap == nil {
+		return nil, false
+	}
+	value, ok := aMap[key]
+	return value, ok
+}
+
+func put(record map[string]interface{}, field string, value interface{}) {
+	if aMap, key := parent(record, field, true); aMap != nil {
+		aMap[key] = value
+	}
+}
+
+func remove(record map[string]interface{}, field string) (interface{}, bool) {
+	aMap, key := parent(record, field, false)
+	if aMap == nil {
+		return nil, false
+	}
+	value, ok := aMap[key]
+	delete(aMap, key)
+	return value, ok
+}
+
+//cast converts value to BigQuery type, TIMESTAMP converts epoch to RFC3339 UTC, fractional epoch is treated as seconds
+func cast(value interface{}, fieldType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	text := strings.TrimSpace(asString(value))
+	switch strings.ToUpper(fieldType) {
+	case "STRING":
+		return asString(value), nil
+	}
+	if text == "" {
+		return nil, nil
+	}
+	switch strings.ToUpper(fieldType) {
+	case "INTEGER", "INT64":
+		if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return integer, nil
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || number != math.Trunc(number) {
+			return nil, errors.Errorf("invalid integer: %v", text)
+		}
+		return int64(number), nil
+	case "FLOAT", "FLOAT64", "NUMERIC":
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return nil, errors.Errorf("invalid number: %v", text)
+		}
+		return json.Number(text), nil
+	case "BOOLEAN", "BOOL":
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number != 0, nil
+		}
+		result, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, errors.Errorf("invalid boolean: %v", text)
+		}
+		return result, nil
+	case "TIMESTAMP":
+		if epoch, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return epochTime(epoch).Format(time.RFC3339Nano), nil
+		}
+		epoch, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return value, nil //already formatted timestamp
+		}
+		return time.Unix(0, int64(epoch*1e9)).UTC().Format(time.RFC3339Nano), nil
+	}
+	return nil, errors.Errorf("unsupported type: %v", fieldType)
+}
+
+//epochTime detects epoch unit (seconds, milliseconds, microseconds or nanoseconds) by magnitude
+func epochTime(epoch int64) time.Time {
+	magnitude := epoch
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	switch {
+	case magnitude < 1e11:
+		return time.Unix(epoch, 0).UTC()
+	case magnitude < 1e14:
+		return time.Unix(0, epoch*int64(time.Millisecond)).UTC()
+	case magnitude < 1e17:
+		return time.Unix(0, epoch*int64(time.Microsecond)).UTC()
+	}
+	return time.Unix(0, epoch).UTC()
+}
+
+func asString(value interface{}) string {
+	switch actual := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return actual
+	case json.Number:
+		return actual.String()
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(actual)
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,220 @@
+package preload
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"io"
+	"strings"
+)
+
+const (
+	jsonFormat = "NEWLINE_DELIMITED_JSON"
+	gzipExt    = ".gz"
+)
+
+//Service represents pre load data file transformation service
+type Service interface {
+	//Transform streams source data file record by record through rule pre load operations into transformed copy
+	Transform(ctx context.Context, request *Request) (*Response, error)
+}
+
+//Request represents transform request
+type Request struct {
+	Rule      *config.Rule
+	DestTable string
+	SourceURL string
+}
+
+//Response represents transform response
+type Response struct {
+	//URL transformed copy URL
+	URL string
+	//Rows source data rows
+	Rows int
+	//Filtered rows skipped by filter operations
+	Filtered int
+}
+
+type service struct {
+	fs afs.Service
+}
+
+//Transform streams source data file record by record through rule pre load operations into transformed copy
+func (s *service) Transform(ctx context.Context, request *Request) (*Response, error) {
+	preLoad := request.Rule.PreLoad
+	transformer, err := newTransformer(preLoad.Operations)
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{URL: TransformedURL(preLoad.URL, request.SourceURL)}
+	reader, err := s.fs.OpenURL(ctx, request.SourceURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %v", request.SourceURL)
+	}
+	defer reader.Close()
+	var source io.Reader = reader
+	compressed := strings.HasSuffix(request.SourceURL, gzipExt)
+	if compressed {
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gzReader.Close()
+		source = gzReader
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := s.fs.Upload(ctx, response.URL, file.DefaultFileOsMode, pipeReader, option.NewSkipChecksum(true))
+		_ = pipeReader.CloseWithError(err)
+		done <- err
+	}()
+	var writer io.Writer = pipeWriter
+	var gzWriter *gzip.Writer
+	if compressed {
+		gzWriter = gzip.NewWriter(pipeWriter)
+		writer = gzWriter
+	}
+	if strings.ToUpper(request.Rule.Dest.SourceFormat) == jsonFormat {
+		err = transformJSON(transformer, bufio.NewReader(source), writer, response)
+	} else {
+		err = transformCSV(transformer, request.Rule.Dest, source, writer, response)
+	}
+	if err == nil && gzWriter != nil {
+		err = gzWriter.Close()
+	}
+	_ = pipeWriter.CloseWithError(err)
+	if uploadErr := <-done; err == nil && uploadErr != nil {
+		err = errors.Wrapf(uploadErr, "failed to upload transformed data file: %v", response.URL)
+	}
+	if err != nil {
+		_ = s.fs.Delete(ctx, response.URL)
+		return nil, errors.Wrapf(err, "failed to transform %v", request.SourceURL)
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] transformed %v rows (filtered: %v): %v\n", request.DestTable, response.Rows, response.Filtered, response.URL)
+	}
+	return response, nil
+}
+
+//transformJSON transforms newline delimited JSON line by line
+func transformJSON(transformer *transformer, reader *bufio.Reader, writer io.Writer, response *Response) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			response.Rows++
+			record := map[string]interface{}{}
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			if err := decoder.Decode(&record); err != nil {
+				return errors.Wrapf(err, "invalid JSON at line %v", number)
+			}
+			ok, err := transformer.Apply(record)
+			if err != nil {
+				return errors.Wrapf(err, "line %v", number)
+			}
+			if !ok {
+				response.Filtered++
+			} else if err = encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//transformCSV transforms CSV records, the last leading row is used as header
+func transformCSV(transformer *transformer, dest *config.Destination, reader io.Reader, writer io.Writer, response *Response) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter(dest.FieldDelimiter)
+	csvReader.FieldsPerRecord = -1
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = csvReader.Comma
+	leadingRows := int(dest.SkipLeadingRows)
+	var header, columns []string
+	for number := 1; ; number++ {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if number < leadingRows {
+			if err = csvWriter.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		if number == leadingRows {
+			header = row
+			columns = transformer.Columns(header)
+			if err = csvWriter.Write(columns); err != nil {
+				return err
+			}
+			continue
+		}
+		response.Rows++
+		record := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i < len(row) {
+				record[column] = row[i]
+			}
+		}
+		ok, err := transformer.Apply(record)
+		if err != nil {
+			return errors.Wrapf(err, "row %v", number)
+		}
+		if !ok {
+			response.Filtered++
+			continue
+		}
+		var values = make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = asString(record[column])
+		}
+		if err = csvWriter.Write(values); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func delimiter(fieldDelimiter string) rune {
+	switch fieldDelimiter {
+	case "", ",":
+		return ','
+	case "\\t", "tab":
+		return '\t'
+	}
+	return []rune(fieldDelimiter)[0]
+}
+
+//TransformedURL returns transformed copy URL
+func TransformedURL(baseURL, sourceURL string) string {
+	return url.Join(baseURL, url.Host(sourceURL), url.Path(sourceURL))
+}
+
+//New creates pre load service
+func New(fs afs.Service) Service {
+	return &service{fs: fs}
+}
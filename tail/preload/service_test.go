@@ -0,0 +1,160 @@
+package preload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestService_Transform(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/preload"
+
+	useCases := []struct {
+		description    string
+		dest           *config.Destination
+		operations     []*config.Operation
+		data           string
+		expect         string
+		expectRows     int
+		expectFiltered int
+		expectError    bool
+	}{
+		{
+			description: "JSON normalize, flatten, cast and filter",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			operations: []*config.Operation{
+				{Op: config.OpNormalize},
+				{Op: config.OpFlatten},
+				{Op: config.OpCast, Fields: map[string]string{"ts": "TIMESTAMP", "user_id": "INTEGER"}},
+				{Op: config.OpFilter, Expr: "status != 'test'"},
+				{Op: config.OpDrop, Keys: []string{"status"}},
+			},
+			data:           "{\"user\":{\"id\":\"12\",\"first-name\":\"Bob\"},\"ts\":\"1600000000123\",\"status\":\"ok\"}\n\n{\"status\":\"test\"}\n",
+			expect:         "{\"ts\":\"2020-09-13T12:26:40.123Z\",\"user_first_name\":\"Bob\",\"user_id\":12}\n",
+			expectRows:     2,
+			expectFiltered: 1,
+		},
+		{
+			description: "JSON rename and map to array",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			operations: []*config.Operation{
+				{Op: config.OpRename, Fields: map[string]string{"attrs.x-y": "attrs.xy"}},
+				{Op: config.OpMapToArray, Keys: []string{"attrs"}, KeyName: "key", ValueName: "value"},
+			},
+			data:       "{\"id\":12345678901234567890,\"attrs\":{\"x-y\":1,\"a\":\"b\"}}",
+			expect:     "{\"attrs\":[{\"key\":\"a\",\"value\":\"b\"},{\"key\":\"xy\",\"value\":1}],\"id\":12345678901234567890}\n",
+			expectRows: 1,
+		},
+		{
+			description: "CSV rename, drop, cast and filter",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SkipLeadingRows: 1}},
+			operations: []*config.Operation{
+				{Op: config.OpNormalize},
+				{Op: config.OpRename, Fields: map[string]string{"_1st": "first"}},
+				{Op: config.OpDrop, Keys: []string{"tmp"}},
+				{Op: config.OpCast, Fields: map[string]string{"active": "BOOLEAN"}},
+				{Op: config.OpFilter, Expr: "amount > 10"},
+			},
+			data:           "1st,amount,tmp,active\na,20,x,1\nb,5,y,0\n\"c,d\",11,z,true\n",
+			expect:         "first,amount,active\na,20,true\n\"c,d\",11,true\n",
+			expectRows:     3,
+			expectFiltered: 1,
+		},
+		{
+			description: "invalid cast",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			operations:  []*config.Operation{{Op: config.OpCast, Fields: map[string]string{"id": "INTEGER"}}},
+			data:        "{\"id\":\"abc\"}\n",
+			expectError: true,
+		},
+	}
+
+	srv := New(fs)
+	for i, useCase := range useCases {
+		sourceURL := baseURL + "/data/case" + string(rune('1'+i)) + ".txt"
+		if !assert.Nil(t, fs.Upload(ctx, sourceURL, 0644, strings.NewReader(useCase.data)), useCase.description) {
+			continue
+		}
+		rule := &config.Rule{Dest: useCase.dest, PreLoad: &config.PreLoad{URL: baseURL + "/transformed", Operations: useCase.operations}}
+		rule.PreLoad.Init()
+		response, err := srv.Transform(ctx, &Request{Rule: rule, SourceURL: sourceURL})
+		if useCase.expectError {
+			assert.NotNil(t, err, useCase.description)
+			continue
+		}
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectRows, response.Rows, useCase.description)
+		assert.EqualValues(t, useCase.expectFiltered, response.Filtered, useCase.description)
+		assert.True(t, rule.PreLoad.IsTransformed(response.URL), useCase.description)
+		data, err := fs.DownloadWithURL(ctx, response.URL)
+		if assert.Nil(t, err, useCase.description) {
+			assert.EqualValues(t, useCase.expect, string(data), useCase.description)
+		}
+	}
+}
+
+//boundedFs emulates gs upload buffering the whole reader to compute checksum unless it is skipped
+type boundedFs struct {
+	afs.Service
+	maxBuffered int
+}
+
+func (f *boundedFs) Upload(ctx context.Context, URL string, mode os.FileMode, reader io.Reader, options ...storage.Option) error {
+	skipChecksum := &option.SkipChecksum{}
+	if _, ok := option.Assign(options, &skipChecksum); ok && skipChecksum.Skip {
+		return f.Service.Upload(ctx, URL, mode, reader, options...)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if len(data) > f.maxBuffered {
+		return fmt.Errorf("buffered %v bytes exceeded %v limit: %v", len(data), f.maxBuffered, URL)
+	}
+	return f.Service.Upload(ctx, URL, mode, bytes.NewReader(data), options...)
+}
+
+func TestService_Transform_Streaming(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/preload/streaming"
+	sourceURL := baseURL + "/data/large.json"
+	data := new(bytes.Buffer)
+	for i := 0; i < 20000; i++ {
+		data.WriteString(fmt.Sprintf("{\"id\":%v,\"name\":\"name %v\"}\n", i, i))
+	}
+	maxBuffered := data.Len() / 10
+	if !assert.Nil(t, fs.Upload(ctx, sourceURL, 0644, bytes.NewReader(data.Bytes()))) {
+		return
+	}
+	rule := &config.Rule{
+		Dest:    &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+		PreLoad: &config.PreLoad{URL: baseURL + "/transformed", Operations: []*config.Operation{{Op: config.OpDrop, Keys: []string{"name"}}}},
+	}
+	rule.PreLoad.Init()
+	srv := New(&boundedFs{Service: fs, maxBuffered: maxBuffered})
+	response, err := srv.Transform(ctx, &Request{Rule: rule, SourceURL: sourceURL})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.EqualValues(t, 20000, response.Rows)
+	transformed, err := fs.DownloadWithURL(ctx, response.URL)
+	if assert.Nil(t, err) {
+		assert.True(t, len(transformed) > maxBuffered)
+	}
+}
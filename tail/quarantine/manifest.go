@@ -0,0 +1,35 @@
+package quarantine
+
+import "time"
+
+//Row represents rejected data row
+type Row struct {
+	//Number data file line number (starting from 1)
+	Number int
+	//Position line starting byte offset
+	Position int64
+	//Reason rejection reason
+	Reason string
+	//Data rejected line
+	Data string
+}
+
+//Manifest represents quarantined data file summary
+type Manifest struct {
+	SourceURL   string
+	CleanURL    string `json:",omitempty"`
+	RejectedURL string
+	DestTable   string
+	EventID     string
+	RuleURL     string `json:",omitempty"`
+	//Rows total data rows count (leading rows excluded)
+	Rows int
+	//Rejected rejected rows count
+	Rejected int
+	Created  time.Time
+}
+
+//Clean returns clean rows count
+func (m *Manifest) Clean() int {
+	return m.Rows - m.Rejected
+}
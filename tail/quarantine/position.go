@@ -0,0 +1,37 @@
+package quarantine
+
+import (
+	"google.golang.org/api/bigquery/v2"
+	"regexp"
+	"strconv"
+)
+
+//positionExpr matches row starting byte offset reported by BigQuery load job errors, i.e.
+// "JSON parsing error in row starting at position 1024: ..." or "... (position 1) starting at location 1024 with message ..."
+var positionExpr = regexp.MustCompile(`(?i)(?:row starting at position|starting at location|line starting at position)\s*:?\s*(\d+)`)
+
+//Positions returns load job error reasons indexed by source URL and row starting byte offset
+func Positions(job *bigquery.Job) map[string]map[int64]string {
+	var result = make(map[string]map[int64]string)
+	if job == nil || job.Status == nil {
+		return result
+	}
+	for _, element := range job.Status.Errors {
+		if element.Location == "" {
+			continue
+		}
+		matched := positionExpr.FindStringSubmatch(element.Message)
+		if len(matched) != 2 {
+			continue
+		}
+		position, err := strconv.ParseInt(matched[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := result[element.Location]; !ok {
+			result[element.Location] = make(map[int64]string)
+		}
+		result[element.Location][position] = element.Message
+	}
+	return result
+}
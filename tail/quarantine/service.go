@@ -0,0 +1,227 @@
+package quarantine
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	cleanFolder    = "clean"
+	rejectedFolder = "rejected"
+	manifestFolder = "manifest"
+	gzipExt        = ".gz"
+)
+
+//Service represents corrupted data file quarantine service
+type Service interface {
+	//Split splits corrupted data file into clean part and rejected rows, it returns nil manifest if data file can not be split
+	Split(ctx context.Context, request *Request) (*Manifest, error)
+}
+
+//Request represents split request
+type Request struct {
+	Rule      *config.Rule
+	EventID   string
+	DestTable string
+	SourceURL string
+	//BaseURL dead letter base location
+	BaseURL string
+	//Errors load job error reasons indexed by row starting byte offset
+	Errors map[int64]string
+}
+
+type service struct {
+	fs afs.Service
+}
+
+//Split splits corrupted data file into clean part and rejected rows, manifest summarising split is written to dead letter location
+func (s *service) Split(ctx context.Context, request *Request) (*Manifest, error) {
+	dest := request.Rule.Dest
+	policy := request.Rule.Quarantine
+	if !isSupported(dest) {
+		return nil, nil
+	}
+	if len(request.Errors) == 0 && !policy.Validate {
+		return nil, nil
+	}
+	seq := fmt.Sprintf("%v", time.Now().UnixNano())
+	URLPath := relativePath(request.BaseURL, request.SourceURL)
+	manifest := &Manifest{
+		SourceURL:   request.SourceURL,
+		CleanURL:    url.Join(request.BaseURL, cleanFolder, request.EventID, seq, URLPath),
+		RejectedURL: url.Join(request.BaseURL, rejectedFolder, request.DestTable, request.EventID, seq, URLPath+shared.JSONExt),
+		DestTable:   request.DestTable,
+		EventID:     request.EventID,
+		RuleURL:     request.Rule.Info.URL,
+	}
+	err := s.split(ctx, request, manifest)
+	if err != nil || manifest.Rejected == 0 || (policy.MaxRejectedRows > 0 && manifest.Rejected > policy.MaxRejectedRows) {
+		_ = s.fs.Delete(ctx, manifest.CleanURL)
+		_ = s.fs.Delete(ctx, manifest.RejectedURL)
+		if err == nil && manifest.Rejected > 0 {
+			shared.LogF("[%v] too many rejected rows: %v, quarantine limit: %v, %v\n", request.DestTable, manifest.Rejected, policy.MaxRejectedRows, request.SourceURL)
+		}
+		return nil, err
+	}
+	if manifest.Clean() == 0 {
+		_ = s.fs.Delete(ctx, manifest.CleanURL)
+		manifest.CleanURL = ""
+	}
+	manifest.Created = time.Now().UTC()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	manifestURL := url.Join(ManifestURL(request.BaseURL, request.Rule), request.EventID+"_"+seq+shared.JSONExt)
+	if err = s.fs.Upload(ctx, manifestURL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return nil, errors.Wrapf(err, "failed to upload quarantine manifest: %v", manifestURL)
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] quarantined %v of %v rows: %v\n", request.DestTable, manifest.Rejected, manifest.Rows, request.SourceURL)
+	}
+	return manifest, nil
+}
+
+//split streams clean rows to clean URL and rejected rows as newline delimited JSON to rejected URL
+func (s *service) split(ctx context.Context, request *Request, manifest *Manifest) error {
+	reader, err := s.fs.OpenURL(ctx, request.SourceURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %v", request.SourceURL)
+	}
+	defer reader.Close()
+	var source io.Reader = reader
+	compressed := strings.HasSuffix(request.SourceURL, gzipExt)
+	if compressed {
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzReader.Close()
+		source = gzReader
+	}
+	clean := s.newUpload(ctx, manifest.CleanURL, "clean rows")
+	rejected := s.newUpload(ctx, manifest.RejectedURL, "rejected rows")
+	var writer io.Writer = clean.pipe
+	var gzWriter *gzip.Writer
+	if compressed {
+		gzWriter = gzip.NewWriter(clean.pipe)
+		writer = gzWriter
+	}
+	err = s.splitRows(request, bufio.NewReader(source), writer, rejected.pipe, manifest)
+	if err == nil && gzWriter != nil {
+		err = gzWriter.Close()
+	}
+	err = clean.close(err)
+	return rejected.close(err)
+}
+
+//upload represents data streamed to storage
+type upload struct {
+	URL         string
+	description string
+	pipe        *io.PipeWriter
+	done        chan error
+}
+
+//close completes upload, it returns err or upload error
+func (u *upload) close(err error) error {
+	_ = u.pipe.CloseWithError(err)
+	if uploadErr := <-u.done; err == nil && uploadErr != nil {
+		err = errors.Wrapf(uploadErr, "failed to upload %v: %v", u.description, u.URL)
+	}
+	return err
+}
+
+func (s *service) newUpload(ctx context.Context, URL, description string) *upload {
+	pipeReader, pipeWriter := io.Pipe()
+	result := &upload{URL: URL, description: description, pipe: pipeWriter, done: make(chan error, 1)}
+	go func() {
+		err := s.fs.Upload(ctx, URL, file.DefaultFileOsMode, pipeReader, option.NewSkipChecksum(true))
+		_ = pipeReader.CloseWithError(err)
+		result.done <- err
+	}()
+	return result
+}
+
+func (s *service) splitRows(request *Request, reader *bufio.Reader, clean io.Writer, rejected io.Writer, manifest *Manifest) error {
+	dest := request.Rule.Dest
+	validate := newValidator(dest)
+	leadingRows := int(dest.SkipLeadingRows)
+	encoder := json.NewEncoder(rejected)
+	position := int64(0)
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			header := number <= leadingRows
+			reason := ""
+			if header {
+				_ = validate(line, header)
+			} else {
+				manifest.Rows++
+				if reason = request.Errors[position]; reason == "" && request.Rule.Quarantine.Validate {
+					if err := validate(line, header); err != nil {
+						reason = err.Error()
+					}
+				}
+			}
+			if reason != "" {
+				manifest.Rejected++
+				row := &Row{Number: number, Position: position, Reason: reason, Data: string(bytes.TrimRight(line, "\r\n"))}
+				if err := encoder.Encode(row); err != nil {
+					return err
+				}
+			} else if _, err := clean.Write(line); err != nil {
+				return err
+			}
+			position += int64(len(line))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//relativePath returns source URL path, for already quarantined clean part the original path is returned
+func relativePath(baseURL, sourceURL string) string {
+	if IsClean(baseURL, sourceURL) {
+		cleanBaseURL := url.Join(baseURL, cleanFolder) + "/"
+		//clean/$eventID/$seq/$path
+		if elements := strings.SplitN(strings.TrimPrefix(sourceURL, cleanBaseURL), "/", 3); len(elements) == 3 {
+			return elements[2]
+		}
+	}
+	_, URLPath := url.Base(sourceURL, "")
+	return URLPath
+}
+
+//IsClean returns true if URL is a clean part of already quarantined data file
+func IsClean(baseURL, URL string) bool {
+	return strings.HasPrefix(URL, url.Join(baseURL, cleanFolder)+"/")
+}
+
+//ManifestURL returns rule manifests location
+func ManifestURL(baseURL string, rule *config.Rule) string {
+	return url.Join(baseURL, manifestFolder, rule.Name())
+}
+
+//New creates quarantine service
+func New(fs afs.Service) Service {
+	return &service{fs: fs}
+}
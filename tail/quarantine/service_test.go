@@ -0,0 +1,176 @@
+package quarantine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestService_Split(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/quarantine"
+
+	useCases := []struct {
+		description   string
+		dest          *config.Destination
+		quarantine    *config.Quarantine
+		data          string
+		jobErrors     []*bigquery.ErrorProto
+		expectSplit   bool
+		expectClean   string
+		expectRows    int
+		expectReject  int
+		expectReasons []string
+	}{
+		{
+			description: "JSON split with load job error positions",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			quarantine:  &config.Quarantine{},
+			data:        "{\"id\":1}\n{\"id\":2\n{\"id\":3}\n",
+			jobErrors: []*bigquery.ErrorProto{
+				{Location: "mem://localhost/data/case1.json", Message: "Error while reading data, error message: JSON parsing error in row starting at position 9: Parser terminated before end of string"},
+			},
+			expectSplit:   true,
+			expectClean:   "{\"id\":1}\n{\"id\":3}\n",
+			expectRows:    3,
+			expectReject:  1,
+			expectReasons: []string{"JSON parsing error"},
+		},
+		{
+			description:   "CSV split with local validation",
+			dest:          &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SkipLeadingRows: 1}},
+			quarantine:    &config.Quarantine{Validate: true},
+			data:          "id,name\n1,abc\n2\n3,xyz\n4,a,b\n",
+			expectSplit:   true,
+			expectClean:   "id,name\n1,abc\n3,xyz\n",
+			expectRows:    4,
+			expectReject:  2,
+			expectReasons: []string{"expected 2 columns, but had 1", "expected 2 columns, but had 3"},
+		},
+		{
+			description: "too many rejected rows",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			quarantine:  &config.Quarantine{Validate: true, MaxRejectedRows: 1},
+			data:        "{\"id\":1}\n[1]\nabc\n",
+		},
+		{
+			description: "no rejected rows",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "NEWLINE_DELIMITED_JSON"}},
+			quarantine:  &config.Quarantine{Validate: true},
+			data:        "{\"id\":1}\n{\"id\":2}\n",
+		},
+		{
+			description: "unsupported format",
+			dest:        &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SourceFormat: "AVRO"}},
+			quarantine:  &config.Quarantine{Validate: true},
+			data:        "abc",
+		},
+	}
+
+	for i, useCase := range useCases {
+		sourceURL := "mem://localhost/data/case" + string(rune('1'+i)) + ".json"
+		if !assert.Nil(t, fs.Upload(ctx, sourceURL, 0644, strings.NewReader(useCase.data)), useCase.description) {
+			continue
+		}
+		rule := &config.Rule{Dest: useCase.dest, Quarantine: useCase.quarantine}
+		rule.Info.URL = "mem://localhost/rules/rule.json"
+		srv := New(fs)
+		manifest, err := srv.Split(ctx, &Request{
+			Rule:      rule,
+			EventID:   "e1",
+			DestTable: "proj:ds.table",
+			SourceURL: sourceURL,
+			BaseURL:   baseURL,
+			Errors:    Positions(&bigquery.Job{Status: &bigquery.JobStatus{Errors: useCase.jobErrors}})[sourceURL],
+		})
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		if !useCase.expectSplit {
+			assert.Nil(t, manifest, useCase.description)
+			continue
+		}
+		if !assert.NotNil(t, manifest, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectRows, manifest.Rows, useCase.description)
+		assert.EqualValues(t, useCase.expectReject, manifest.Rejected, useCase.description)
+		clean, err := fs.DownloadWithURL(ctx, manifest.CleanURL)
+		assert.Nil(t, err, useCase.description)
+		assert.EqualValues(t, useCase.expectClean, string(clean), useCase.description)
+		rejected, err := fs.DownloadWithURL(ctx, manifest.RejectedURL)
+		assert.Nil(t, err, useCase.description)
+		for _, reason := range useCase.expectReasons {
+			assert.Contains(t, string(rejected), reason, useCase.description)
+		}
+		manifests, err := fs.List(ctx, ManifestURL(baseURL, rule))
+		assert.Nil(t, err, useCase.description)
+		assert.True(t, len(manifests) > 1, useCase.description)
+	}
+}
+
+func TestService_Split_Streaming(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	baseURL := "mem://localhost/quarantine/streaming"
+	sourceURL := "mem://localhost/data/streaming/large.csv"
+	data := new(bytes.Buffer)
+	data.WriteString("id,name\n")
+	for i := 0; i < 10000; i++ {
+		if i%2 == 0 {
+			data.WriteString(fmt.Sprintf("%v,name %v\n", i, i))
+			continue
+		}
+		data.WriteString(fmt.Sprintf("%v\n", i))
+	}
+	if !assert.Nil(t, fs.Upload(ctx, sourceURL, 0644, bytes.NewReader(data.Bytes()))) {
+		return
+	}
+	rule := &config.Rule{Dest: &config.Destination{JobConfigurationLoad: bigquery.JobConfigurationLoad{SkipLeadingRows: 1}}, Quarantine: &config.Quarantine{Validate: true}}
+	rule.Info.URL = "mem://localhost/rules/streaming.json"
+	maxBuffered := data.Len() / 10
+	srv := New(&boundedFs{Service: fs, maxBuffered: maxBuffered})
+	manifest, err := srv.Split(ctx, &Request{Rule: rule, EventID: "e2", DestTable: "proj:ds.table", SourceURL: sourceURL, BaseURL: baseURL})
+	if !assert.Nil(t, err) || !assert.NotNil(t, manifest) {
+		return
+	}
+	assert.EqualValues(t, 10000, manifest.Rows)
+	assert.EqualValues(t, 5000, manifest.Rejected)
+	rejected, err := fs.DownloadWithURL(ctx, manifest.RejectedURL)
+	if assert.Nil(t, err) {
+		assert.True(t, len(rejected) > maxBuffered)
+	}
+}
+
+//boundedFs emulates gs upload buffering the whole reader to compute checksum unless it is skipped
+type boundedFs struct {
+	afs.Service
+	maxBuffered int
+}
+
+func (f *boundedFs) Upload(ctx context.Context, URL string, mode os.FileMode, reader io.Reader, options ...storage.Option) error {
+	skipChecksum := &option.SkipChecksum{}
+	if _, ok := option.Assign(options, &skipChecksum); ok && skipChecksum.Skip {
+		return f.Service.Upload(ctx, URL, mode, reader, options...)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if len(data) > f.maxBuffered {
+		return fmt.Errorf("buffered %v bytes exceeded %v limit: %v", len(data), f.maxBuffered, URL)
+	}
+	return f.Service.Upload(ctx, URL, mode, bytes.NewReader(data), options...)
+}
@@ -0,0 +1,84 @@
+package quarantine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/viant/bqtail/tail/config"
+	"strings"
+)
+
+const (
+	jsonFormat = "NEWLINE_DELIMITED_JSON"
+	csvFormat  = "CSV"
+)
+
+//validator validates data line, header flags CSV leading row
+type validator func(line []byte, header bool) error
+
+//isSupported returns true if data file can be split line by line
+func isSupported(dest *config.Destination) bool {
+	switch strings.ToUpper(dest.SourceFormat) {
+	case jsonFormat:
+		return true
+	case "", csvFormat:
+		return !dest.AllowQuotedNewlines
+	}
+	return false
+}
+
+func newValidator(dest *config.Destination) validator {
+	if strings.ToUpper(dest.SourceFormat) == jsonFormat {
+		return validateJSON
+	}
+	return newCSVValidator(dest)
+}
+
+func validateJSON(line []byte, header bool) error {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+	if line[0] != '{' || !json.Valid(line) {
+		return fmt.Errorf("invalid JSON object")
+	}
+	return nil
+}
+
+func newCSVValidator(dest *config.Destination) validator {
+	columns := 0
+	comma := ','
+	switch dest.FieldDelimiter {
+	case "", ",":
+	case "\\t", "tab":
+		comma = '\t'
+	default:
+		comma = []rune(dest.FieldDelimiter)[0]
+	}
+	return func(line []byte, header bool) error {
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			return nil
+		}
+		reader := csv.NewReader(bytes.NewReader(line))
+		reader.Comma = comma
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = dest.Quote != nil && *dest.Quote == ""
+		record, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("invalid CSV row: %v", err)
+		}
+		if columns == 0 {
+			columns = len(record)
+			return nil
+		}
+		if header {
+			return nil
+		}
+		if len(record) != columns {
+			return fmt.Errorf("expected %v columns, but had %v", columns, len(record))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package quota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"google.golang.org/api/bigquery/v2"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Job types counted against table quota
+const (
+	JobTypeLoad  = "load"
+	JobTypeCopy  = "copy"
+	JobTypeQuery = "query"
+	maxRetries   = 5
+)
+
+//Service represents daily destination table jobs quota tracking service
+type Service interface {
+	//Add increments the current day table jobs count
+	Add(ctx context.Context, table string, jobType string) (*Usage, error)
+	//Get returns the current day table usage, or empty usage
+	Get(ctx context.Context, table string) (*Usage, error)
+	//List returns the current day tables usage
+	List(ctx context.Context) ([]*Usage, error)
+}
+
+type service struct {
+	baseURL string
+	fs      afs.Service
+}
+
+//Add increments the current day table jobs count with generation precondition, conflicting update is retried
+func (s *service) Add(ctx context.Context, table string, jobType string) (*Usage, error) {
+	URL := s.usageURL(table, time.Now())
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		usage, generation, loadErr := s.load(ctx, URL)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		if usage == nil {
+			usage = newUsage(table, time.Now())
+		}
+		usage.Add(jobType)
+		usage.Updated = time.Now().UTC()
+		data, marshalErr := json.Marshal(usage)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		if err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data), option.NewGeneration(true, generation)); err == nil {
+			return usage, nil
+		}
+		if !base.IsPreConditionError(err) {
+			break
+		}
+	}
+	return nil, errors.Wrapf(err, "failed to update table quota usage: %v", URL)
+}
+
+//Get returns the current day table usage, or empty usage
+func (s *service) Get(ctx context.Context, table string) (*Usage, error) {
+	usage, _, err := s.load(ctx, s.usageURL(table, time.Now()))
+	if usage == nil && err == nil {
+		usage = newUsage(table, time.Now())
+	}
+	return usage, err
+}
+
+//List returns the current day tables usage
+func (s *service) List(ctx context.Context) ([]*Usage, error) {
+	baseURL := url.Join(s.baseURL, time.Now().UTC().Format(dateLayout))
+	if ok, _ := s.fs.Exists(ctx, baseURL); !ok {
+		return nil, nil
+	}
+	objects, err := s.fs.List(ctx, baseURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list table quota usage: %v", baseURL)
+	}
+	var result = make([]*Usage, 0)
+	for _, object := range objects {
+		if object.IsDir() || path.Ext(object.Name()) != shared.JSONExt {
+			continue
+		}
+		usage, _, err := s.load(ctx, object.URL())
+		if err != nil {
+			return nil, err
+		}
+		if usage != nil {
+			result = append(result, usage)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result, nil
+}
+
+func (s *service) load(ctx context.Context, URL string) (*Usage, int64, error) {
+	exists, err := s.fs.Exists(ctx, URL, option.NewObjectKind(true))
+	if err != nil || !exists {
+		return nil, 0, err
+	}
+	generation := &option.Generation{}
+	data, err := s.fs.DownloadWithURL(ctx, URL, generation)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to load table quota usage: %v", URL)
+	}
+	usage := &Usage{}
+	if err = json.Unmarshal(data, usage); err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to unmarshal table quota usage: %v", URL)
+	}
+	return usage, generation.Generation, nil
+}
+
+//usageURL returns daily table usage URL: baseURL/$date/$dataset.$table.json
+func (s *service) usageURL(table string, date time.Time) string {
+	return url.Join(s.baseURL, date.UTC().Format(dateLayout), table+shared.JSONExt)
+}
+
+//TableKey returns quota table key (dataset.table), project and partition decorator are ignored
+func TableKey(table *bigquery.TableReference) string {
+	return table.DatasetId + "." + base.TableID(table.TableId)
+}
+
+//JobTable returns destination table and job type counted against table quota, temp or anonymous query results tables are excluded
+func JobTable(job *bigquery.Job, tempTable string) (*bigquery.TableReference, string) {
+	if job == nil || job.Configuration == nil {
+		return nil, ""
+	}
+	var table *bigquery.TableReference
+	jobType := ""
+	switch {
+	case job.Configuration.Load != nil:
+		table, jobType = job.Configuration.Load.DestinationTable, JobTypeLoad
+	case job.Configuration.Copy != nil:
+		table, jobType = job.Configuration.Copy.DestinationTable, JobTypeCopy
+	case job.Configuration.Query != nil:
+		table, jobType = job.Configuration.Query.DestinationTable, JobTypeQuery
+	}
+	if table == nil || strings.HasPrefix(table.DatasetId, "_") {
+		return nil, ""
+	}
+	if tempTable != "" {
+		if tempRef, err := base.NewTableReference(tempTable); err == nil && TableKey(tempRef) == TableKey(table) {
+			return nil, ""
+		}
+	}
+	return table, jobType
+}
+
+//New creates afs storage based table quota service
+func New(baseURL string, fs afs.Service) Service {
+	return &service{baseURL: baseURL, fs: fs}
+}
@@ -0,0 +1,85 @@
+package quota
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"google.golang.org/api/bigquery/v2"
+	"testing"
+	"time"
+)
+
+func TestService_Add(t *testing.T) {
+	ctx := context.Background()
+	srv := New("mem://localhost/quota", afs.New())
+
+	useCases := []struct {
+		description string
+		job         *bigquery.Job
+		tempTable   string
+		expectTable string
+		expectJobs  int
+	}{
+		{
+			description: "load job with partition decorator",
+			job:         &bigquery.Job{Configuration: &bigquery.JobConfiguration{Load: &bigquery.JobConfigurationLoad{DestinationTable: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events$20200101"}}}},
+			expectTable: "ds.events",
+			expectJobs:  1,
+		},
+		{
+			description: "copy job",
+			job:         &bigquery.Job{Configuration: &bigquery.JobConfiguration{Copy: &bigquery.JobConfigurationTableCopy{DestinationTable: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events"}}}},
+			expectTable: "ds.events",
+			expectJobs:  2,
+		},
+		{
+			description: "temp table load excluded",
+			job:         &bigquery.Job{Configuration: &bigquery.JobConfiguration{Load: &bigquery.JobConfigurationLoad{DestinationTable: &bigquery.TableReference{ProjectId: "p", DatasetId: "temp", TableId: "events_123"}}}},
+			tempTable:   "p:temp.events_123",
+		},
+		{
+			description: "anonymous query results excluded",
+			job:         &bigquery.Job{Configuration: &bigquery.JobConfiguration{Query: &bigquery.JobConfigurationQuery{DestinationTable: &bigquery.TableReference{ProjectId: "p", DatasetId: "_abc", TableId: "anon"}}}},
+		},
+	}
+
+	for _, useCase := range useCases {
+		table, jobType := JobTable(useCase.job, useCase.tempTable)
+		if useCase.expectTable == "" {
+			assert.Nil(t, table, useCase.description)
+			continue
+		}
+		if !assert.NotNil(t, table, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectTable, TableKey(table), useCase.description)
+		_, err := srv.Add(ctx, TableKey(table), jobType)
+		assert.Nil(t, err, useCase.description)
+		usage, err := srv.Get(ctx, useCase.expectTable)
+		assert.Nil(t, err, useCase.description)
+		assert.EqualValues(t, useCase.expectJobs, usage.Jobs, useCase.description)
+	}
+	usages, err := srv.List(ctx)
+	assert.Nil(t, err)
+	if assert.EqualValues(t, 1, len(usages)) {
+		assert.EqualValues(t, 1, usages[0].LoadJobs)
+		assert.EqualValues(t, 1, usages[0].CopyJobs)
+	}
+}
+
+func TestUsage_WindowLevel(t *testing.T) {
+	noon := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	useCases := []struct {
+		description string
+		jobs        int
+		expect      int
+	}{
+		{description: "below threshold", jobs: 1000, expect: 0},
+		{description: "remaining jobs last till midnight", jobs: 1200, expect: 1},
+		{description: "quota exhausted", jobs: 1500, expect: 10},
+	}
+	for _, useCase := range useCases {
+		usage := &Usage{Jobs: useCase.jobs}
+		assert.EqualValues(t, useCase.expect, usage.WindowLevel(1500, 0.8, 1, 95, noon), useCase.description)
+	}
+}
@@ -0,0 +1,66 @@
+package quota
+
+import (
+	"github.com/viant/bqtail/tail/config"
+	"math"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+//Usage represents daily destination table jobs count
+type Usage struct {
+	Table     string
+	Date      string
+	Jobs      int
+	LoadJobs  int `json:",omitempty"`
+	CopyJobs  int `json:",omitempty"`
+	QueryJobs int `json:",omitempty"`
+	Updated   time.Time
+}
+
+//Add increments job count for supplied job type
+func (u *Usage) Add(jobType string) {
+	u.Jobs++
+	switch jobType {
+	case JobTypeLoad:
+		u.LoadJobs++
+	case JobTypeCopy:
+		u.CopyJobs++
+	case JobTypeQuery:
+		u.QueryJobs++
+	}
+}
+
+//WindowLevel returns batch window widening level (window duration is multiplied by 2^level),
+//once threshold is reached the window is widened so that remaining jobs quota lasts till the end of the day (UTC)
+func (u *Usage) WindowLevel(maxJobs int, threshold float64, jobsPerWindow int, durationInSec int, now time.Time) int {
+	if u == nil || maxJobs <= 0 || durationInSec <= 0 || float64(u.Jobs) < threshold*float64(maxJobs) {
+		return 0
+	}
+	maxLevel := 0
+	for durationInSec<<(maxLevel+1) <= config.MaxWindowDurationSec {
+		maxLevel++
+	}
+	remaining := maxJobs - u.Jobs
+	if remaining <= 0 {
+		return maxLevel + 1
+	}
+	if jobsPerWindow < 1 {
+		jobsPerWindow = 1
+	}
+	midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	required := midnight.Sub(now).Seconds() * float64(jobsPerWindow) / float64(remaining)
+	level := 0
+	if required > float64(durationInSec) {
+		level = int(math.Ceil(math.Log2(required / float64(durationInSec))))
+	}
+	if level > maxLevel {
+		return maxLevel + 1
+	}
+	return level
+}
+
+func newUsage(table string, date time.Time) *Usage {
+	return &Usage{Table: table, Date: date.UTC().Format(dateLayout)}
+}
@@ -30,10 +30,20 @@ import (
 	"github.com/viant/bqtail/stage/activity"
 	"github.com/viant/bqtail/stage/load"
 	"github.com/viant/bqtail/tail/batch"
+	"github.com/viant/bqtail/tail/chunk"
 	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/tail/config/transient"
 	"github.com/viant/bqtail/tail/contract"
+	"github.com/viant/bqtail/tail/cost"
+	"github.com/viant/bqtail/tail/evolution"
+	"github.com/viant/bqtail/tail/ledger"
+	"github.com/viant/bqtail/tail/preload"
+	"github.com/viant/bqtail/tail/quarantine"
+	"github.com/viant/bqtail/tail/quota"
+	"github.com/viant/bqtail/tail/staging"
 	"github.com/viant/bqtail/tail/status"
 	"github.com/viant/bqtail/task"
+	"github.com/viant/toolbox/data"
 	"google.golang.org/api/bigquery/v2"
 	goption "google.golang.org/api/option"
 	"strings"
@@ -48,11 +58,20 @@ type Service interface {
 
 type service struct {
 	task.Registry
-	bq     bq.Service
-	batch  batch.Service
-	fs     afs.Service
-	cfs    afs.Service
-	config *Config
+	bq         bq.Service
+	bigQuery   *bigquery.Service
+	batch      batch.Service
+	ledger     ledger.Ledger
+	evolution  evolution.Service
+	quarantine quarantine.Service
+	stager     staging.Service
+	preLoader  preload.Service
+	chunker    chunk.Service
+	cost       cost.Service
+	quota      quota.Service
+	fs         afs.Service
+	cfs        afs.Service
+	config     *Config
 }
 
 func (s *service) Init(ctx context.Context) error {
@@ -75,17 +94,32 @@ func (s *service) Init(ctx context.Context) error {
 		shared.LogF("failed to create pubsub service: %v", err)
 	}
 
-	bqService, err := bigquery.NewService(ctx, options...)
+	bqService := s.bigQuery
+	if bqService == nil {
+		if bqService, err = bigquery.NewService(ctx, options...); err != nil {
+			return err
+		}
+	}
+	s.bq = bq.New(bqService, s.Registry, s.config.ProjectID, s.fs, s.config.Config)
+	s.bq.SetJobListener(s.logJobUsage)
+	locker, err := batch.NewWindowLocker(s.config.WindowLocker, time.Duration(s.config.WindowLeaseInSec)*time.Second, s.fs)
 	if err != nil {
 		return err
 	}
-	s.bq = bq.New(bqService, s.Registry, s.config.ProjectID, s.fs, s.config.Config)
-	s.batch = batch.New(s.config.TaskURL, s.fs)
+	s.batch = batch.New(s.config.TaskURL, s.fs, locker)
+	s.ledger = ledger.New(s.config.LedgerURL, s.fs)
+	s.evolution = evolution.New(s.bq, s.fs, s.config.SchemaAuditURL)
+	s.quarantine = quarantine.New(s.fs)
+	s.stager = staging.New(s.fs, s.config.StagingJournalURL)
+	s.preLoader = preload.New(s.fs)
+	s.chunker = chunk.New(s.fs)
+	s.cost = cost.New(s.config.CostURL, s.fs)
+	s.quota = quota.New(s.config.TableQuotaURL, s.fs)
 	bq.InitRegistry(s.Registry, s.bq)
 	http.InitRegistry(s.Registry, http.New())
 	sbatch.InitRegistry(s.Registry, sbatch.New(s.fs, s.Registry))
 	storage.InitRegistry(s.Registry, storage.New(s.fs))
-	return err
+	return nil
 }
 
 func (s *service) Tail(ctx context.Context, request *contract.Request) *contract.Response {
@@ -112,7 +146,7 @@ func (s *service) Tail(ctx context.Context, request *contract.Request) *contract
 	return response
 }
 
-func (s *service) tail(ctx context.Context, request *contract.Request, response *contract.Response) error {
+func (s *service) tail(ctx context.Context, request *contract.Request, response *contract.Response) (err error) {
 	response.Retriable = true
 	if err := s.config.ReloadIfNeeded(ctx, s.cfs); err != nil {
 		return err
@@ -121,18 +155,59 @@ func (s *service) tail(ctx context.Context, request *contract.Request, response
 	if rule == nil {
 		return nil
 	}
+	if rule.PreLoad != nil && rule.PreLoad.IsTransformed(request.SourceURL) {
+		response.Status = shared.StatusNoMatch
+		response.Retriable = false
+		return nil
+	}
+	if paused, err := s.pauseIfOverBudget(ctx, rule, request, response); paused || err != nil {
+		return err
+	}
+	isStaged := rule.Staging != nil && rule.Staging.IsRequired(request.SourceURL)
+	if isStaged {
+		if err := staging.IsSupported(request.SourceURL); err != nil {
+			response.Retriable = false
+			return err
+		}
+	}
 	source, err := s.fs.Object(ctx, request.SourceURL, option.NewObjectKind(true))
 	if err != nil {
 		response.NotFoundError = err.Error()
 		response.Status = shared.StatusNotFound
 		return nil
 	}
+	if rule.UsesLedger() {
+		var entry *ledger.Entry
+		if entry, err = s.registerIngestion(ctx, source, rule, request, response); err != nil {
+			return err
+		}
+		if response.Duplicate != nil && !rule.Dedupe.FlagOnly {
+			response.Retriable = false
+			return nil
+		}
+		if entry != nil {
+			defer func() {
+				s.completeIngestion(ctx, entry, response, err)
+			}()
+		}
+	}
+	if isStaged {
+		if source, err = s.stage(ctx, source, rule, request, response); err != nil {
+			return err
+		}
+	}
+	if rule.PreLoad != nil {
+		if source, err = s.preLoad(ctx, source, rule, request, response); err != nil {
+			return err
+		}
+	}
 	process, err := s.newProcess(ctx, source, rule, request, response)
 	if err != nil {
 		return err
 	}
 	var job *load.Job
 	if rule.Batch != nil {
+		rule = s.throttle(ctx, process, rule, response)
 		job, err = s.tailInBatch(ctx, process, rule, response)
 	} else {
 		_, err = s.tailIndividually(ctx, process, rule, response)
@@ -144,6 +219,94 @@ func (s *service) tail(ctx context.Context, request *contract.Request, response
 	return s.tryRecover(ctx, job, response)
 }
 
+//stage copies external source object to Google Storage staging location, BigQuery loads and post actions use the staged copy
+func (s *service) stage(ctx context.Context, source astorage.Object, rule *config.Rule, request *contract.Request, response *contract.Response) (astorage.Object, error) {
+	staged, err := s.stager.Stage(ctx, &staging.Request{EventID: request.EventID, Source: source, Staging: rule.Staging})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stage %v", source.URL())
+	}
+	response.StagedURL = staged.URL()
+	return staged, nil
+}
+
+//preLoad transforms source data file record by record, BigQuery loads and post actions use the transformed copy
+func (s *service) preLoad(ctx context.Context, source astorage.Object, rule *config.Rule, request *contract.Request, response *contract.Response) (astorage.Object, error) {
+	transformed, err := s.preLoader.Transform(ctx, &preload.Request{Rule: rule, DestTable: rule.DestTable(source.URL(), source.ModTime()), SourceURL: source.URL()})
+	if err != nil {
+		return nil, err
+	}
+	response.TransformedURL = transformed.URL
+	response.FilteredRows = transformed.Filtered
+	if response.StagedURL != "" && !rule.Staging.KeepStaged {
+		if err = s.fs.Delete(ctx, response.StagedURL); err != nil {
+			response.UploadError = err.Error()
+		}
+	}
+	return s.fs.Object(ctx, transformed.URL, option.NewObjectKind(true))
+}
+
+//registerIngestion registers source object with the ingestion ledger, if the object has been already ingested response is flagged as duplicate
+func (s *service) registerIngestion(ctx context.Context, source astorage.Object, rule *config.Rule, request *contract.Request, response *contract.Response) (*ledger.Entry, error) {
+	entry := ledger.NewEntry(source, rule.DestTable(source.URL(), source.ModTime()), request.EventID)
+	registered, err := s.ledger.Register(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if registered == nil {
+		return entry, nil
+	}
+	response.Status = shared.StatusDuplicate
+	response.Duplicate = registered
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] duplicate: %v, already ingested by event: %v\n", registered.DestTable, registered.URL, registered.EventID)
+	}
+	return nil, nil
+}
+
+//completeIngestion updates ledger entry with load job ID or releases it if ingestion failed or the source object was excluded as corrupted or with invalid schema,
+//so redelivered event can ingest the source object
+func (s *service) completeIngestion(ctx context.Context, entry *ledger.Entry, response *contract.Response, err error) {
+	if err != nil || response.Error != "" || isExcluded(entry.URL, response) {
+		if e := s.ledger.Release(ctx, entry); e != nil {
+			response.UploadError = e.Error()
+		}
+		return
+	}
+	if response.JobRef == nil {
+		return
+	}
+	entry.JobID = response.JobRef.JobId
+	if e := s.ledger.Update(ctx, entry); e != nil {
+		response.UploadError = e.Error()
+	}
+}
+
+//releaseIngestion releases ledger entries of source files that have not been loaded by a failed or recovered load job,
+//in async and batch mode the ingesting event has already completed, thus redelivered or replayed event would be skipped as duplicate otherwise
+func (s *service) releaseIngestion(ctx context.Context, rule *config.Rule, destTable string, URLs []string, response *contract.Response) {
+	if rule == nil || !rule.UsesLedger() || len(URLs) == 0 {
+		return
+	}
+	if err := s.ledger.ReleaseURLs(ctx, destTable, URLs); err != nil {
+		response.UploadError = err.Error()
+	}
+}
+
+//isExcluded returns true if source URL has been moved to corrupted or invalid schema location
+func isExcluded(URL string, response *contract.Response) bool {
+	for _, candidate := range response.Corrupted {
+		if candidate == URL {
+			return true
+		}
+	}
+	for _, candidate := range response.InvalidSchema {
+		if candidate == URL {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *service) OnDone(ctx context.Context, request *contract.Request, response *contract.Response) {
 	response.ListOpCount = gs.GetListCounter(true)
 	response.StorageRetries = gs.GetRetryCodes(true)
@@ -252,6 +415,8 @@ func (s *service) canRetryEvent(ctx context.Context, errorCounterURL string, res
 
 func (s *service) newProcess(ctx context.Context, source astorage.Object, rule *config.Rule, request *contract.Request, response *contract.Response) (*stage.Process, error) {
 	result := stage.NewProcess(request.EventID, stage.NewSource(source.URL(), source.ModTime()), rule.Info.URL, rule.Async)
+	result.Source.Size = source.Size()
+	result.Priority = rule.Priority
 	var err error
 	if result.DestTable, err = rule.Dest.ExpandTable(rule.Dest.Table, result.Source); err != nil {
 		return nil, errors.Wrapf(err, "failed to expand table :%v", rule.Dest.Table)
@@ -259,13 +424,65 @@ func (s *service) newProcess(ctx context.Context, source astorage.Object, rule *
 	result.ProcessURL = s.config.BuildLoadURL(result)
 	result.DoneProcessURL = s.config.DoneLoadURL(result)
 	result.FailedURL = url.Join(s.config.JournalURL, "failed")
-	result.ProjectID = s.selectProjectID(ctx, rule, response)
-	result.Params, err = rule.Dest.Params(result.Source.URL)
+	result.ProjectID = s.selectProjectID(ctx, rule, result.DestTable, response)
+	if result.Params, err = rule.Dest.Params(result.Source.URL); err != nil {
+		return nil, err
+	}
+	if chunk.IsOversized(source.URL(), source.Size()) && chunk.IsSupported(rule.Dest) {
+		manifest, err := s.split(ctx, source, rule, result.DestTable)
+		if err != nil {
+			return nil, err
+		}
+		result.Source.Chunks = manifest.Chunks
+		response.Chunks = manifest.Chunks
+	}
 	if shared.IsDebugLoggingLevel() {
 		shared.LogF("process: ")
 		shared.LogLn(result)
 	}
-	return result, err
+	return result, nil
+}
+
+//split splits gzip data file exceeding BigQuery compressed file limit into line aligned uncompressed chunks
+func (s *service) split(ctx context.Context, source astorage.Object, rule *config.Rule, destTable string) (*chunk.Manifest, error) {
+	return s.chunker.Split(ctx, &chunk.Request{
+		Rule:      rule,
+		DestTable: destTable,
+		Source:    source,
+		BaseURL:   s.config.ChunkURL,
+		ChunkSize: int64(s.config.ChunkSizeMb) * 1024 * 1024,
+	})
+}
+
+//useChunks replaces oversized compressed data files with their chunks in the load job
+func (s *service) useChunks(ctx context.Context, job *load.Job) error {
+	if !chunk.IsSupported(job.Rule.Dest) {
+		return nil
+	}
+	var chunks = make(map[string][]string)
+	if job.Window == nil {
+		if len(job.Source.Chunks) > 0 {
+			chunks[job.Source.URL] = job.Source.Chunks
+		}
+		job.UseChunks(chunks)
+		return nil
+	}
+	for _, resource := range job.Window.Resources {
+		if !chunk.IsOversized(resource.URL, resource.Size) {
+			continue
+		}
+		source, err := s.fs.Object(ctx, resource.URL, option.NewObjectKind(true))
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %v", resource.URL)
+		}
+		manifest, err := s.split(ctx, source, job.Rule, job.DestTable)
+		if err != nil {
+			return err
+		}
+		chunks[resource.URL] = manifest.Chunks
+	}
+	job.UseChunks(chunks)
+	return nil
 }
 
 func (s *service) submitJob(ctx context.Context, job *load.Job, response *contract.Response) (*load.Job, error) {
@@ -323,6 +540,13 @@ func (s *service) tailIndividually(ctx context.Context, process *stage.Process,
 	if err != nil {
 		return nil, err
 	}
+	if err = s.useChunks(ctx, job); err != nil {
+		return nil, err
+	}
+	s.downgradeIfOverBudget(ctx, job, response)
+	if err = s.evolveSchema(ctx, job, response); err != nil || len(job.Load.SourceUris) == 0 {
+		return nil, err
+	}
 	err = job.Init(ctx, s.bq)
 	if err != nil {
 		return nil, err
@@ -330,18 +554,33 @@ func (s *service) tailIndividually(ctx context.Context, process *stage.Process,
 	return s.submitJob(ctx, job, response)
 }
 
-func (s *service) selectProjectID(ctx context.Context, rule *config.Rule, response *contract.Response) string {
+func (s *service) selectProjectID(ctx context.Context, rule *config.Rule, destTable string, response *contract.Response) string {
 	projectID := s.config.ProjectID
 	if rule.Dest.Transient != nil {
 		projectPerformance, err := LoadProjectPerformance(ctx, s.fs, &s.config.Config)
 		if err != nil {
 			response.DownloadError = err.Error()
 		}
-		projectID = rule.Dest.Transient.JobProjectID(projectPerformance)
+		selection := &transient.Selection{Performance: projectPerformance, DestTable: destTable}
+		if balancer := rule.Dest.Transient.Balancer; balancer != nil && balancer.Strategy == shared.BalancerStrategyRoundRobin {
+			selection.Sequence = s.nextBalancerSequence(ctx, rule, response)
+		}
+		projectID = rule.Dest.Transient.JobProjectID(selection)
 	}
 	return projectID
 }
 
+//nextBalancerSequence increments rule round robin balancer sequence persisted in the journal
+func (s *service) nextBalancerSequence(ctx context.Context, rule *config.Rule, response *contract.Response) int {
+	sequenceURL := url.Join(s.config.BalancerURL, cost.RuleKey(rule.Info.URL)+shared.JSONExt)
+	counter := sync.NewCounter(sequenceURL, s.fs)
+	sequence, err := counter.Increment(ctx)
+	if err != nil {
+		response.CounterError = err.Error()
+	}
+	return sequence
+}
+
 func (s *service) tailInBatch(ctx context.Context, process *stage.Process, rule *config.Rule, response *contract.Response) (*load.Job, error) {
 	response.Batched = true
 	batchWindow, err := s.batch.TryAcquireWindow(ctx, process, rule)
@@ -354,6 +593,13 @@ func (s *service) tailInBatch(ctx context.Context, process *stage.Process, rule
 		response.BatchingEventID = batchWindow.OwnerEventID
 		response.WindowURL = batchWindow.WindowURL
 	}
+	if rule.Async {
+		for _, sealedURL := range batchWindow.SealedWindowURLs {
+			if err = s.scheduleSealedWindow(ctx, sealedURL); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if batchWindow.Window == nil {
 		return nil, nil
 	}
@@ -393,6 +639,10 @@ func (s *service) runPostLoadActions(ctx context.Context, request *contract.Requ
 		response.Retriable = base.IsRetryError(err)
 		return errors.Wrapf(err, "failed to fetch aJob %v,", action.Job.JobReference.JobId)
 	}
+	if err := s.logJobUsage(ctx, bqJob, action); err != nil {
+		response.UsageError = err.Error()
+		shared.LogF("failed to log job usage: %v, %v\n", bqJob.JobReference.JobId, err)
+	}
 	if err := s.logJobInfo(ctx, bqJob, action); err != nil {
 		response.UploadError = fmt.Sprintf("failed to log aJob info: %v", err.Error())
 	}
@@ -404,10 +654,12 @@ func (s *service) runPostLoadActions(ctx context.Context, request *contract.Requ
 		rule := s.config.Rule(ctx, action.Meta.RuleURL)
 		processJob, err := load.NewJobFromURL(ctx, rule, action.Meta.Process.ProcessURL, s.fs)
 		if err != nil {
+			s.releaseIngestion(ctx, rule, action.Meta.DestTable, bqJob.Configuration.Load.SourceUris, response)
 			return bqJobError
 		}
 		processJob.BqJob = bqJob
 		if !processJob.Recoverable() {
+			s.releaseIngestion(ctx, rule, processJob.DestTable, bqJob.Configuration.Load.SourceUris, response)
 			return err
 		}
 		return s.tryRecover(ctx, processJob, response)
@@ -479,17 +731,49 @@ func (s *service) runInBatch(ctx context.Context, rule *config.Rule, window *bat
 	if remainingDuration < 0 && window.IsSyncMode() { //intendent for client sync mode
 		remainingDuration = time.Duration(shared.StorageListVisibilityDelayMs) * time.Millisecond
 	}
+	if remainingDuration > 0 && s.batch.IsSealed(ctx, window) {
+		remainingDuration = time.Duration(shared.StorageListVisibilityDelayMs) * time.Millisecond
+	}
 	if shared.IsInfoLoggingLevel() {
 		shared.LogF("[%v] starting batch window: %s\n", window.DestTable, rule.Batch.Window.Duration)
 	}
 	if remainingDuration > 0 {
-		time.Sleep(remainingDuration)
+		s.batch.Wait(ctx, rule, window, remainingDuration)
 	}
 	err := s.batch.MatchWindowDataURLs(ctx, rule, window)
 	if err != nil || len(window.URIs) == 0 {
 		return nil, err
 	}
 	_ = s.logBatchInfo(ctx, window)
+	if len(window.Parts) > 1 {
+		return s.runInParts(ctx, rule, window, response)
+	}
+	return s.loadWindow(ctx, rule, window, response)
+}
+
+//runInParts loads oversized window with a load job per window part
+func (s *service) runInParts(ctx context.Context, rule *config.Rule, window *batch.Window, response *contract.Response) (*load.Job, error) {
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] splitting batch window into %v load jobs\n", window.DestTable, len(window.Parts))
+	}
+	var result error
+	for i, partWindow := range window.LoadWindows() {
+		if i > 0 {
+			partWindow.ProcessURL = s.config.BuildLoadURL(partWindow.Process)
+			partWindow.DoneProcessURL = s.config.DoneLoadURL(partWindow.Process)
+		}
+		loadJob, err := s.loadWindow(ctx, rule, partWindow, response)
+		if err != nil && loadJob.Recoverable() {
+			err = s.tryRecover(ctx, loadJob, response)
+		}
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return nil, result
+}
+
+func (s *service) loadWindow(ctx context.Context, rule *config.Rule, window *batch.Window, response *contract.Response) (*load.Job, error) {
 	group, err := s.loadGroup(ctx, rule, window)
 	if err != nil {
 		return nil, err
@@ -498,6 +782,13 @@ func (s *service) runInBatch(ctx context.Context, rule *config.Rule, window *bat
 	if jobErr != nil {
 		return nil, jobErr
 	}
+	if err = s.useChunks(ctx, loadJob); err != nil {
+		return nil, err
+	}
+	s.downgradeIfOverBudget(ctx, loadJob, response)
+	if err = s.evolveSchema(ctx, loadJob, response); err != nil || len(loadJob.Load.SourceUris) == 0 {
+		return nil, err
+	}
 	err = loadJob.Init(ctx, s.bq)
 	if err != nil {
 		return nil, err
@@ -506,6 +797,26 @@ func (s *service) runInBatch(ctx context.Context, rule *config.Rule, window *bat
 	return loadJob, err
 }
 
+//scheduleSealedWindow schedules window sealed by batch limits without waiting for window end time
+func (s *service) scheduleSealedWindow(ctx context.Context, windowURL string) error {
+	if ok, _ := s.fs.Exists(ctx, windowURL, option.NewObjectKind(true)); !ok {
+		return nil
+	}
+	scheduledURL := strings.Replace(windowURL, shared.WindowExt, shared.WindowExtScheduled, 1)
+	err := s.fs.Upload(ctx, scheduledURL, file.DefaultFileOsMode, strings.NewReader("."), option.NewGeneration(true, 0))
+	if err != nil { //already scheduled
+		return nil
+	}
+	_, name := url.Split(windowURL, gs.Scheme)
+	destURL := url.Join(s.config.TriggerBucketURL()+s.config.BatchPrefix, name)
+	if err = s.fs.Copy(ctx, windowURL, destURL, option.NewObjectKind(true)); err != nil {
+		//window owner may not have stored the window yet, the dispatcher schedules it once window ends
+		shared.LogF("failed to schedule sealed window: %v, %v\n", windowURL, err)
+		_ = s.fs.Delete(ctx, scheduledURL)
+	}
+	return nil
+}
+
 func (s *service) loadGroup(ctx context.Context, rule *config.Rule, window *batch.Window) (*batch.Group, error) {
 	if rule.Batch.Group == nil {
 		return nil, nil
@@ -538,9 +849,18 @@ func (s *service) tryRecover(ctx context.Context, job *load.Job, response *contr
 			if err := s.addMissingFields(ctx, job, uris); err != nil {
 				return err
 			}
+		} else if job.Rule.Dest.SchemaEvolution != nil {
+			if err := s.evolveInvalidSchema(ctx, job, uris, response); err != nil {
+				return err
+			}
 		}
 	}
 
+	quarantined := 0
+	if len(uris.Corrupted) > 0 && job.Rule.Quarantine != nil {
+		quarantined = s.quarantineCorrupted(ctx, job, uris, response)
+	}
+
 	corruptedFileURL, invalidSchemaURL := s.getDataErrorsURLs(job.Rule)
 	if shared.IsInfoLoggingLevel() {
 		if len(uris.Corrupted) > 0 || len(uris.InvalidSchema) > 0 {
@@ -548,7 +868,8 @@ func (s *service) tryRecover(ctx context.Context, job *load.Job, response *contr
 		}
 	}
 
-	if len(uris.InvalidSchema) == 0 && len(uris.Corrupted) == 0 && len(uris.Missing) == 0 && len(uris.MissingFields) == 0 {
+	if quarantined == 0 && len(uris.InvalidSchema) == 0 && len(uris.Corrupted) == 0 && len(uris.Missing) == 0 && len(uris.MissingFields) == 0 {
+		s.releaseIngestion(ctx, job.Rule, job.DestTable, job.Load.SourceUris, response)
 		return base.JobError(job.BqJob)
 	}
 
@@ -561,6 +882,11 @@ func (s *service) tryRecover(ctx context.Context, job *load.Job, response *contr
 		err = errors.Wrapf(err, "failed to move %v to %v", response.InvalidSchema, invalidSchemaURL)
 		response.MoveError = err.Error()
 	}
+	response.Corrupted = append(response.Corrupted, uris.Corrupted...)
+	response.InvalidSchema = append(response.InvalidSchema, uris.InvalidSchema...)
+	var excluded = make([]string, 0, len(uris.Corrupted)+len(uris.InvalidSchema))
+	excluded = append(append(excluded, uris.Corrupted...), uris.InvalidSchema...)
+	s.releaseIngestion(ctx, job.Rule, job.DestTable, excluded, response)
 
 	if len(uris.Valid) == 0 {
 		response.Retriable = false
@@ -569,18 +895,17 @@ func (s *service) tryRecover(ctx context.Context, job *load.Job, response *contr
 	response.Status = shared.StatusOK
 	response.Error = ""
 	job.Load.SourceUris = uris.Valid
-	loadRequest, action := job.NewLoadRequest()
 	meta := activity.Parse(job.BqJob.JobReference.JobId)
-	action.Meta.Step = meta.Step + 1
 	reloadCount := meta.Step - 1
 	if shared.IsDebugLoggingLevel() {
 		shared.LogF("reload attempt: %v\n", reloadCount)
 		shared.LogLn(meta)
 	}
 	if reloadCount > job.Rule.MaxReloadAttempts() {
+		s.releaseIngestion(ctx, job.Rule, job.DestTable, job.Load.SourceUris, response)
 		return base.JobError(job.BqJob)
 	}
-	action.Meta = action.Meta.Wrap(shared.ActionReload)
+	loadRequest, action := job.NewReloadRequest(meta.Step + 1)
 	loadJob, err := s.bq.Load(ctx, loadRequest, action)
 	if err == nil {
 		err = base.JobError(loadJob)
@@ -592,6 +917,116 @@ func (s *service) tryRecover(ctx context.Context, job *load.Job, response *contr
 	return err
 }
 
+//evolveSchema applies destination schema evolution policy before load, data files with rejected schema changes are moved to invalid schema location
+func (s *service) evolveSchema(ctx context.Context, job *load.Job, response *contract.Response) error {
+	if job.Rule.Dest.SchemaEvolution == nil {
+		return nil
+	}
+	result, err := s.evolution.Evolve(ctx, &evolution.Request{
+		Rule:       job.Rule,
+		EventID:    job.EventID,
+		DestTable:  job.DestTable,
+		SourceURLs: job.Load.SourceUris,
+	})
+	if err != nil {
+		response.SchemaError = err.Error()
+		return err
+	}
+	response.SchemaChanges = append(response.SchemaChanges, result.Changes...)
+	if len(result.Rejected) == 0 {
+		return nil
+	}
+	_, invalidSchemaURL := s.getDataErrorsURLs(job.Rule)
+	if err := s.moveAssets(ctx, result.Rejected, invalidSchemaURL); err != nil {
+		response.MoveError = errors.Wrapf(err, "failed to move %v to %v", result.Rejected, invalidSchemaURL).Error()
+	}
+	response.InvalidSchema = append(response.InvalidSchema, result.Rejected...)
+	s.releaseIngestion(ctx, job.Rule, job.DestTable, result.Rejected, response)
+	job.Load.SourceUris = result.Accepted
+	if len(job.Load.SourceUris) == 0 {
+		response.Retriable = false
+	}
+	return nil
+}
+
+//evolveInvalidSchema applies destination schema evolution policy to data files failed with invalid schema, accepted files are reloaded
+func (s *service) evolveInvalidSchema(ctx context.Context, job *load.Job, uris *status.URIs, response *contract.Response) error {
+	var fields = make([][]*bigquery.TableFieldSchema, 0)
+	for _, field := range uris.MissingFields {
+		if err := field.AdjustType(ctx, s.fs); err == nil {
+			fields = append(fields, field.Fields)
+		}
+	}
+	request := &evolution.Request{
+		Rule:       job.Rule,
+		EventID:    job.EventID,
+		DestTable:  job.DestTable,
+		SourceURLs: uris.InvalidSchema,
+	}
+	if len(fields) > 0 {
+		request.Fields = schema.MergeFields(fields...)
+	}
+	result, err := s.evolution.Evolve(ctx, request)
+	if err != nil {
+		response.SchemaError = err.Error()
+		return err
+	}
+	response.SchemaChanges = append(response.SchemaChanges, result.Changes...)
+	if job.Load.Schema != nil && len(result.Changes) > 0 {
+		policy := job.Rule.Dest.SchemaEvolution
+		fields := schema.Evolve(policy.TargetFields(job.Load.Schema.Fields), result.Changes)
+		job.Load.Schema = &bigquery.TableSchema{Fields: policy.AliasFields(fields)}
+	}
+	uris.Valid = append(uris.Valid, result.Accepted...)
+	uris.InvalidSchema = result.Rejected
+	return nil
+}
+
+//quarantineCorrupted splits corrupted data files into clean part that is reloaded and rejected rows moved to dead letter location,
+//data files that can not be split stay corrupted, it returns quarantined files count
+func (s *service) quarantineCorrupted(ctx context.Context, job *load.Job, uris *status.URIs, response *contract.Response) int {
+	baseURL := job.Rule.Quarantine.URL
+	if baseURL == "" {
+		baseURL = s.config.QuarantineURL
+	}
+	corruptedFileURL, _ := s.getDataErrorsURLs(job.Rule)
+	positions := quarantine.Positions(job.BqJob)
+	var corrupted = make([]string, 0)
+	quarantined := 0
+	for _, URL := range uris.Corrupted {
+		manifest, err := s.quarantine.Split(ctx, &quarantine.Request{
+			Rule:      job.Rule,
+			EventID:   job.EventID,
+			DestTable: job.DestTable,
+			SourceURL: URL,
+			BaseURL:   baseURL,
+			Errors:    positions[URL],
+		})
+		if err != nil {
+			shared.LogF("[%v] failed to quarantine %v: %v\n", job.DestTable, URL, err)
+		}
+		if manifest == nil {
+			corrupted = append(corrupted, URL)
+			continue
+		}
+		quarantined++
+		response.Quarantined = append(response.Quarantined, manifest)
+		if manifest.CleanURL != "" {
+			uris.Valid = append(uris.Valid, manifest.CleanURL)
+		}
+		if job.Rule.Quarantine.KeepOriginal && !quarantine.IsClean(baseURL, URL) {
+			err = s.moveAssets(ctx, []string{URL}, corruptedFileURL)
+		} else {
+			err = s.fs.Delete(ctx, URL)
+		}
+		if err != nil {
+			response.MoveError = errors.Wrapf(err, "failed to remove quarantined %v", URL).Error()
+		}
+	}
+	uris.Corrupted = corrupted
+	return quarantined
+}
+
 func (s *service) getDataErrorsURLs(rule *config.Rule) (string, string) {
 	corruptedFileURL := s.config.CorruptedFileURL
 	invalidSchemaURL := s.config.InvalidSchemaURL
@@ -655,7 +1090,7 @@ func (s *service) logBatchInfo(ctx context.Context, window *batch.Window) error
 	if s.config.BqBatchInfoPath == "" {
 		return nil
 	}
-	URL := url.Join(fmt.Sprintf("gs://%v/", s.config.TriggerBucket), s.config.BqBatchInfoPath, window.EventID+shared.JSONExt)
+	URL := url.Join(s.config.TriggerBucketURL(), s.config.BqBatchInfoPath, window.EventID+shared.JSONExt)
 	data, err := json.Marshal(window)
 	if err != nil {
 		return err
@@ -673,8 +1108,9 @@ func (s *service) logJobInfo(ctx context.Context, bqjob *bigquery.Job, action *t
 		info.EventID = action.Meta.EventID
 		info.TempTable = action.Meta.TempTable
 		info.RuleURL = action.Meta.RuleURL
+		s.setDroppedRows(ctx, bqjob, info)
 	}
-	URL := url.Join(fmt.Sprintf("gs://%v/", s.config.TriggerBucket), s.config.BqJobInfoPath, bqjob.JobReference.JobId+shared.JSONExt)
+	URL := url.Join(s.config.TriggerBucketURL(), s.config.BqJobInfoPath, bqjob.JobReference.JobId+shared.JSONExt)
 	data, err := json.Marshal(info)
 	if err != nil {
 		return err
@@ -682,6 +1118,236 @@ func (s *service) logJobInfo(ctx context.Context, bqjob *bigquery.Job, action *t
 	return s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data))
 }
 
+//logJobUsage records destination table jobs count and rule cost, it is called for async jobs by post-job task, and for sync jobs by bq job listener
+func (s *service) logJobUsage(ctx context.Context, bqjob *bigquery.Job, action *task.Action) error {
+	if action == nil || action.Meta == nil {
+		return nil
+	}
+	err := s.logTableJob(ctx, bqjob, action)
+	if costErr := s.logJobCost(ctx, bqjob, action); costErr != nil {
+		if err == nil {
+			return costErr
+		}
+		return errors.Wrapf(err, "failed to log job cost: %v", costErr)
+	}
+	return err
+}
+
+//logTableJob increments the current day destination table jobs count
+func (s *service) logTableJob(ctx context.Context, bqjob *bigquery.Job, action *task.Action) error {
+	if s.config.TableQuotaURL == "" {
+		return nil
+	}
+	table, jobType := quota.JobTable(bqjob, action.Meta.TempTable)
+	if table == nil {
+		return nil
+	}
+	_, err := s.quota.Add(ctx, quota.TableKey(table), jobType)
+	return err
+}
+
+//throttle returns rule with batch window widened once destination table approaches daily jobs quota
+func (s *service) throttle(ctx context.Context, process *stage.Process, rule *config.Rule, response *contract.Response) *config.Rule {
+	if s.config.TableQuotaURL == "" || process.DestTable == "" {
+		return rule
+	}
+	table, err := base.NewTableReference(process.DestTable)
+	if err != nil {
+		return rule
+	}
+	usage, err := s.quota.Get(ctx, quota.TableKey(table))
+	if err != nil {
+		response.QuotaError = err.Error()
+		shared.LogF("[%v] failed to get table quota usage: %v\n", process.DestTable, err)
+		return rule
+	}
+	response.TableJobs = usage.Jobs
+	if float64(usage.Jobs) < s.config.TableQuotaThreshold*float64(s.config.MaxTableJobsPerDay) {
+		return rule
+	}
+	jobsPerWindow := 1
+	if rule.Dest.Transient != nil {
+		jobsPerWindow++
+	}
+	level := usage.WindowLevel(s.config.MaxTableJobsPerDay, s.config.TableQuotaThreshold, jobsPerWindow, rule.Batch.Window.DurationInSec, time.Now())
+	widened := rule.WithBatch(rule.Batch.Widen(level))
+	response.WindowInSec = widened.Batch.Window.DurationInSec
+	if shared.IsInfoLoggingLevel() && level > 0 {
+		shared.LogF("[%v] %v/%v daily jobs, widened batch window: %v\n", process.DestTable, usage.Jobs, s.config.MaxTableJobsPerDay, widened.Batch.Window.Duration)
+	}
+	return widened
+}
+
+//logJobCost adds job usage to the daily rule cost record, rule budget OnExceeded actions run once the budget gets exceeded
+func (s *service) logJobCost(ctx context.Context, bqjob *bigquery.Job, action *task.Action) error {
+	if s.config.CostURL == "" || action.Meta.RuleURL == "" {
+		return nil
+	}
+	rule := s.config.Rule(ctx, action.Meta.RuleURL)
+	request := &cost.Request{RuleURL: action.Meta.RuleURL, DestTable: action.Meta.DestTable, Job: bqjob}
+	if rule != nil {
+		request.Budget = rule.Budget
+	}
+	record, exceeded, err := s.cost.Add(ctx, request)
+	if err != nil || !exceeded || rule == nil || rule.Budget == nil || len(rule.Budget.OnExceeded) == 0 {
+		return err
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("[%v] rule budget exceeded: %v bytes, %v slot ms: %v\n", action.Meta.DestTable, record.ChargedBytes, record.TotalSlotMs, action.Meta.RuleURL)
+	}
+	expander := data.Map(map[string]interface{}{
+		"RuleURL":         record.RuleURL,
+		"Date":            record.Date,
+		"Owner":           rule.Info.LeadEngineer,
+		"DestTable":       action.Meta.DestTable,
+		"Mode":            rule.Budget.Mode,
+		"ChargedBytes":    record.ChargedBytes,
+		"TotalSlotMs":     record.TotalSlotMs,
+		"MaxBytesPerDay":  rule.Budget.MaxBytesPerDay,
+		"MaxSlotMsPerDay": rule.Budget.MaxSlotMsPerDay,
+	})
+	var actions = make([]*task.Action, 0, len(rule.Budget.OnExceeded))
+	for _, onExceeded := range rule.Budget.OnExceeded {
+		actions = append(actions, onExceeded.Expand(nil, expander))
+	}
+	_, err = task.RunAll(ctx, s.Registry, actions)
+	return err
+}
+
+//isOverBudget returns true if rule daily budget has been exceeded
+func (s *service) isOverBudget(ctx context.Context, rule *config.Rule) bool {
+	if rule.Budget == nil || s.config.CostURL == "" {
+		return false
+	}
+	record, err := s.cost.Get(ctx, rule.Info.URL)
+	if err != nil {
+		shared.LogF("failed to get cost record: %v, %v\n", rule.Info.URL, err)
+		return false
+	}
+	return record.Exceeded
+}
+
+//pauseIfOverBudget moves data file to CostURL/paused/$rule/$bucket/$path once rule daily budget has been exceeded, rule with copy budget mode is paused only if plain copy is not possible
+func (s *service) pauseIfOverBudget(ctx context.Context, rule *config.Rule, request *contract.Request, response *contract.Response) (bool, error) {
+	if rule.Budget == nil {
+		return false, nil
+	}
+	if rule.Budget.Mode == config.BudgetModeCopy && !rule.Dest.HasSplit() {
+		return false, nil
+	}
+	if !s.isOverBudget(ctx, rule) {
+		s.resumePaused(ctx, rule, request)
+		return false, nil
+	}
+	pausedURL := url.Join(s.config.CostURL, shared.PausedFolder, cost.RuleKey(rule.Info.URL), url.Host(request.SourceURL), url.Path(request.SourceURL))
+	if err := s.fs.Move(ctx, request.SourceURL, pausedURL); err != nil {
+		return false, errors.Wrapf(err, "failed to move %v to %v", request.SourceURL, pausedURL)
+	}
+	response.Status = shared.StatusPaused
+	response.PausedURL = pausedURL
+	response.Retriable = false
+	return true, nil
+}
+
+//resumePaused moves data files paused by exceeded rule budget back to $scheme://$bucket/$path with the first rule event of the day within the budget,
+//moved data files are ingested with new storage events
+func (s *service) resumePaused(ctx context.Context, rule *config.Rule, request *contract.Request) {
+	if s.config.CostURL == "" {
+		return
+	}
+	pausedURL := url.Join(s.config.CostURL, shared.PausedFolder, cost.RuleKey(rule.Info.URL))
+	if exists, _ := s.fs.Exists(ctx, pausedURL); !exists {
+		return
+	}
+	claimed, err := s.cost.Resume(ctx, rule.Info.URL)
+	if err != nil {
+		shared.LogF("failed to resume paused data files: %v, %v\n", rule.Info.URL, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	URLs, err := s.listPaused(ctx, pausedURL)
+	if err != nil {
+		shared.LogF("failed to list paused data files: %v, %v\n", pausedURL, err)
+		return
+	}
+	scheme := url.Scheme(request.SourceURL, gs.Scheme)
+	basePath := url.Path(pausedURL)
+	for _, URL := range URLs {
+		sourceURL := fmt.Sprintf("%v://%v", scheme, strings.Trim(strings.TrimPrefix(url.Path(URL), basePath), "/"))
+		if err := s.fs.Move(ctx, URL, sourceURL); err != nil {
+			shared.LogF("failed to resume paused data file: %v, %v\n", URL, err)
+			continue
+		}
+		if shared.IsInfoLoggingLevel() {
+			shared.LogF("resumed paused data file: %v\n", sourceURL)
+		}
+	}
+}
+
+//listPaused returns paused data files URLs
+func (s *service) listPaused(ctx context.Context, baseURL string) ([]string, error) {
+	objects, err := s.fs.List(ctx, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	var result = make([]string, 0)
+	for i, object := range objects {
+		if i == 0 && object.IsDir() {
+			continue
+		}
+		if !object.IsDir() {
+			result = append(result, object.URL())
+			continue
+		}
+		URLs, err := s.listPaused(ctx, object.URL())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, URLs...)
+	}
+	return result, nil
+}
+
+//downgradeIfOverBudget replaces transient transform/query step with plain copy once rule daily budget has been exceeded in copy mode
+func (s *service) downgradeIfOverBudget(ctx context.Context, job *load.Job, response *contract.Response) {
+	rule := job.Rule
+	if rule.Budget == nil || rule.Budget.Mode != config.BudgetModeCopy || rule.Dest.Transient == nil || rule.Dest.HasSplit() {
+		return
+	}
+	if job.Downgraded = s.isOverBudget(ctx, rule); job.Downgraded {
+		response.Downgraded = true
+	}
+}
+
+//setDroppedRows sets total number of transient rows not copied to destination for rules with dest.Dedupe lookback,
+//the total also includes rows dropped by UniqueColumns deduplication and Transient.Criteria, lookback duplicates are not counted separately
+func (s *service) setDroppedRows(ctx context.Context, bqjob *bigquery.Job, info *job.Info) {
+	if info.TempTable == "" || bqjob.Configuration == nil || bqjob.Configuration.Query == nil {
+		return
+	}
+	rule := s.config.Rule(ctx, info.RuleURL)
+	if rule == nil || rule.Dest.Dedupe == nil || rule.Dest.HasSplit() {
+		return
+	}
+	copied, ok := job.CopiedRows(bqjob)
+	if !ok {
+		return
+	}
+	tempRef, err := base.NewTableReference(info.TempTable)
+	if err != nil {
+		return
+	}
+	table, err := s.bq.Table(ctx, tempRef)
+	if err != nil || table == nil {
+		return
+	}
+	if dropped := int64(table.NumRows) - copied; dropped > 0 {
+		info.DroppedRows = int(dropped)
+	}
+}
+
 func (s *service) addMissingFields(ctx context.Context, job *load.Job, uris *status.URIs) error {
 
 	for _, field := range uris.MissingFields {
@@ -746,3 +1412,15 @@ func New(ctx context.Context, config *Config) (Service, error) {
 	}
 	return srv, srv.Init(ctx)
 }
+
+//NewWithBigQuery creates a new service with supplied BigQuery API service, i.e. in-process emulator
+func NewWithBigQuery(ctx context.Context, config *Config, bigQuery *bigquery.Service) (Service, error) {
+	srv := &service{
+		config:   config,
+		fs:       afs.New(),
+		cfs:      cache.Singleton(config.URL),
+		bigQuery: bigQuery,
+		Registry: task.NewRegistry(),
+	}
+	return srv, srv.Init(ctx)
+}
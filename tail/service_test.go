@@ -0,0 +1,370 @@
+package tail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/service/bq/emulator"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/stage"
+	"github.com/viant/bqtail/stage/load"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/tail/contract"
+	"github.com/viant/bqtail/tail/cost"
+	"github.com/viant/bqtail/tail/ledger"
+	"github.com/viant/bqtail/task"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBaseURL = "mem://localhost/tail/test"
+
+func newTestConfig(ctx context.Context, fs afs.Service, rules map[string]string) (*Config, error) {
+	async := false
+	cfg := &Config{Async: &async}
+	cfg.URL = testBaseURL + "/config/config.json"
+	cfg.ProjectID = "myproject"
+	cfg.TriggerBucket = "mybucket"
+	cfg.JournalURL = testBaseURL + "/journal"
+	cfg.ErrorURL = testBaseURL + "/errors"
+	cfg.CorruptedFileURL = testBaseURL + "/corrupted"
+	cfg.AsyncTaskURL = testBaseURL + "/tasks"
+	cfg.SyncTaskURL = testBaseURL + "/tasks"
+	cfg.RulesURL = testBaseURL + "/config/rules"
+	cfg.CheckInMs = 1
+	for name, rule := range rules {
+		if err := fs.Upload(ctx, cfg.RulesURL+"/"+name, file.DefaultFileOsMode, strings.NewReader(rule)); err != nil {
+			return nil, err
+		}
+	}
+	data, _ := json.Marshal(cfg)
+	if err := fs.Upload(ctx, cfg.URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func TestService_Tail(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	cfg, err := newTestConfig(ctx, fs, map[string]string{
+		"events.yaml": `When:
+  Prefix: /data/events/
+  Suffix: .json
+Dest:
+  Table: mydataset.events
+  Transient:
+    Dataset: temp
+OnSuccess:
+  - Action: delete
+Info:
+  Workflow: emulated events ingestion
+`,
+		"ledger.yaml": `When:
+  Prefix: /data/ledger/
+  Suffix: .json
+Dest:
+  Table: mydataset.ledger_events
+Dedupe:
+  Ledger: true
+`,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	bqEmulator := emulator.New(testBaseURL+"/bq", fs)
+	bqService, err := bqEmulator.Service(ctx, task.NewRegistry(), cfg.ProjectID, fs, cfg.Config)
+	if !assert.Nil(t, err) {
+		return
+	}
+	for _, dataset := range []string{"mydataset", "temp"} {
+		assert.Nil(t, bqService.CreateDatasetIfNotExist(ctx, "", &bigquery.DatasetReference{DatasetId: dataset}))
+	}
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "STRING"}}}
+	for _, table := range []string{"events", "ledger_events"} {
+		assert.Nil(t, bqService.CreateTableIfNotExist(ctx, &bigquery.Table{TableReference: &bigquery.TableReference{DatasetId: "mydataset", TableId: table}, Schema: schema}, false))
+	}
+
+	bigQuery, err := bqEmulator.BigQuery(ctx)
+	if !assert.Nil(t, err) {
+		return
+	}
+	srv, err := NewWithBigQuery(ctx, cfg, bigQuery)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	registered, released := true, false
+	ledgerURL := url.Join(cfg.JournalURL, shared.LedgerFolder, "mydataset.ledger_events")
+	var useCases = []struct {
+		description   string
+		eventID       string
+		URL           string
+		data          string
+		table         string
+		expectRows    uint64
+		expectStatus  string
+		expectDeleted bool
+		//expectLedger expected ledger entry presence, checked for ledger rule files
+		expectLedger *bool
+	}{
+		{
+			description:   "individual file ingestion",
+			eventID:       "101",
+			URL:           "mem://localhost/data/events/events1.json",
+			data:          "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n",
+			table:         "mydataset.events",
+			expectRows:    2,
+			expectStatus:  shared.StatusOK,
+			expectDeleted: true,
+		},
+		{
+			description:   "second file ingestion",
+			eventID:       "102",
+			URL:           "mem://localhost/data/events/events2.json",
+			data:          "{\"id\":3,\"name\":\"c\"}\n",
+			table:         "mydataset.events",
+			expectRows:    3,
+			expectStatus:  shared.StatusOK,
+			expectDeleted: true,
+		},
+		{
+			description:  "ledger - first delivery",
+			eventID:      "103",
+			URL:          "mem://localhost/data/ledger/events1.json",
+			data:         "{\"id\":1,\"name\":\"a\"}\n",
+			table:        "mydataset.ledger_events",
+			expectRows:   1,
+			expectStatus: shared.StatusOK,
+			expectLedger: &registered,
+		},
+		{
+			description:  "ledger - redelivered event",
+			eventID:      "104",
+			URL:          "mem://localhost/data/ledger/events1.json",
+			table:        "mydataset.ledger_events",
+			expectRows:   1,
+			expectStatus: shared.StatusDuplicate,
+			expectLedger: &registered,
+		},
+		{
+			description:  "ledger - failed load releases entry",
+			eventID:      "105",
+			URL:          "mem://localhost/data/ledger/events2.json",
+			data:         "{\"id\":2,\"name\":\n",
+			table:        "mydataset.ledger_events",
+			expectRows:   1,
+			expectStatus: shared.StatusError,
+			expectLedger: &released,
+		},
+	}
+
+	for _, useCase := range useCases {
+		if useCase.data != "" {
+			err = fs.Upload(ctx, useCase.URL, file.DefaultFileOsMode, strings.NewReader(useCase.data))
+			assert.Nil(t, err, useCase.description)
+		}
+		response := srv.Tail(ctx, &contract.Request{
+			EventID:   useCase.eventID,
+			SourceURL: useCase.URL,
+			Started:   time.Now(),
+		})
+		bqEmulator.Wait()
+		assert.Equal(t, useCase.expectStatus, response.Status, useCase.description+" "+response.Error)
+		ref, _ := base.NewTableReference(useCase.table)
+		table, err := bqService.Table(ctx, ref)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectRows, table.NumRows, useCase.description)
+		exists, _ := fs.Exists(ctx, useCase.URL, option.NewObjectKind(true))
+		assert.Equal(t, !useCase.expectDeleted, exists, useCase.description)
+		if useCase.expectLedger != nil {
+			assert.Equal(t, *useCase.expectLedger, hasLedgerEntry(ctx, fs, ledgerURL, useCase.URL), useCase.description)
+		}
+	}
+
+	//sync mode jobs are recorded in destination table quota and rule cost usage
+	tailService := srv.(*service)
+	usage, err := tailService.quota.Get(ctx, "mydataset.events")
+	if assert.Nil(t, err) {
+		assert.EqualValues(t, 2, usage.CopyJobs)
+	}
+	record, err := tailService.cost.Get(ctx, cfg.RulesURL+"/events.yaml")
+	if assert.Nil(t, err) {
+		assert.EqualValues(t, 4, record.Jobs)
+	}
+}
+
+func hasLedgerEntry(ctx context.Context, fs afs.Service, ledgerURL, URL string) bool {
+	objects, _ := fs.List(ctx, ledgerURL)
+	for _, object := range objects {
+		if !object.IsDir() && strings.HasPrefix(object.Name(), fmt.Sprintf("%v_", base.Hash(URL))) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestService_ResumePaused(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	cfg, err := newTestConfig(ctx, fs, map[string]string{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	cfg.CostURL = testBaseURL + "/cost"
+	bqEmulator := emulator.New(testBaseURL+"/bq", fs)
+	bigQuery, err := bqEmulator.BigQuery(ctx)
+	if !assert.Nil(t, err) {
+		return
+	}
+	srv, err := NewWithBigQuery(ctx, cfg, bigQuery)
+	if !assert.Nil(t, err) {
+		return
+	}
+	tailService := srv.(*service)
+	rule := &config.Rule{Budget: &config.Budget{MaxBytesPerDay: 100}, Dest: &config.Destination{Table: "mydataset.events"}}
+	rule.Info.URL = cfg.RulesURL + "/budget.yaml"
+	pausedURL := url.Join(cfg.CostURL, shared.PausedFolder, cost.RuleKey(rule.Info.URL))
+
+	var useCases = []struct {
+		description   string
+		pausedURL     string
+		sourceURL     string
+		expectResumed bool
+	}{
+		{
+			description:   "paused data file resumed with the first event of the day",
+			pausedURL:     pausedURL + "/localhost/data/budget/events1.json",
+			sourceURL:     "mem://localhost/data/budget/events1.json",
+			expectResumed: true,
+		},
+		{
+			description: "paused data file not resumed again the same day",
+			pausedURL:   pausedURL + "/localhost/data/budget/events2.json",
+			sourceURL:   "mem://localhost/data/budget/events2.json",
+		},
+	}
+	for _, useCase := range useCases {
+		assert.Nil(t, fs.Upload(ctx, useCase.pausedURL, file.DefaultFileOsMode, strings.NewReader("{\"id\":1}\n")), useCase.description)
+		response := contract.NewResponse("301")
+		paused, err := tailService.pauseIfOverBudget(ctx, rule, &contract.Request{EventID: "301", SourceURL: "mem://localhost/data/budget/events0.json"}, response)
+		assert.Nil(t, err, useCase.description)
+		assert.False(t, paused, useCase.description)
+		resumed, _ := fs.Exists(ctx, useCase.sourceURL, option.NewObjectKind(true))
+		assert.EqualValues(t, useCase.expectResumed, resumed, useCase.description)
+		stillPaused, _ := fs.Exists(ctx, useCase.pausedURL, option.NewObjectKind(true))
+		assert.EqualValues(t, !useCase.expectResumed, stillPaused, useCase.description)
+	}
+}
+
+func TestService_TryRecover_ReleaseIngestion(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	cfg, err := newTestConfig(ctx, fs, map[string]string{
+		"ledger.yaml": `When:
+  Prefix: /data/ledger/
+  Suffix: .json
+Dest:
+  Table: mydataset.events
+Dedupe:
+  Ledger: true
+`,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	bqEmulator := emulator.New(testBaseURL+"/bq", fs)
+	bigQuery, err := bqEmulator.BigQuery(ctx)
+	if !assert.Nil(t, err) {
+		return
+	}
+	srv, err := NewWithBigQuery(ctx, cfg, bigQuery)
+	if !assert.Nil(t, err) {
+		return
+	}
+	tailService := srv.(*service)
+	rule := &config.Rule{Dedupe: &config.Dedupe{Ledger: true}, Dest: &config.Destination{Table: "mydataset.events"}}
+
+	var useCases = []struct {
+		description     string
+		folder          string
+		errors          func(URLs []string) []*bigquery.ErrorProto
+		expectCorrupted int
+	}{
+		{
+			description: "unclassified load error releases all source files",
+			folder:      "unclassified",
+			errors: func(URLs []string) []*bigquery.ErrorProto {
+				return []*bigquery.ErrorProto{{Reason: "internalError", Message: "internal error"}}
+			},
+		},
+		{
+			description: "corrupted source files released",
+			folder:      "corrupted",
+			errors: func(URLs []string) []*bigquery.ErrorProto {
+				return []*bigquery.ErrorProto{
+					{Reason: "invalid", Location: URLs[0], Message: "JSON parsing error"},
+					{Reason: "invalid", Location: URLs[1], Message: "JSON parsing error"},
+				}
+			},
+			expectCorrupted: 2,
+		},
+	}
+	for _, useCase := range useCases {
+		destTable := "mydataset.events_" + useCase.folder
+		var URLs = make([]string, 0)
+		for i := 0; i < 3; i++ {
+			URL := fmt.Sprintf("mem://localhost/data/ledger/%v/events%v.json", useCase.folder, i)
+			assert.Nil(t, fs.Upload(ctx, URL, file.DefaultFileOsMode, strings.NewReader("{\"id\":1}\n")), useCase.description)
+			object, err := fs.Object(ctx, URL)
+			if !assert.Nil(t, err, useCase.description) {
+				continue
+			}
+			registered, err := tailService.ledger.Register(ctx, ledger.NewEntry(object, destTable, "501"))
+			assert.Nil(t, err, useCase.description)
+			assert.Nil(t, registered, useCase.description)
+			URLs = append(URLs, URL)
+		}
+		loadConfig := &bigquery.JobConfigurationLoad{SourceUris: URLs[:2]}
+		jobErrors := useCase.errors(URLs)
+		job := &load.Job{
+			Process: &stage.Process{EventID: "501", DestTable: destTable},
+			Rule:    rule,
+			Load:    loadConfig,
+			BqJob: &bigquery.Job{
+				Id:            "myproject:US.job_501",
+				JobReference:  &bigquery.JobReference{ProjectId: "myproject", JobId: "job_501"},
+				Configuration: &bigquery.JobConfiguration{Load: loadConfig},
+				Status:        &bigquery.JobStatus{State: "DONE", ErrorResult: jobErrors[0], Errors: jobErrors},
+			},
+		}
+		response := contract.NewResponse("501")
+		_ = tailService.tryRecover(ctx, job, response)
+		assert.EqualValues(t, useCase.expectCorrupted, len(response.Corrupted), useCase.description)
+		objects, err := fs.List(ctx, url.Join(cfg.LedgerURL, destTable))
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		var entries = make([]string, 0)
+		for _, object := range objects {
+			if !object.IsDir() {
+				entries = append(entries, object.Name())
+			}
+		}
+		if assert.Equal(t, 1, len(entries), useCase.description) {
+			assert.True(t, strings.HasPrefix(entries[0], fmt.Sprintf("%v_", base.Hash(URLs[2]))), useCase.description)
+		}
+	}
+}
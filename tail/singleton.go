@@ -5,6 +5,10 @@ import (
 	"github.com/viant/bqtail/shared"
 	//use google fs connector
 	_ "github.com/viant/afsc/gs"
+	//use aws s3 fs connector, required by rules with Staging setting
+	_ "github.com/viant/afsc/s3"
+	//use azure blob fs connector, required by rules with Staging setting
+	_ "github.com/viant/bqtail/tail/staging/azure"
 )
 
 var srv Service
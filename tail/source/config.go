@@ -0,0 +1,78 @@
+package source
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	defaultMaxEvents    = 100
+	defaultPollInterval = time.Second
+)
+
+//Config represents event source config
+type Config struct {
+	//Kind event source kind: pubsub, kafkarest or dir
+	Kind string
+	//ProjectID Pub/Sub subscription project
+	ProjectID string `json:",omitempty"`
+	//Subscription Pub/Sub pull subscription name
+	Subscription string `json:",omitempty"`
+	//ProxyURL Kafka REST proxy (v2 API) URL, i.e. http://localhost:8082
+	ProxyURL string `json:",omitempty"`
+	//Topics Kafka topics
+	Topics []string `json:",omitempty"`
+	//Group Kafka consumer group
+	Group string `json:",omitempty"`
+	//Dir watched local directory
+	Dir string `json:",omitempty"`
+	//ScanExisting if set, files already present in the watched directory are emitted on start
+	ScanExisting bool `json:",omitempty"`
+	//MaxEvents max events returned by a single receive
+	MaxEvents int `json:",omitempty"`
+	//PollIntervalMs poll interval used by Kafka records timeout and directory polling
+	PollIntervalMs int `json:",omitempty"`
+}
+
+//Init initialises config
+func (c *Config) Init() {
+	if c.MaxEvents == 0 {
+		c.MaxEvents = defaultMaxEvents
+	}
+	if c.PollIntervalMs == 0 {
+		c.PollIntervalMs = int(defaultPollInterval / time.Millisecond)
+	}
+}
+
+//PollInterval returns poll interval
+func (c *Config) PollInterval() time.Duration {
+	return time.Duration(c.PollIntervalMs) * time.Millisecond
+}
+
+//Validate checks if config is valid
+func (c *Config) Validate() error {
+	switch c.Kind {
+	case KindPubSub:
+		if c.Subscription == "" {
+			return errors.New("pubsub subscription was empty")
+		}
+	case KindKafkaREST:
+		if c.ProxyURL == "" {
+			return errors.New("kafkarest proxyURL was empty")
+		}
+		if len(c.Topics) == 0 {
+			return errors.New("kafkarest topics were empty")
+		}
+		if c.Group == "" {
+			return errors.New("kafkarest group was empty")
+		}
+	case KindDir:
+		if c.Dir == "" {
+			return errors.New("dir was empty")
+		}
+	default:
+		return fmt.Errorf("unsupported event source kind: '%v'", c.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/tail/contract"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const dirEventBuffer = 1024
+
+var dirEventSeq uint64
+
+//dirSource represents local directory watcher, it emits closed after write or moved in files
+type dirSource struct {
+	dir          string
+	maxEvents    int
+	pollInterval time.Duration
+	events       chan string
+	closed       chan bool
+	watcher      io.Closer
+}
+
+//Receive waits for the directory files events up to poll interval
+func (s *dirSource) Receive(ctx context.Context) ([]*Event, error) {
+	var result = make([]*Event, 0)
+	select {
+	case <-ctx.Done():
+		return result, nil
+	case <-s.closed:
+		return nil, errors.New("event source was closed")
+	case location := <-s.events:
+		result = append(result, s.newEvent(location))
+	case <-time.After(s.pollInterval):
+		return result, nil
+	}
+	for len(result) < s.maxEvents {
+		select {
+		case location := <-s.events:
+			result = append(result, s.newEvent(location))
+		default:
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+func (s *dirSource) newEvent(location string) *Event {
+	ID := fmt.Sprintf("%v%v", time.Now().Unix(), atomic.AddUint64(&dirEventSeq, 1))
+	return &Event{Request: contract.NewRequest(ID, "file://"+location, time.Now()), Handle: location}
+}
+
+//Commit does nothing, processed file is removed or moved by rule post actions
+func (s *dirSource) Commit(ctx context.Context, event *Event) error {
+	return nil
+}
+
+//Reject emits file again after poll interval
+func (s *dirSource) Reject(ctx context.Context, event *Event) error {
+	go func() {
+		select {
+		case <-time.After(s.pollInterval):
+			s.emit(event.Handle.(string))
+		case <-s.closed:
+		}
+	}()
+	return nil
+}
+
+func (s *dirSource) emit(location string) {
+	select {
+	case s.events <- location:
+	case <-s.closed:
+	}
+}
+
+//Close stops directory watcher
+func (s *dirSource) Close() error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+		close(s.closed)
+	}
+	return s.watcher.Close()
+}
+
+//scan emits files already present in the directory
+func (s *dirSource) scan(dir string) {
+	_ = filepath.Walk(dir, func(location string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			s.emit(location)
+		}
+		return nil
+	})
+}
+
+//NewDir creates local directory watcher event source
+func NewDir(dir string, scanExisting bool, maxEvents int, pollInterval time.Duration) (EventSource, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, errors.Errorf("invalid watched directory: %v, %v", dir, err)
+	}
+	result := &dirSource{
+		dir:          dir,
+		maxEvents:    maxEvents,
+		pollInterval: pollInterval,
+		events:       make(chan string, dirEventBuffer),
+		closed:       make(chan bool),
+	}
+	if result.watcher, err = watch(result, dir); err != nil {
+		return nil, errors.Wrapf(err, "failed to watch %v", dir)
+	}
+	if scanExisting {
+		go result.scan(dir)
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+//go:build linux
+// +build linux
+
+package source
+
+import (
+	"bytes"
+	"golang.org/x/sys/unix"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+const (
+	inotifyMask    = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_CREATE
+	inotifyTimeout = 500
+)
+
+//inotifyWatcher represents inotify based recursive directory watcher
+type inotifyWatcher struct {
+	fd     int
+	dirs   map[int]string
+	source *dirSource
+}
+
+func (w *inotifyWatcher) add(dir string) error {
+	return filepath.Walk(dir, func(location string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, location, inotifyMask)
+		if err != nil {
+			return err
+		}
+		w.dirs[wd] = location
+		return nil
+	})
+}
+
+func (w *inotifyWatcher) run() {
+	buffer := make([]byte, (unix.SizeofInotifyEvent+unix.PathMax)*64)
+	fds := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-w.source.closed:
+			return
+		default:
+		}
+		n, err := unix.Poll(fds, inotifyTimeout)
+		if err != nil && err != unix.EINTR {
+			return
+		}
+		if n == 0 || err != nil {
+			continue
+		}
+		if n, err = unix.Read(w.fd, buffer); err != nil || n < unix.SizeofInotifyEvent {
+			continue
+		}
+		w.handle(buffer[:n])
+	}
+}
+
+func (w *inotifyWatcher) handle(data []byte) {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(data); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&data[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		name := string(bytes.TrimRight(data[nameStart:nameStart+int(event.Len)], "\x00"))
+		offset = nameStart + int(event.Len)
+		parent, ok := w.dirs[int(event.Wd)]
+		if !ok || name == "" {
+			continue
+		}
+		location := filepath.Join(parent, name)
+		if event.Mask&unix.IN_ISDIR != 0 {
+			if event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && w.add(location) == nil {
+				//files created before watch was added
+				go w.source.scan(location)
+			}
+			continue
+		}
+		if event.Mask&(unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO) != 0 {
+			w.source.emit(location)
+		}
+	}
+}
+
+//Close closes inotify descriptor
+func (w *inotifyWatcher) Close() error {
+	return unix.Close(w.fd)
+}
+
+func watch(source *dirSource, dir string) (*inotifyWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	watcher := &inotifyWatcher{fd: fd, dirs: make(map[int]string), source: source}
+	if err = watcher.add(dir); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	go watcher.run()
+	return watcher, nil
+}
@@ -0,0 +1,56 @@
+//go:build !linux
+// +build !linux
+
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//pollWatcher represents polling directory watcher used where inotify is not available
+type pollWatcher struct {
+	dir    string
+	seen   map[string]time.Time
+	source *dirSource
+}
+
+func (w *pollWatcher) poll(emit bool) {
+	_ = filepath.Walk(w.dir, func(location string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if modTime, ok := w.seen[location]; ok && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		w.seen[location] = info.ModTime()
+		if emit {
+			w.source.emit(location)
+		}
+		return nil
+	})
+}
+
+func (w *pollWatcher) run() {
+	for {
+		select {
+		case <-w.source.closed:
+			return
+		case <-time.After(w.source.pollInterval):
+			w.poll(true)
+		}
+	}
+}
+
+//Close does nothing, polling stops once source is closed
+func (w *pollWatcher) Close() error {
+	return nil
+}
+
+func watch(source *dirSource, dir string) (*pollWatcher, error) {
+	watcher := &pollWatcher{dir: dir, seen: make(map[string]time.Time), source: source}
+	watcher.poll(false)
+	go watcher.run()
+	return watcher, nil
+}
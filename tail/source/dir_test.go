@@ -0,0 +1,54 @@
+package source
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bqtail_source")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	existing := filepath.Join(dir, "existing.json")
+	assert.Nil(t, ioutil.WriteFile(existing, []byte("{}"), 0644))
+
+	srv, err := NewDir(dir, true, 10, 100*time.Millisecond)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer srv.Close()
+	ctx := context.Background()
+	created := filepath.Join(dir, "sub", "created.json")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(created), 0755))
+	time.Sleep(200 * time.Millisecond)
+	assert.Nil(t, ioutil.WriteFile(created, []byte("{}"), 0644))
+
+	var received = make(map[string]*Event)
+	for i := 0; i < 30 && len(received) < 2; i++ {
+		events, err := srv.Receive(ctx)
+		assert.Nil(t, err)
+		for _, event := range events {
+			received[event.SourceURL] = event
+		}
+	}
+	assert.NotNil(t, received["file://"+existing])
+	if !assert.NotNil(t, received["file://"+created]) {
+		return
+	}
+	assert.Nil(t, srv.Reject(ctx, received["file://"+created]))
+	var redelivered bool
+	for i := 0; i < 30 && !redelivered; i++ {
+		events, _ := srv.Receive(ctx)
+		for _, event := range events {
+			redelivered = redelivered || event.SourceURL == "file://"+created
+		}
+	}
+	assert.True(t, redelivered)
+}
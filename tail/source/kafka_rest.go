@@ -0,0 +1,185 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/shared"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	kafkaContentType   = "application/vnd.kafka.v2+json"
+	kafkaBinaryContent = "application/vnd.kafka.binary.v2+json"
+)
+
+//kafkaOffset represents Kafka partition offset
+type kafkaOffset struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+func (o *kafkaOffset) key() string {
+	return fmt.Sprintf("%v/%v", o.Topic, o.Partition)
+}
+
+type kafkaRecord struct {
+	kafkaOffset
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type kafkaInstance struct {
+	InstanceID string `json:"instance_id"`
+	BaseURI    string `json:"base_uri"`
+}
+
+//kafkaSource represents Kafka consumer using REST proxy v2 protocol, offsets are committed manually
+type kafkaSource struct {
+	client       *http.Client
+	proxyURL     string
+	group        string
+	topics       []string
+	pollInterval time.Duration
+	instance     *kafkaInstance
+	//rejected earliest rejected offset by topic partition since the last receive
+	rejected map[string]int64
+}
+
+//Receive fetches consumer records, records that can not be converted to tail request are skipped
+func (s *kafkaSource) Receive(ctx context.Context) ([]*Event, error) {
+	if s.instance == nil {
+		if err := s.subscribe(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.rejected = make(map[string]int64)
+	URL := fmt.Sprintf("%v/records?timeout=%v", s.instance.BaseURI, int(s.pollInterval/time.Millisecond))
+	var records = make([]*kafkaRecord, 0)
+	if err := s.call(ctx, http.MethodGet, URL, nil, &records); err != nil {
+		return nil, err
+	}
+	var result = make([]*Event, 0, len(records))
+	for _, record := range records {
+		data, err := base64.StdEncoding.DecodeString(record.Value)
+		if err != nil {
+			data = []byte(record.Value)
+		}
+		ID := fmt.Sprintf("%v-%v-%v", record.Topic, record.Partition, record.Offset)
+		request, err := NewRequest(ID, data, nil, time.Now())
+		if err != nil {
+			shared.LogF("skipping record %v: %v\n", ID, err)
+			continue
+		}
+		offset := record.kafkaOffset
+		result = append(result, &Event{Request: request, Handle: &offset})
+	}
+	return result, nil
+}
+
+//Commit commits record offset (REST proxy commits the next offset to consume), offset is not committed if earlier record of the same partition has been rejected
+func (s *kafkaSource) Commit(ctx context.Context, event *Event) error {
+	offset := event.Handle.(*kafkaOffset)
+	if rejected, ok := s.rejected[offset.key()]; ok && rejected <= offset.Offset {
+		return nil
+	}
+	return s.call(ctx, http.MethodPost, s.instance.BaseURI+"/offsets", map[string]interface{}{"offsets": []*kafkaOffset{offset}}, nil)
+}
+
+//Reject seeks partition back to the rejected record offset, so that it is consumed again
+func (s *kafkaSource) Reject(ctx context.Context, event *Event) error {
+	offset := event.Handle.(*kafkaOffset)
+	if rejected, ok := s.rejected[offset.key()]; ok && rejected <= offset.Offset {
+		return nil
+	}
+	s.rejected[offset.key()] = offset.Offset
+	return s.call(ctx, http.MethodPost, s.instance.BaseURI+"/positions", map[string]interface{}{"offsets": []*kafkaOffset{offset}}, nil)
+}
+
+//Close deletes consumer instance
+func (s *kafkaSource) Close() error {
+	if s.instance == nil {
+		return nil
+	}
+	err := s.call(context.Background(), http.MethodDelete, s.instance.BaseURI, nil, nil)
+	s.instance = nil
+	return err
+}
+
+func (s *kafkaSource) subscribe(ctx context.Context) error {
+	instance := &kafkaInstance{}
+	request := map[string]interface{}{
+		"name":               fmt.Sprintf("bqtail-%v", time.Now().UnixNano()),
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}
+	if err := s.call(ctx, http.MethodPost, fmt.Sprintf("%v/consumers/%v", s.proxyURL, s.group), request, instance); err != nil {
+		return errors.Wrapf(err, "failed to create kafka consumer: %v", s.group)
+	}
+	s.instance = instance
+	if err := s.call(ctx, http.MethodPost, instance.BaseURI+"/subscription", map[string]interface{}{"topics": s.topics}, nil); err != nil {
+		_ = s.Close()
+		return errors.Wrapf(err, "failed to subscribe kafka topics: %v", s.topics)
+	}
+	return nil
+}
+
+func (s *kafkaSource) call(ctx context.Context, method, URL string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpRequest, err := http.NewRequest(method, URL, body)
+	if err != nil {
+		return err
+	}
+	httpRequest = httpRequest.WithContext(ctx)
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", kafkaContentType)
+	}
+	httpRequest.Header.Set("Accept", strings.Join([]string{kafkaBinaryContent, kafkaContentType}, ", "))
+	httpResponse, err := s.client.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+	data, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return err
+	}
+	if httpResponse.StatusCode/100 != 2 {
+		return errors.Errorf("%v %v failed: %v, %s", method, URL, httpResponse.Status, data)
+	}
+	if response == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
+
+//NewKafkaREST creates Kafka consumer event source using REST proxy v2 protocol, it requires REST proxy in front of the cluster
+func NewKafkaREST(client *http.Client, proxyURL, group string, topics []string, pollInterval time.Duration) EventSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &kafkaSource{
+		client:       client,
+		proxyURL:     strings.TrimRight(proxyURL, "/"),
+		group:        group,
+		topics:       topics,
+		pollInterval: pollInterval,
+		rejected:     make(map[string]int64),
+	}
+}
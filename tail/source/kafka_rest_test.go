@@ -0,0 +1,61 @@
+package source
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKafkaRESTSource(t *testing.T) {
+	var server *httptest.Server
+	var calls = make([]string, 0)
+	server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		calls = append(calls, strings.TrimSpace(fmt.Sprintf("%v %v %s", request.Method, request.URL.Path, body)))
+		switch {
+		case request.URL.Path == "/consumers/g1":
+			_ = json.NewEncoder(writer).Encode(map[string]string{"instance_id": "c1", "base_uri": server.URL + "/consumers/g1/instances/c1"})
+		case strings.HasSuffix(request.URL.Path, "/records"):
+			records := make([]map[string]interface{}, 0)
+			for i, value := range []string{"gs://b/f0.json", "gs://b/f1.json", "gs://b/f2.json", "invalid message"} {
+				records = append(records, map[string]interface{}{"topic": "t1", "partition": 0, "offset": i, "value": base64.StdEncoding.EncodeToString([]byte(value))})
+			}
+			_ = json.NewEncoder(writer).Encode(records)
+		default:
+			writer.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	srv := NewKafkaREST(nil, server.URL, "g1", []string{"t1"}, time.Millisecond)
+	events, err := srv.Receive(ctx)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 3, len(events))
+	assert.Nil(t, srv.Commit(ctx, events[0]))
+	assert.Nil(t, srv.Reject(ctx, events[1]))
+	assert.Nil(t, srv.Commit(ctx, events[2]))
+	assert.Nil(t, srv.Close())
+
+	if !assert.True(t, len(calls) > 1) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(calls[0], `POST /consumers/g1 {"auto.commit.enable":"false","auto.offset.reset":"earliest","format":"binary"`), calls[0])
+	assert.EqualValues(t, []string{
+		`POST /consumers/g1/instances/c1/subscription {"topics":["t1"]}`,
+		`GET /consumers/g1/instances/c1/records`,
+		`POST /consumers/g1/instances/c1/offsets {"offsets":[{"topic":"t1","partition":0,"offset":0}]}`,
+		`POST /consumers/g1/instances/c1/positions {"offsets":[{"topic":"t1","partition":0,"offset":1}]}`,
+		`DELETE /consumers/g1/instances/c1`,
+	}, calls[1:])
+}
@@ -0,0 +1,26 @@
+package source
+
+import (
+	"context"
+	"google.golang.org/api/option"
+	"google.golang.org/api/pubsub/v1"
+)
+
+//New creates event source for supplied config
+func New(ctx context.Context, config *Config, options ...option.ClientOption) (EventSource, error) {
+	config.Init()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	switch config.Kind {
+	case KindPubSub:
+		service, err := pubsub.NewService(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		return NewPubSub(service, config.ProjectID, config.Subscription, config.MaxEvents), nil
+	case KindKafkaREST:
+		return NewKafkaREST(nil, config.ProxyURL, config.Group, config.Topics, config.PollInterval()), nil
+	}
+	return NewDir(config.Dir, config.ScanExisting, config.MaxEvents, config.PollInterval())
+}
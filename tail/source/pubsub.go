@@ -0,0 +1,178 @@
+package source
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"google.golang.org/api/pubsub/v1"
+	"strings"
+	"sync"
+	"time"
+)
+
+//pubsubAckDeadline in flight message ack deadline, it is extended every half of the deadline until message is committed or rejected
+const pubsubAckDeadline = 60 * time.Second
+
+type pubsubSource struct {
+	service        *pubsub.Service
+	subscription   string
+	maxEvents      int64
+	extendInterval time.Duration
+	mux            sync.Mutex
+	inFlight       map[string]bool
+	keepAlive      sync.Once
+	closed         chan bool
+	closeOnce      sync.Once
+}
+
+//Receive pulls subscription messages, messages that can not be converted to tail request are acknowledged and skipped
+func (s *pubsubSource) Receive(ctx context.Context) ([]*Event, error) {
+	call := s.service.Projects.Subscriptions.Pull(s.subscription, &pubsub.PullRequest{MaxMessages: s.maxEvents})
+	call.Context(ctx)
+	var response *pubsub.PullResponse
+	var err error
+	err = base.RunWithRetries(func() error {
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result = make([]*Event, 0, len(response.ReceivedMessages))
+	for _, received := range response.ReceivedMessages {
+		msg := received.Message
+		data, err := base64.StdEncoding.DecodeString(msg.Data)
+		if err != nil {
+			data = []byte(msg.Data)
+		}
+		published, _ := time.Parse(time.RFC3339Nano, msg.PublishTime)
+		request, err := NewRequest(msg.MessageId, data, msg.Attributes, published)
+		if err != nil {
+			shared.LogF("skipping message %v: %v\n", msg.MessageId, err)
+			_ = s.acknowledge(ctx, received.AckId)
+			continue
+		}
+		result = append(result, &Event{Request: request, Handle: received.AckId})
+	}
+	if len(result) > 0 {
+		s.track(ctx, result)
+	}
+	return result, nil
+}
+
+//track marks events as in flight, extends their ack deadline and starts deadline extension loop
+func (s *pubsubSource) track(ctx context.Context, events []*Event) {
+	s.mux.Lock()
+	var ackIDs = make([]string, 0, len(events))
+	for _, event := range events {
+		ackID := event.Handle.(string)
+		s.inFlight[ackID] = true
+		ackIDs = append(ackIDs, ackID)
+	}
+	s.mux.Unlock()
+	if err := s.modifyAckDeadline(ctx, ackIDs, pubsubAckDeadline); err != nil {
+		shared.LogF("failed to extend ack deadline: %v\n", err)
+	}
+	s.keepAlive.Do(func() {
+		go s.extendAckDeadlines(ctx)
+	})
+}
+
+//extendAckDeadlines periodically extends ack deadline of in flight messages, so that long running tail does not cause redelivery
+func (s *pubsubSource) extendAckDeadlines(ctx context.Context) {
+	ticker := time.NewTicker(s.extendInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		case <-ticker.C:
+		}
+		s.mux.Lock()
+		var ackIDs = make([]string, 0, len(s.inFlight))
+		for ackID := range s.inFlight {
+			ackIDs = append(ackIDs, ackID)
+		}
+		s.mux.Unlock()
+		if len(ackIDs) == 0 {
+			continue
+		}
+		if err := s.modifyAckDeadline(ctx, ackIDs, pubsubAckDeadline); err != nil {
+			shared.LogF("failed to extend ack deadline: %v\n", err)
+		}
+	}
+}
+
+//release removes message from in flight messages
+func (s *pubsubSource) release(ackID string) {
+	s.mux.Lock()
+	delete(s.inFlight, ackID)
+	s.mux.Unlock()
+}
+
+//Commit acknowledges message
+func (s *pubsubSource) Commit(ctx context.Context, event *Event) error {
+	ackID := event.Handle.(string)
+	s.release(ackID)
+	return s.acknowledge(ctx, ackID)
+}
+
+func (s *pubsubSource) acknowledge(ctx context.Context, ackID string) error {
+	call := s.service.Projects.Subscriptions.Acknowledge(s.subscription, &pubsub.AcknowledgeRequest{AckIds: []string{ackID}})
+	call.Context(ctx)
+	return base.RunWithRetries(func() error {
+		_, err := call.Do()
+		return err
+	})
+}
+
+//Reject resets message ack deadline, so that it is redelivered immediately
+func (s *pubsubSource) Reject(ctx context.Context, event *Event) error {
+	ackID := event.Handle.(string)
+	s.release(ackID)
+	return s.modifyAckDeadline(ctx, []string{ackID}, 0)
+}
+
+func (s *pubsubSource) modifyAckDeadline(ctx context.Context, ackIDs []string, deadline time.Duration) error {
+	call := s.service.Projects.Subscriptions.ModifyAckDeadline(s.subscription, &pubsub.ModifyAckDeadlineRequest{
+		AckIds:             ackIDs,
+		AckDeadlineSeconds: int64(deadline / time.Second),
+		ForceSendFields:    []string{"AckDeadlineSeconds"},
+	})
+	call.Context(ctx)
+	return base.RunWithRetries(func() error {
+		_, err := call.Do()
+		return err
+	})
+}
+
+//Close stops in flight messages ack deadline extension
+func (s *pubsubSource) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+	return nil
+}
+
+func subscriptionName(projectID, subscription string) string {
+	if strings.Contains(subscription, "/") {
+		return subscription
+	}
+	return fmt.Sprintf("projects/%v/subscriptions/%v", projectID, subscription)
+}
+
+//NewPubSub creates Pub/Sub pull subscription event source
+func NewPubSub(service *pubsub.Service, projectID, subscription string, maxEvents int) EventSource {
+	return &pubsubSource{
+		service:        service,
+		subscription:   subscriptionName(projectID, subscription),
+		maxEvents:      int64(maxEvents),
+		extendInterval: pubsubAckDeadline / 2,
+		inFlight:       make(map[string]bool),
+		closed:         make(chan bool),
+	}
+}
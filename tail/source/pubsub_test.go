@@ -0,0 +1,84 @@
+package source
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/api/pubsub/v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPubSubSource(t *testing.T) {
+	var mux sync.Mutex
+	var deadlines = make([]*pubsub.ModifyAckDeadlineRequest, 0)
+	var acknowledged = make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mux.Lock()
+		defer mux.Unlock()
+		switch {
+		case strings.HasSuffix(request.URL.Path, ":pull"):
+			messages := make([]*pubsub.ReceivedMessage, 0)
+			for i, value := range []string{"gs://b/f0.json", "gs://b/f1.json", "gs://b/f2.json"} {
+				messages = append(messages, &pubsub.ReceivedMessage{
+					AckId:   "ack" + string(rune('0'+i)),
+					Message: &pubsub.PubsubMessage{MessageId: "m" + string(rune('0'+i)), Data: base64.StdEncoding.EncodeToString([]byte(value))},
+				})
+			}
+			_ = json.NewEncoder(writer).Encode(&pubsub.PullResponse{ReceivedMessages: messages})
+			return
+		case strings.HasSuffix(request.URL.Path, ":modifyAckDeadline"):
+			modify := &pubsub.ModifyAckDeadlineRequest{}
+			_ = json.NewDecoder(request.Body).Decode(modify)
+			deadlines = append(deadlines, modify)
+		case strings.HasSuffix(request.URL.Path, ":acknowledge"):
+			acknowledge := &pubsub.AcknowledgeRequest{}
+			_ = json.NewDecoder(request.Body).Decode(acknowledge)
+			acknowledged = append(acknowledged, acknowledge.AckIds...)
+		}
+		_, _ = writer.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	service, err := pubsub.NewService(ctx, option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	if !assert.Nil(t, err) {
+		return
+	}
+	srv := NewPubSub(service, "p", "s", 10)
+	srv.(*pubsubSource).extendInterval = 10 * time.Millisecond
+	events, err := srv.Receive(ctx)
+	if !assert.Nil(t, err) || !assert.Equal(t, 3, len(events)) {
+		return
+	}
+	assert.Nil(t, srv.Commit(ctx, events[0]))
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, srv.Reject(ctx, events[1]))
+	assert.Nil(t, srv.Commit(ctx, events[2]))
+	assert.Nil(t, srv.Close())
+
+	mux.Lock()
+	defer mux.Unlock()
+	assert.EqualValues(t, []string{"ack0", "ack2"}, acknowledged)
+	if !assert.True(t, len(deadlines) > 2) {
+		return
+	}
+	assert.EqualValues(t, []string{"ack0", "ack1", "ack2"}, deadlines[0].AckIds, "received messages deadline")
+	assert.EqualValues(t, 60, deadlines[0].AckDeadlineSeconds)
+	extended := deadlines[1]
+	assert.ElementsMatch(t, []string{"ack1", "ack2"}, extended.AckIds, "in flight messages deadline")
+	assert.EqualValues(t, 60, extended.AckDeadlineSeconds)
+	var rejected = make([]string, 0)
+	for _, deadline := range deadlines {
+		if deadline.AckDeadlineSeconds == 0 {
+			rejected = append(rejected, deadline.AckIds...)
+		}
+	}
+	assert.EqualValues(t, []string{"ack1"}, rejected, "rejected message deadline")
+}
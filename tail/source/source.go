@@ -0,0 +1,81 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/bqtail/tail/contract"
+	"strings"
+	"time"
+)
+
+const (
+	//KindPubSub Pub/Sub pull subscription event source
+	KindPubSub = "pubsub"
+	//KindKafkaREST Kafka consumer event source using REST proxy v2 protocol (i.e. Confluent REST Proxy)
+	KindKafkaREST = "kafkarest"
+	//KindDir local directory watcher event source
+	KindDir = "dir"
+
+	bucketIDAttribute = "bucketId"
+	objectIDAttribute = "objectId"
+)
+
+//EventSource represents pluggable data file event source producing tail requests
+type EventSource interface {
+	//Receive returns next events, it blocks until events are available, poll timeout elapses or context is done
+	Receive(ctx context.Context) ([]*Event, error)
+	//Commit acknowledges event (message ack, offset commit), it is called once event has been processed
+	Commit(ctx context.Context, event *Event) error
+	//Reject rejects event, so that it is redelivered
+	Reject(ctx context.Context, event *Event) error
+	//Close releases event source resources
+	Close() error
+}
+
+//Event represents event source event
+type Event struct {
+	*contract.Request
+	//Handle event source specific event handle, i.e. ack ID or record offset
+	Handle interface{}
+}
+
+//message represents data file announcement message
+type message struct {
+	EventID   string
+	SourceURL string
+	Bucket    string `json:"bucket"`
+	Name      string `json:"name"`
+}
+
+//NewRequest creates tail request from message data and attributes, supported messages:
+//Google Storage notification (bucketId, objectId attributes or object JSON), {"SourceURL":"..."} JSON or plain data file URL
+func NewRequest(ID string, data []byte, attributes map[string]string, published time.Time) (*contract.Request, error) {
+	if published.IsZero() {
+		published = time.Now()
+	}
+	if bucket, object := attributes[bucketIDAttribute], attributes[objectIDAttribute]; bucket != "" && object != "" {
+		return contract.NewRequest(ID, (&contract.GSEvent{Bucket: bucket, Name: object}).URL(), published), nil
+	}
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "{") {
+		msg := &message{}
+		if err := json.Unmarshal([]byte(text), msg); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode message: %s", text)
+		}
+		if msg.EventID != "" {
+			ID = msg.EventID
+		}
+		if msg.SourceURL != "" {
+			return contract.NewRequest(ID, msg.SourceURL, published), nil
+		}
+		if msg.Bucket != "" && msg.Name != "" {
+			return contract.NewRequest(ID, (&contract.GSEvent{Bucket: msg.Bucket, Name: msg.Name}).URL(), published), nil
+		}
+	}
+	if strings.Contains(text, "://") && !strings.ContainsAny(text, " \n") {
+		return contract.NewRequest(ID, text, published), nil
+	}
+	return nil, fmt.Errorf("unsupported message: %s", text)
+}
@@ -0,0 +1,69 @@
+package source
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewRequest(t *testing.T) {
+
+	var useCases = []struct {
+		description string
+		ID          string
+		data        string
+		attributes  map[string]string
+		expectID    string
+		expectURL   string
+		hasError    bool
+	}{
+		{
+			description: "storage notification attributes",
+			ID:          "1",
+			data:        `{"kind":"storage#object"}`,
+			attributes:  map[string]string{"bucketId": "bucket", "objectId": "data/file1.json"},
+			expectID:    "1",
+			expectURL:   "gs://bucket/data/file1.json",
+		},
+		{
+			description: "storage object JSON",
+			ID:          "2",
+			data:        `{"bucket":"bucket","name":"data/file2.json"}`,
+			expectID:    "2",
+			expectURL:   "gs://bucket/data/file2.json",
+		},
+		{
+			description: "tail request JSON",
+			ID:          "3",
+			data:        `{"EventID":"e3","SourceURL":"s3://bucket/data/file3.json"}`,
+			expectID:    "e3",
+			expectURL:   "s3://bucket/data/file3.json",
+		},
+		{
+			description: "plain URL",
+			ID:          "4",
+			data:        " gs://bucket/data/file4.json\n",
+			expectID:    "4",
+			expectURL:   "gs://bucket/data/file4.json",
+		},
+		{
+			description: "unsupported message",
+			ID:          "5",
+			data:        "hello world",
+			hasError:    true,
+		},
+	}
+
+	for _, useCase := range useCases {
+		request, err := NewRequest(useCase.ID, []byte(useCase.data), useCase.attributes, time.Now())
+		if useCase.hasError {
+			assert.NotNil(t, err, useCase.description)
+			continue
+		}
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectID, request.EventID, useCase.description)
+		assert.EqualValues(t, useCase.expectURL, request.SourceURL, useCase.description)
+	}
+}
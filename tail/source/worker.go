@@ -0,0 +1,107 @@
+package source
+
+import (
+	"context"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail"
+	"github.com/viant/bqtail/tail/contract"
+	"sync"
+	"time"
+)
+
+const receiveErrorDelay = 5 * time.Second
+
+//Worker represents long lived tail worker consuming event source
+type Worker struct {
+	service     tail.Service
+	source      EventSource
+	concurrency int
+	//maxAttempts max data file processing attempts, once exceeded failed event is committed (0 - no limit)
+	maxAttempts int
+	attempts    map[string]int
+}
+
+//Run receives and tails events until context is done, event is committed only once tail response is not an error, otherwise it is rejected for redelivery
+func (w *Worker) Run(ctx context.Context) error {
+	defer w.source.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		events, err := w.source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			shared.LogF("failed to receive events: %v\n", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(receiveErrorDelay):
+			}
+			continue
+		}
+		if len(events) == 0 {
+			continue
+		}
+		responses := w.tail(ctx, events)
+		for i, event := range events {
+			if err = w.complete(ctx, event, responses[i]); err != nil {
+				shared.LogF("failed to complete event %v: %v\n", event.EventID, err)
+			}
+		}
+	}
+}
+
+func (w *Worker) tail(ctx context.Context, events []*Event) []*contract.Response {
+	var responses = make([]*contract.Response, len(events))
+	limiter := make(chan bool, w.concurrency)
+	waitGroup := &sync.WaitGroup{}
+	for i := range events {
+		events[i].Attempt = w.attempts[events[i].SourceURL]
+		waitGroup.Add(1)
+		limiter <- true
+		go func(i int) {
+			defer func() {
+				<-limiter
+				waitGroup.Done()
+			}()
+			responses[i] = w.service.Tail(ctx, events[i].Request)
+		}(i)
+	}
+	waitGroup.Wait()
+	return responses
+}
+
+//complete commits or rejects event in receive order, so that offsets are never committed past a failed event
+func (w *Worker) complete(ctx context.Context, event *Event, response *contract.Response) error {
+	if shared.IsInfoLoggingLevel() {
+		shared.LogLn(response)
+	}
+	if response.Status != shared.StatusError {
+		delete(w.attempts, event.SourceURL)
+		return w.source.Commit(ctx, event)
+	}
+	w.attempts[event.SourceURL]++
+	if w.maxAttempts > 0 && w.attempts[event.SourceURL] >= w.maxAttempts {
+		shared.LogF("giving up event %v: %v after %v attempts: %v\n", event.EventID, event.SourceURL, w.attempts[event.SourceURL], response.Error)
+		delete(w.attempts, event.SourceURL)
+		return w.source.Commit(ctx, event)
+	}
+	return w.source.Reject(ctx, event)
+}
+
+//NewWorker creates tail worker
+func NewWorker(service tail.Service, source EventSource, concurrency, maxAttempts int) *Worker {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Worker{
+		service:     service,
+		source:      source,
+		concurrency: concurrency,
+		maxAttempts: maxAttempts,
+		attempts:    make(map[string]int),
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 )
 
+const rowNumberColumn = "_row_number"
+
 func buildNestedDedupeSQL(sourceTable string, schema Schema, dest *config.Destination, except map[string]bool) string {
 	projection := make([]string, 0)
 	outerProjection := make([]string, 0)
@@ -41,10 +43,40 @@ func buildNestedDedupeSQL(sourceTable string, schema Schema, dest *config.Destin
 FROM (
   SELECT
       %v,
-      ROW_NUMBER() OVER (PARTITION BY %v) row_number
+      ROW_NUMBER() OVER (PARTITION BY %v%v) row_number
   FROM %v $WHERE
 ) %v $JOIN
-WHERE row_number = 1`, strings.Join(outerProjection, ", "), strings.Join(projection, ", "), strings.Join(dest.UniqueColumns, ","), sourceTable, dest.Transient.Alias)
+WHERE row_number = 1`, strings.Join(outerProjection, ", "), strings.Join(projection, ", "), strings.Join(dest.UniqueColumns, ","), windowOrder(dest.DedupeOrderBy, ""), sourceTable, dest.Transient.Alias)
+}
+
+func windowOrder(orderBy *config.OrderBy, alias string) string {
+	if orderBy == nil {
+		return ""
+	}
+	return " ORDER BY " + orderBy.Expression(alias)
+}
+
+//buildFirstRowSQL returns projection of the first row per unique key in window order
+func buildFirstRowSQL(sourceTable string, projection []string, dest *config.Destination, order string) string {
+	var keys = make([]string, 0)
+	for _, column := range dest.UniqueColumns {
+		if expression, ok := dest.Transform[column]; ok {
+			keys = append(keys, expression)
+			continue
+		}
+		keys = append(keys, dest.Transient.Alias+"."+column)
+	}
+	window := "PARTITION BY " + strings.Join(keys, ", ")
+	if order != "" {
+		window += " ORDER BY " + order
+	}
+	projection = append(projection, fmt.Sprintf("ROW_NUMBER() OVER (%v) AS %v", window, rowNumberColumn))
+	return fmt.Sprintf(`SELECT * EXCEPT(%v)
+FROM (
+  SELECT %v
+  FROM %v %v $JOIN $WHERE
+)
+WHERE %v = 1`, rowNumberColumn, strings.Join(projection, ", "), sourceTable, dest.Transient.Alias, rowNumberColumn)
 }
 
 func getTransform(dest *config.Destination) (map[string]string, []string) {
@@ -86,6 +118,10 @@ func columnNames(schema *bigquery.TableSchema, dest *config.Destination, except
 }
 
 func buildDedupeSQL(sourceTable string, schema Schema, unique map[string]bool, dest *config.Destination, except map[string]bool) string {
+	if dest.DedupeOrderBy != nil {
+		projection := selectAllProjection(schema, dest, except)
+		return buildFirstRowSQL(sourceTable, projection, dest, dest.DedupeOrderBy.Expression(dest.Transient.Alias))
+	}
 	var projection = make([]string, 0)
 	var groupBy = make([]string, 0)
 
@@ -128,6 +164,16 @@ GROUP BY %v`,
 }
 
 func buildSelectAll(sourceTable string, schema Schema, dest *config.Destination, except map[string]bool) string {
+	projection := selectAllProjection(schema, dest, except)
+	return fmt.Sprintf(`SELECT %v 
+FROM %v %v $JOIN $WHERE`,
+		strings.Join(projection, ", "),
+		sourceTable,
+		dest.Transient.Alias,
+	)
+}
+
+func selectAllProjection(schema Schema, dest *config.Destination, except map[string]bool) []string {
 	var projection = make([]string, 0)
 	transform, transformKeys := getTransform(dest)
 	for _, field := range schema.Fields {
@@ -148,13 +194,7 @@ func buildSelectAll(sourceTable string, schema Schema, dest *config.Destination,
 		}
 		projection = append(projection, fmt.Sprintf("%v AS %v", expression, key))
 	}
-
-	return fmt.Sprintf(`SELECT %v 
-FROM %v %v $JOIN $WHERE`,
-		strings.Join(projection, ", "),
-		sourceTable,
-		dest.Transient.Alias,
-	)
+	return projection
 }
 
 func getTransformExpression(dest *config.Destination, field *bigquery.TableFieldSchema) (string, bool) {
@@ -167,7 +207,7 @@ func getTransformExpression(dest *config.Destination, field *bigquery.TableField
 
 //BuildAppendDML returns INSERT INTO table () SELECT ...
 func BuildAppendDML(source, destination *bigquery.TableReference, sourceSchema *bigquery.TableSchema, dest *config.Destination, destSchema *bigquery.TableSchema) string {
-	selectALL := BuildSelect(source, sourceSchema, dest, destSchema)
+	selectALL := BuildSelect(source, destination, sourceSchema, dest, destSchema)
 	except := columnExclusion(sourceSchema, destSchema)
 	columns := columnNames(sourceSchema, dest, except)
 	destTable := base.EncodeTableReference(destination, true)
@@ -175,10 +215,15 @@ func BuildAppendDML(source, destination *bigquery.TableReference, sourceSchema *
 	return result
 }
 
-//BuildSelect returns select SQL statement for specified parameter, if uniqueColumns SQL de-duplicates data
-func BuildSelect(source *bigquery.TableReference, sourceSchema *bigquery.TableSchema, dest *config.Destination, destSchema *bigquery.TableSchema) string {
+//BuildSelect returns select SQL statement for specified parameter, if uniqueColumns SQL de-duplicates data,
+//if dest.Dedupe is set and destination specified, rows already loaded to destination within lookback are excluded
+func BuildSelect(source, destination *bigquery.TableReference, sourceSchema *bigquery.TableSchema, dest *config.Destination, destSchema *bigquery.TableSchema) string {
 	except := columnExclusion(sourceSchema, destSchema)
-	SQL := buildSelect(source, sourceSchema, dest, except)
+	sourceTable := "`" + base.EncodeTableReference(source, true) + "`"
+	if dest.Dedupe != nil && destination != nil {
+		sourceTable = buildLookbackSource(sourceTable, destination, dest, destSchema)
+	}
+	SQL := buildSelect(sourceTable, sourceSchema, dest, except)
 	join := buildJoins(dest.SideInputs)
 	SQL = strings.Replace(SQL, "$JOIN", join, 1)
 	return SQL
@@ -220,8 +265,7 @@ func buildJoins(sideInputs []*config.SideInput) string {
 }
 
 //buildSelect returns select SQL statement for specified parameter, if uniqueColumns SQL de-duplicates data
-func buildSelect(source *bigquery.TableReference, tableScheme *bigquery.TableSchema, dest *config.Destination, except map[string]bool) string {
-	sourceTable := "`" + base.EncodeTableReference(source, true) + "`"
+func buildSelect(sourceTable string, tableScheme *bigquery.TableSchema, dest *config.Destination, except map[string]bool) string {
 	schema := Schema(*tableScheme)
 	if len(dest.UniqueColumns) == 0 {
 		return buildSelectAll(sourceTable, schema, dest, except)
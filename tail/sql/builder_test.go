@@ -0,0 +1,127 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+)
+
+func TestBuildDedupeSQL(t *testing.T) {
+	schema := Schema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INT64"},
+		{Name: "name", Type: "STRING"},
+		{Name: "updated", Type: "TIMESTAMP"},
+	}}
+	var useCases = []struct {
+		description string
+		dest        *config.Destination
+		expectSQL   string
+		expect      []string
+	}{
+		{
+			description: "arbitrary MAX aggregation",
+			dest:        &config.Destination{UniqueColumns: []string{"id"}},
+			expectSQL: "SELECT id, MAX(t.name) AS name, MAX(t.updated) AS updated \n" +
+				"FROM `p.ds.events` t $JOIN\n" +
+				"$WHERE\n" +
+				"GROUP BY 1",
+		},
+		{
+			description: "latest row wins",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				DedupeOrderBy: &config.OrderBy{Column: "updated"},
+			},
+			expectSQL: "SELECT * EXCEPT(_row_number)\n" +
+				"FROM (\n" +
+				"  SELECT t.id AS id, t.name AS name, t.updated AS updated, ROW_NUMBER() OVER (PARTITION BY t.id ORDER BY t.updated DESC) AS _row_number\n" +
+				"  FROM `p.ds.events` t $JOIN $WHERE\n" +
+				")\n" +
+				"WHERE _row_number = 1",
+		},
+		{
+			description: "earliest row wins with transform",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				DedupeOrderBy: &config.OrderBy{Column: "updated", Direction: "asc"},
+				Transform:     map[string]string{"name": "UPPER(t.name)"},
+			},
+			expect: []string{
+				"SELECT t.id AS id, UPPER(t.name) AS name, t.updated AS updated, ROW_NUMBER() OVER (PARTITION BY t.id ORDER BY t.updated ASC) AS _row_number",
+			},
+		},
+	}
+
+	for _, useCase := range useCases {
+		dest := useCase.dest
+		dest.Transient = &config.Transient{Alias: "t"}
+		unique := map[string]bool{}
+		for _, column := range dest.UniqueColumns {
+			unique[column] = true
+		}
+		actual := buildDedupeSQL("`p.ds.events`", schema, unique, dest, map[string]bool{})
+		if useCase.expectSQL != "" {
+			assert.EqualValues(t, useCase.expectSQL, actual, useCase.description)
+		}
+		for _, expect := range useCase.expect {
+			assert.True(t, strings.Contains(actual, expect), useCase.description+": "+expect+"\n"+actual)
+		}
+	}
+}
+
+func TestBuildNestedDedupeSQL(t *testing.T) {
+	schema := Schema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INT64"},
+		{Name: "tags", Type: "STRING", Mode: "REPEATED"},
+		{Name: "updated", Type: "TIMESTAMP"},
+	}}
+	var useCases = []struct {
+		description string
+		dest        *config.Destination
+		expectSQL   string
+		expect      []string
+	}{
+		{
+			description: "arbitrary row",
+			dest:        &config.Destination{UniqueColumns: []string{"id"}},
+			expect:      []string{"ROW_NUMBER() OVER (PARTITION BY id) row_number"},
+		},
+		{
+			description: "latest row wins",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				DedupeOrderBy: &config.OrderBy{Column: "updated", Direction: "DESC"},
+			},
+			expectSQL: "SELECT t.id AS id, t.tags AS tags, t.updated AS updated\n" +
+				"FROM (\n" +
+				"  SELECT\n" +
+				"      id, tags, updated,\n" +
+				"      ROW_NUMBER() OVER (PARTITION BY id ORDER BY updated DESC) row_number\n" +
+				"  FROM `p.ds.events` $WHERE\n" +
+				") t $JOIN\n" +
+				"WHERE row_number = 1",
+		},
+		{
+			description: "earliest row wins",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				DedupeOrderBy: &config.OrderBy{Column: "updated", Direction: "ASC"},
+			},
+			expect: []string{"ROW_NUMBER() OVER (PARTITION BY id ORDER BY updated ASC) row_number"},
+		},
+	}
+
+	for _, useCase := range useCases {
+		dest := useCase.dest
+		dest.Transient = &config.Transient{Alias: "t"}
+		actual := buildNestedDedupeSQL("`p.ds.events`", schema, dest, map[string]bool{})
+		if useCase.expectSQL != "" {
+			assert.EqualValues(t, useCase.expectSQL, actual, useCase.description)
+		}
+		for _, expect := range useCase.expect {
+			assert.True(t, strings.Contains(actual, expect), useCase.description+": "+expect+"\n"+actual)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"fmt"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+)
+
+const (
+	lookbackDestAlias   = "d"
+	lookbackSourceAlias = "s"
+)
+
+//buildLookbackSource returns source table subquery excluding rows already loaded to destination within Dest.Dedupe lookback
+func buildLookbackSource(sourceTable string, destination *bigquery.TableReference, dest *config.Destination, destSchema *bigquery.TableSchema) string {
+	dedupe := dest.Dedupe
+	destRef := *destination
+	destRef.TableId = base.TableID(destRef.TableId)
+	destTable := "`" + base.EncodeTableReference(&destRef, true) + "`"
+
+	var criteria = make([]string, 0)
+	for _, column := range dedupe.Columns {
+		criteria = append(criteria, fmt.Sprintf("%v.%v = %v.%v", lookbackDestAlias, column, lookbackSourceAlias, column))
+	}
+	if lookback := lookbackCriteria(dedupe, base.TablePartition(destination.TableId), destSchema); lookback != "" {
+		criteria = append(criteria, "("+lookback+")")
+	}
+	return fmt.Sprintf("(SELECT %v.* FROM %v %v WHERE NOT EXISTS (SELECT 1 FROM %v %v WHERE %v))",
+		lookbackSourceAlias, sourceTable, lookbackSourceAlias, destTable, lookbackDestAlias, strings.Join(criteria, " AND "))
+}
+
+//lookbackCriteria returns destination lookback criteria, partition lookback without partition decorator scans the whole table
+func lookbackCriteria(dedupe *config.LookbackDedupe, partition string, destSchema *bigquery.TableSchema) string {
+	column := lookbackDestAlias + "." + dedupe.Column
+	dataType := "TIMESTAMP"
+	if destSchema != nil && dedupe.Column != config.PartitionTimeColumn {
+		for _, field := range destSchema.Fields {
+			if strings.EqualFold(field.Name, dedupe.Column) {
+				dataType = strings.ToUpper(field.Type)
+			}
+		}
+	}
+	if dedupe.IsPartition() {
+		return partitionCriteria(column, dataType, partition)
+	}
+	if value, unit, ok := dedupe.Duration(); ok {
+		switch dataType {
+		case "DATE":
+			days := value
+			switch unit {
+			case "HOUR":
+				days = (value + 23) / 24
+			case "MINUTE":
+				days = (value + 1439) / 1440
+			}
+			return fmt.Sprintf("%v >= DATE_SUB(CURRENT_DATE(), INTERVAL %v DAY)", column, days)
+		case "DATETIME":
+			return fmt.Sprintf("%v >= DATETIME_SUB(CURRENT_DATETIME(), INTERVAL %v %v)", column, value, unit)
+		}
+		return fmt.Sprintf("%v >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL %v %v)", column, value, unit)
+	}
+	return dedupe.Lookback
+}
+
+//partitionCriteria returns criteria scoping column to day (YYYYMMDD) or hour (YYYYMMDDHH) partition
+func partitionCriteria(column, dataType, partition string) string {
+	if len(partition) < 8 {
+		return ""
+	}
+	unit := "DAY"
+	value := partition[:4] + "-" + partition[4:6] + "-" + partition[6:8]
+	if len(partition) >= 10 {
+		unit = "HOUR"
+		value += " " + partition[8:10] + ":00:00"
+	}
+	switch dataType {
+	case "DATE":
+		return fmt.Sprintf("%v = '%v'", column, value[:10])
+	case "DATETIME":
+		return fmt.Sprintf("%v >= DATETIME('%v') AND %v < DATETIME_ADD(DATETIME('%v'), INTERVAL 1 %v)", column, value, column, value, unit)
+	}
+	return fmt.Sprintf("%v >= TIMESTAMP('%v') AND %v < TIMESTAMP_ADD(TIMESTAMP('%v'), INTERVAL 1 %v)", column, value, column, value, unit)
+}
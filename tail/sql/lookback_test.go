@@ -0,0 +1,78 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+)
+
+func TestBuildSelect_Lookback(t *testing.T) {
+	source := &bigquery.TableReference{ProjectId: "p", DatasetId: "temp", TableId: "events_123"}
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INT64"},
+		{Name: "name", Type: "STRING"},
+		{Name: "event_date", Type: "DATE"},
+	}}
+
+	var useCases = []struct {
+		description string
+		destination *bigquery.TableReference
+		dedupe      *config.LookbackDedupe
+		expectSQL   string
+		expect      []string
+	}{
+		{
+			description: "same partition lookback",
+			destination: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events$20200101"},
+			dedupe:      &config.LookbackDedupe{Lookback: "partition"},
+			expectSQL: "SELECT id, MAX(t.name) AS name, MAX(t.event_date) AS event_date \n" +
+				"FROM (SELECT s.* FROM `p.temp.events_123` s WHERE NOT EXISTS (SELECT 1 FROM `p.ds.events` d WHERE d.id = s.id AND (d._PARTITIONTIME >= TIMESTAMP('2020-01-01') AND d._PARTITIONTIME < TIMESTAMP_ADD(TIMESTAMP('2020-01-01'), INTERVAL 1 DAY)))) t \n" +
+				"$WHERE\n" +
+				"GROUP BY 1",
+		},
+		{
+			description: "last N hours lookback on date column",
+			destination: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events"},
+			dedupe:      &config.LookbackDedupe{Lookback: "36h", Column: "event_date"},
+			expect:      []string{"d.id = s.id AND (d.event_date >= DATE_SUB(CURRENT_DATE(), INTERVAL 2 DAY))"},
+		},
+		{
+			description: "last N hours lookback on partition time",
+			destination: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events"},
+			dedupe:      &config.LookbackDedupe{Lookback: "6 hours"},
+			expect:      []string{"(d._PARTITIONTIME >= TIMESTAMP_SUB(CURRENT_TIMESTAMP(), INTERVAL 6 HOUR))"},
+		},
+		{
+			description: "custom lookback criteria",
+			destination: &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events"},
+			dedupe:      &config.LookbackDedupe{Lookback: "d.event_date > '2020-01-01'", Columns: []string{"id", "name"}},
+			expect:      []string{"d.id = s.id AND d.name = s.name AND (d.event_date > '2020-01-01')"},
+		},
+		{
+			description: "no destination",
+			dedupe:      &config.LookbackDedupe{Lookback: "partition"},
+			expectSQL: "SELECT id, MAX(t.name) AS name, MAX(t.event_date) AS event_date \n" +
+				"FROM `p.temp.events_123` t \n" +
+				"$WHERE\n" +
+				"GROUP BY 1",
+		},
+	}
+
+	for _, useCase := range useCases {
+		dest := &config.Destination{
+			UniqueColumns: []string{"id"},
+			Transient:     &config.Transient{Alias: "t"},
+			Dedupe:        useCase.dedupe,
+		}
+		dest.Dedupe.Init(dest.UniqueColumns)
+		actual := BuildSelect(source, useCase.destination, schema, dest, schema)
+		if useCase.expectSQL != "" {
+			assert.EqualValues(t, useCase.expectSQL, actual, useCase.description)
+		}
+		for _, expect := range useCase.expect {
+			assert.True(t, strings.Contains(actual, expect), useCase.description+": "+expect+"\n"+actual)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"fmt"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/tail/config"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"time"
+)
+
+const (
+	mergeDestAlias    = "d"
+	mergeSourceAlias  = "s"
+	mergeDeleteColumn = "_merge_deleted"
+	mergeTimeColumn   = "_PARTITIONTIME"
+)
+
+//BuildMergeDML returns MERGE statement upserting dest table rows by unique columns, rows matching Transient.Merge.Delete are removed,
+//for ingestion time partitioned dest table with partition decorator, rows are matched and inserted within decorator partition
+func BuildMergeDML(source, destination *bigquery.TableReference, sourceSchema *bigquery.TableSchema, dest *config.Destination, destSchema *bigquery.TableSchema, ingestionTimePartitioned bool) string {
+	except := columnExclusion(sourceSchema, destSchema)
+	columns := columnNames(sourceSchema, dest, except)
+	using := buildMergeSource(source, sourceSchema, dest, except)
+	using = strings.Replace(using, "$JOIN", buildJoins(dest.SideInputs), 1)
+
+	//MERGE does not support partition decorator, dest partition pruning is only applied with decorator partition time,
+	//Transient.Criteria filters USING source rows ($WHERE), dest rows outside criteria still have to match, otherwise their keys would be inserted again
+	destRef := *destination
+	destRef.TableId = base.TableID(destRef.TableId)
+	destTable := "`" + base.EncodeTableReference(&destRef, true) + "`"
+	partitionTime := ""
+	if ingestionTimePartitioned {
+		partitionTime = partitionTimestamp(base.TablePartition(destination.TableId))
+	}
+
+	var on = make([]string, 0)
+	for _, column := range dest.UniqueColumns {
+		on = append(on, fmt.Sprintf("%v.%v = %v.%v", mergeSourceAlias, column, mergeDestAlias, column))
+	}
+	if partitionTime != "" {
+		on = append(on, fmt.Sprintf("%v.%v = %v", mergeDestAlias, mergeTimeColumn, partitionTime))
+	}
+	var updates = make([]string, 0)
+	var values = make([]string, 0)
+	for _, column := range columns {
+		values = append(values, mergeSourceAlias+"."+column)
+		if isUniqueColumn(dest, column) {
+			continue
+		}
+		updates = append(updates, fmt.Sprintf("%v = %v.%v", column, mergeSourceAlias, column))
+	}
+	hasDelete := dest.Transient.Merge != nil && dest.Transient.Merge.Delete != ""
+
+	var clauses = make([]string, 0)
+	if hasDelete {
+		clauses = append(clauses, fmt.Sprintf("WHEN MATCHED AND %v.%v THEN DELETE", mergeSourceAlias, mergeDeleteColumn))
+	}
+	if len(updates) > 0 {
+		clauses = append(clauses, fmt.Sprintf("WHEN MATCHED THEN UPDATE SET %v", strings.Join(updates, ", ")))
+	}
+	insertCondition := ""
+	if hasDelete {
+		insertCondition = fmt.Sprintf(" AND NOT %v.%v", mergeSourceAlias, mergeDeleteColumn)
+	}
+	if partitionTime != "" {
+		columns = append([]string{mergeTimeColumn}, columns...)
+		values = append([]string{partitionTime}, values...)
+	}
+	clauses = append(clauses, fmt.Sprintf("WHEN NOT MATCHED%v THEN INSERT(%v) VALUES(%v)", insertCondition, strings.Join(columns, ","), strings.Join(values, ",")))
+
+	return fmt.Sprintf(`MERGE %v %v
+USING (
+%v
+) %v
+ON %v
+%v`, destTable, mergeDestAlias, using, mergeSourceAlias, strings.Join(on, " AND "), strings.Join(clauses, "\n"))
+}
+
+//buildMergeSource returns source rows with the latest version per unique key
+func buildMergeSource(source *bigquery.TableReference, tableScheme *bigquery.TableSchema, dest *config.Destination, except map[string]bool) string {
+	sourceTable := "`" + base.EncodeTableReference(source, true) + "`"
+	projection := selectAllProjection(Schema(*tableScheme), dest, except)
+	merge := dest.Transient.Merge
+	if merge != nil && merge.Delete != "" {
+		projection = append(projection, fmt.Sprintf("IFNULL(%v, FALSE) AS %v", merge.Delete, mergeDeleteColumn))
+	}
+	order := merge.OrderByExpression()
+	if order == "" && dest.DedupeOrderBy != nil {
+		order = dest.DedupeOrderBy.Expression(dest.Transient.Alias)
+	}
+	SQL := buildFirstRowSQL(sourceTable, projection, dest, order)
+	return "  " + strings.Replace(SQL, "\n", "\n  ", -1)
+}
+
+//partitionTimestamp returns TIMESTAMP literal for hour, day, month or year partition decorator, or empty string
+func partitionTimestamp(partition string) string {
+	for _, layout := range []string{"2006010215", "20060102", "200601", "2006"} {
+		if len(partition) != len(layout) {
+			continue
+		}
+		if ts, err := time.Parse(layout, partition); err == nil {
+			return fmt.Sprintf("TIMESTAMP('%v')", ts.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return ""
+}
+
+func isUniqueColumn(dest *config.Destination, column string) bool {
+	for _, candidate := range dest.UniqueColumns {
+		if strings.EqualFold(candidate, column) {
+			return true
+		}
+	}
+	return false
+}
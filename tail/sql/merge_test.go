@@ -0,0 +1,151 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/tail/config/transient"
+	"google.golang.org/api/bigquery/v2"
+	"strings"
+	"testing"
+)
+
+func TestBuildMergeDML(t *testing.T) {
+	source := &bigquery.TableReference{ProjectId: "p", DatasetId: "temp", TableId: "events_123"}
+	destination := &bigquery.TableReference{ProjectId: "p", DatasetId: "ds", TableId: "events$20200101"}
+	schema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INT64"},
+		{Name: "name", Type: "STRING"},
+		{Name: "updated", Type: "TIMESTAMP"},
+		{Name: "op", Type: "STRING"},
+	}}
+	destSchema := &bigquery.TableSchema{Fields: []*bigquery.TableFieldSchema{
+		{Name: "id", Type: "INT64"},
+		{Name: "name", Type: "STRING"},
+		{Name: "updated", Type: "TIMESTAMP"},
+	}}
+
+	var useCases = []struct {
+		description   string
+		dest          *config.Destination
+		ingestionTime bool
+		expectSQL     string
+		expect        []string
+	}{
+		{
+			description: "upsert by key",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				Transient:     &config.Transient{Alias: "t"},
+			},
+			expectSQL: "MERGE `p.ds.events` d\n" +
+				"USING (\n" +
+				"  SELECT * EXCEPT(_row_number)\n" +
+				"  FROM (\n" +
+				"    SELECT t.id AS id, t.name AS name, t.updated AS updated, ROW_NUMBER() OVER (PARTITION BY t.id) AS _row_number\n" +
+				"    FROM `p.temp.events_123` t  $WHERE\n" +
+				"  )\n" +
+				"  WHERE _row_number = 1\n" +
+				") s\n" +
+				"ON s.id = d.id\n" +
+				"WHEN MATCHED THEN UPDATE SET name = s.name, updated = s.updated\n" +
+				"WHEN NOT MATCHED THEN INSERT(id,name,updated) VALUES(s.id,s.name,s.updated)",
+		},
+		{
+			description: "latest version with tombstones and partition pruning",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				Transient: &config.Transient{
+					Alias:    "t",
+					Criteria: "DATE(t.updated) = '2020-01-01'",
+					Merge:    &transient.Merge{OrderBy: "t.updated", Delete: "t.op = 'D'"},
+				},
+			},
+			expect: []string{
+				"IFNULL(t.op = 'D', FALSE) AS _merge_deleted",
+				"ROW_NUMBER() OVER (PARTITION BY t.id ORDER BY t.updated DESC) AS _row_number",
+				"FROM `p.temp.events_123` t  $WHERE",
+				"ON s.id = d.id\nWHEN MATCHED AND s._merge_deleted THEN DELETE",
+				"WHEN MATCHED AND s._merge_deleted THEN DELETE",
+				"WHEN NOT MATCHED AND NOT s._merge_deleted THEN INSERT(id,name,updated)",
+			},
+		},
+		{
+			description: "criteria applied to source only, dest key outside criteria stays matched",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				Transient:     &config.Transient{Alias: "t", Criteria: "DATE(t.updated) = '2020-01-01'"},
+			},
+			expectSQL: "MERGE `p.ds.events` d\n" +
+				"USING (\n" +
+				"  SELECT * EXCEPT(_row_number)\n" +
+				"  FROM (\n" +
+				"    SELECT t.id AS id, t.name AS name, t.updated AS updated, ROW_NUMBER() OVER (PARTITION BY t.id) AS _row_number\n" +
+				"    FROM `p.temp.events_123` t  $WHERE\n" +
+				"  )\n" +
+				"  WHERE _row_number = 1\n" +
+				") s\n" +
+				"ON s.id = d.id\n" +
+				"WHEN MATCHED THEN UPDATE SET name = s.name, updated = s.updated\n" +
+				"WHEN NOT MATCHED THEN INSERT(id,name,updated) VALUES(s.id,s.name,s.updated)",
+		},
+		{
+			description: "explicit ordering direction",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				Transient: &config.Transient{
+					Alias: "t",
+					Merge: &transient.Merge{OrderBy: "t.updated ASC"},
+				},
+			},
+			expect: []string{"ORDER BY t.updated ASC)"},
+		},
+		{
+			description: "ingestion time partitioned dest with partition decorator",
+			dest: &config.Destination{
+				UniqueColumns: []string{"id"},
+				Transient:     &config.Transient{Alias: "t"},
+			},
+			ingestionTime: true,
+			expectSQL: "MERGE `p.ds.events` d\n" +
+				"USING (\n" +
+				"  SELECT * EXCEPT(_row_number)\n" +
+				"  FROM (\n" +
+				"    SELECT t.id AS id, t.name AS name, t.updated AS updated, ROW_NUMBER() OVER (PARTITION BY t.id) AS _row_number\n" +
+				"    FROM `p.temp.events_123` t  $WHERE\n" +
+				"  )\n" +
+				"  WHERE _row_number = 1\n" +
+				") s\n" +
+				"ON s.id = d.id AND d._PARTITIONTIME = TIMESTAMP('2020-01-01 00:00:00')\n" +
+				"WHEN MATCHED THEN UPDATE SET name = s.name, updated = s.updated\n" +
+				"WHEN NOT MATCHED THEN INSERT(_PARTITIONTIME,id,name,updated) VALUES(TIMESTAMP('2020-01-01 00:00:00'),s.id,s.name,s.updated)",
+		},
+	}
+
+	for _, useCase := range useCases {
+		actual := BuildMergeDML(source, destination, schema, useCase.dest, destSchema, useCase.ingestionTime)
+		if useCase.expectSQL != "" {
+			assert.EqualValues(t, useCase.expectSQL, actual, useCase.description)
+		}
+		for _, expect := range useCase.expect {
+			assert.True(t, strings.Contains(actual, expect), useCase.description+": "+expect+"\n"+actual)
+		}
+	}
+}
+
+func TestPartitionTimestamp(t *testing.T) {
+	var useCases = []struct {
+		description string
+		partition   string
+		expect      string
+	}{
+		{description: "hour partition", partition: "2020010112", expect: "TIMESTAMP('2020-01-01 12:00:00')"},
+		{description: "day partition", partition: "20200101", expect: "TIMESTAMP('2020-01-01 00:00:00')"},
+		{description: "month partition", partition: "202001", expect: "TIMESTAMP('2020-01-01 00:00:00')"},
+		{description: "year partition", partition: "2020", expect: "TIMESTAMP('2020-01-01 00:00:00')"},
+		{description: "no partition", partition: ""},
+		{description: "range partition", partition: "abc"},
+	}
+	for _, useCase := range useCases {
+		assert.EqualValues(t, useCase.expect, partitionTimestamp(useCase.partition), useCase.description)
+	}
+}
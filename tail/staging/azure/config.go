@@ -0,0 +1,152 @@
+package azure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	accountEnvKey  = "AZURE_STORAGE_ACCOUNT"
+	keyEnvKey      = "AZURE_STORAGE_KEY"
+	sasTokenEnvKey = "AZURE_STORAGE_SAS_TOKEN"
+	endpointEnvKey = "AZURE_STORAGE_ENDPOINT"
+
+	apiVersion    = "2020-10-02"
+	headerDate    = "x-ms-date"
+	headerVersion = "x-ms-version"
+	headerPrefix  = "x-ms-"
+)
+
+//Config represents Azure Blob storage account settings
+type Config struct {
+	//Account storage account name
+	Account string
+	//Key base64 encoded storage account shared key
+	Key string `json:",omitempty"`
+	//SASToken shared access signature query string, used instead of Key
+	SASToken string `json:",omitempty"`
+	//Endpoint blob service endpoint, https://$Account.blob.core.windows.net by default
+	Endpoint string `json:",omitempty"`
+}
+
+//BaseURL returns blob service endpoint
+func (c *Config) BaseURL() string {
+	if c.Endpoint != "" {
+		return strings.TrimRight(c.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://%v.blob.core.windows.net", c.Account)
+}
+
+//Validate checks if config is valid
+func (c *Config) Validate() error {
+	if c.Account == "" {
+		return errors.Errorf("azure storage account was empty, set %v env variable", accountEnvKey)
+	}
+	if c.Key == "" && c.SASToken == "" {
+		return errors.Errorf("azure storage credentials were empty, set %v or %v env variable", keyEnvKey, sasTokenEnvKey)
+	}
+	return nil
+}
+
+//authorize adds SAS token or shared key signature to the request
+func (c *Config) authorize(request *http.Request) error {
+	request.Header.Set(headerVersion, apiVersion)
+	if c.SASToken != "" {
+		query := strings.TrimPrefix(c.SASToken, "?")
+		if request.URL.RawQuery != "" {
+			query = request.URL.RawQuery + "&" + query
+		}
+		request.URL.RawQuery = query
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return errors.Wrapf(err, "invalid azure storage key")
+	}
+	request.Header.Set(headerDate, time.Now().UTC().Format(http.TimeFormat))
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign(c.Account, request)))
+	request.Header.Set("Authorization", fmt.Sprintf("SharedKey %v:%v", c.Account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return nil
+}
+
+//stringToSign returns shared key string to sign for blob service request
+func stringToSign(account string, request *http.Request) string {
+	contentLength := request.Header.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+	elements := []string{
+		request.Method,
+		request.Header.Get("Content-Encoding"),
+		request.Header.Get("Content-Language"),
+		contentLength,
+		request.Header.Get("Content-MD5"),
+		request.Header.Get("Content-Type"),
+		request.Header.Get("Date"),
+		request.Header.Get("If-Modified-Since"),
+		request.Header.Get("If-Match"),
+		request.Header.Get("If-None-Match"),
+		request.Header.Get("If-Unmodified-Since"),
+		request.Header.Get("Range"),
+	}
+	return strings.Join(elements, "\n") + "\n" + canonicalizedHeaders(request.Header) + canonicalizedResource(account, request.URL)
+}
+
+func canonicalizedHeaders(header http.Header) string {
+	var names = make([]string, 0)
+	var values = make(map[string]string)
+	for name, value := range header {
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, headerPrefix) {
+			continue
+		}
+		names = append(names, name)
+		values[name] = strings.TrimSpace(strings.Join(value, ","))
+	}
+	sort.Strings(names)
+	result := ""
+	for _, name := range names {
+		result += name + ":" + values[name] + "\n"
+	}
+	return result
+}
+
+func canonicalizedResource(account string, URL *url.URL) string {
+	resourcePath := URL.EscapedPath()
+	if resourcePath == "" {
+		resourcePath = "/"
+	}
+	result := "/" + account + resourcePath
+	query := URL.Query()
+	var names = make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		result += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+	return result
+}
+
+//NewConfigFromEnv creates config from AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN and optional AZURE_STORAGE_ENDPOINT env variables
+func NewConfigFromEnv() *Config {
+	return &Config{
+		Account:  os.Getenv(accountEnvKey),
+		Key:      os.Getenv(keyEnvKey),
+		SASToken: os.Getenv(sasTokenEnvKey),
+		Endpoint: os.Getenv(endpointEnvKey),
+	}
+}
@@ -0,0 +1,59 @@
+package azure
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/base"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"net/http"
+)
+
+//Scheme Azure Blob URL scheme, URL format: azure://$container/$path
+const Scheme = "azure"
+
+type manager struct {
+	*base.Manager
+	client *http.Client
+}
+
+func (m *manager) provider(ctx context.Context, baseURL string, options ...storage.Option) (storage.Storager, error) {
+	options = m.Options(options)
+	config := &Config{}
+	if _, ok := option.Assign(options, &config); !ok {
+		config = NewConfigFromEnv()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newStorager(baseURL, config, m.client), nil
+}
+
+//ErrorCode returns blob service response status code
+func (m *manager) ErrorCode(err error) int {
+	if failure, ok := errors.Cause(err).(*responseError); ok {
+		return failure.StatusCode
+	}
+	return 0
+}
+
+//New creates read only Azure Blob storage manager, account settings are taken from *Config option or env variables
+func New(options ...storage.Option) storage.Manager {
+	result := &manager{client: http.DefaultClient}
+	var client *http.Client
+	if _, ok := option.Assign(options, &client); ok {
+		result.client = client
+	}
+	result.Manager = base.New(result, Scheme, result.provider, options)
+	return result
+}
+
+//Provider returns Azure Blob storage manager
+func Provider(options ...storage.Option) (storage.Manager, error) {
+	return New(options...), nil
+}
+
+func init() {
+	afs.GetRegistry().Register(Scheme, Provider)
+}
@@ -0,0 +1,58 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const headerRange = "x-ms-range"
+
+//reader represents blob reader fetching byte ranges, it supports random access, so staging parts are transferred without reading preceding content
+type reader struct {
+	ctx      context.Context
+	storager *storager
+	URL      string
+	size     int64
+	partSize int
+	offset   int64
+}
+
+//ReadAt reads blob range starting at offset
+func (r *reader) ReadAt(data []byte, offset int64) (int, error) {
+	if offset >= r.size {
+		return 0, io.EOF
+	}
+	end := offset + int64(len(data))
+	if end > r.size {
+		end = r.size
+	}
+	header := http.Header{}
+	header.Set(headerRange, fmt.Sprintf("bytes=%v-%v", offset, end-1))
+	response, err := r.storager.do(r.ctx, http.MethodGet, r.URL, header)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	read, err := io.ReadFull(response.Body, data[:end-offset])
+	if err == nil && read < len(data) {
+		err = io.EOF
+	}
+	return read, err
+}
+
+//Read reads blob sequentially in up to part size ranges
+func (r *reader) Read(data []byte) (int, error) {
+	if len(data) > r.partSize {
+		data = data[:r.partSize]
+	}
+	read, err := r.ReadAt(data, r.offset)
+	r.offset += int64(read)
+	return read, err
+}
+
+//Close closes reader
+func (r *reader) Close() error {
+	return nil
+}
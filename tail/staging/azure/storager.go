@@ -0,0 +1,254 @@
+package azure
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	aurl "github.com/viant/afs/url"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Properties represents blob properties, it is returned as storage object Sys()
+type Properties struct {
+	ETag string
+	//MD5 base64 encoded content MD5 hash, if reported by blob service
+	MD5 string `json:",omitempty"`
+}
+
+type responseError struct {
+	StatusCode int
+	message    string
+}
+
+func (e *responseError) Error() string {
+	return e.message
+}
+
+//IsNotFound returns true if error is blob or container not found error
+func IsNotFound(err error) bool {
+	failure, ok := errors.Cause(err).(*responseError)
+	return ok && failure.StatusCode == http.StatusNotFound
+}
+
+type listResult struct {
+	Blobs struct {
+		Blob []struct {
+			Name       string
+			Properties struct {
+				LastModified  string `xml:"Last-Modified"`
+				ETag          string `xml:"Etag"`
+				ContentLength int64  `xml:"Content-Length"`
+				ContentMD5    string `xml:"Content-MD5"`
+			}
+		}
+		BlobPrefix []struct {
+			Name string
+		}
+	}
+	NextMarker string
+}
+
+//storager represents read only container storager using blob service REST API
+type storager struct {
+	config    *Config
+	container string
+	client    *http.Client
+}
+
+//Close closes storager
+func (s *storager) Close() error {
+	return nil
+}
+
+//Exists returns true if blob or blob prefix exists
+func (s *storager) Exists(ctx context.Context, location string, options ...storage.Option) (bool, error) {
+	_, err := s.Get(ctx, location, options...)
+	if err == nil {
+		return true, nil
+	}
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+//Get returns blob info, if blob does not exist and object kind is not restricted to file, blob prefix info is returned
+func (s *storager) Get(ctx context.Context, location string, options ...storage.Option) (os.FileInfo, error) {
+	location = strings.Trim(location, "/")
+	info, err := s.properties(ctx, location)
+	if err == nil || !IsNotFound(err) {
+		return info, err
+	}
+	objectKind := &option.ObjectKind{}
+	if _, ok := option.Assign(options, &objectKind); ok && objectKind.File {
+		return nil, err
+	}
+	files, listErr := s.List(ctx, location, options...)
+	if listErr != nil || len(files) == 0 {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+func (s *storager) properties(ctx context.Context, location string) (os.FileInfo, error) {
+	if location == "" {
+		return nil, &responseError{StatusCode: http.StatusNotFound, message: fmt.Sprintf("%v: not found", s.container)}
+	}
+	response, err := s.do(ctx, http.MethodHead, s.blobURL(location), nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = response.Body.Close()
+	modified, _ := time.Parse(http.TimeFormat, response.Header.Get("Last-Modified"))
+	size, _ := strconv.ParseInt(response.Header.Get("Content-Length"), 10, 64)
+	properties := &Properties{ETag: strings.Trim(response.Header.Get("ETag"), `"`), MD5: response.Header.Get("Content-MD5")}
+	return file.NewInfo(path.Base(location), size, file.DefaultFileOsMode, modified, false, properties), nil
+}
+
+//List returns blob info if location is a blob, otherwise location folder info followed by its blobs and blob prefixes
+func (s *storager) List(ctx context.Context, location string, options ...storage.Option) ([]os.FileInfo, error) {
+	location = strings.Trim(location, "/")
+	if info, err := s.properties(ctx, location); err == nil {
+		return []os.FileInfo{info}, nil
+	} else if !IsNotFound(err) {
+		return nil, err
+	}
+	prefix := ""
+	if location != "" {
+		prefix = location + "/"
+	}
+	var result = []os.FileInfo{file.NewInfo(path.Base(location), 0, file.DefaultDirOsMode, time.Now(), true)}
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("restype", "container")
+		query.Set("comp", "list")
+		query.Set("delimiter", "/")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		listed, err := s.list(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, blobPrefix := range listed.Blobs.BlobPrefix {
+			result = append(result, file.NewInfo(path.Base(strings.Trim(blobPrefix.Name, "/")), 0, file.DefaultDirOsMode, time.Now(), true))
+		}
+		for _, blob := range listed.Blobs.Blob {
+			modified, _ := time.Parse(http.TimeFormat, blob.Properties.LastModified)
+			properties := &Properties{ETag: strings.Trim(blob.Properties.ETag, `"`), MD5: blob.Properties.ContentMD5}
+			result = append(result, file.NewInfo(path.Base(blob.Name), blob.Properties.ContentLength, file.DefaultFileOsMode, modified, false, properties))
+		}
+		if marker = listed.NextMarker; marker == "" {
+			break
+		}
+	}
+	if len(result) == 1 && location != "" {
+		return []os.FileInfo{}, nil
+	}
+	return result, nil
+}
+
+func (s *storager) list(ctx context.Context, query url.Values) (*listResult, error) {
+	URL := s.config.BaseURL() + "/" + url.PathEscape(s.container) + "?" + query.Encode()
+	response, err := s.do(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	result := &listResult{}
+	if err = xml.NewDecoder(response.Body).Decode(result); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode blob list: %v", s.container)
+	}
+	return result, nil
+}
+
+//Open returns blob reader, with stream option the reader fetches blob ranges and supports random access
+func (s *storager) Open(ctx context.Context, location string, options ...storage.Option) (io.ReadCloser, error) {
+	location = strings.Trim(location, "/")
+	stream := &option.Stream{}
+	option.Assign(options, &stream)
+	if stream.PartSize > 0 {
+		size := int64(stream.Size)
+		if size == 0 {
+			info, err := s.properties(ctx, location)
+			if err != nil {
+				return nil, err
+			}
+			size = info.Size()
+		}
+		return &reader{ctx: ctx, storager: s, URL: s.blobURL(location), size: size, partSize: stream.PartSize}, nil
+	}
+	response, err := s.do(ctx, http.MethodGet, s.blobURL(location), nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+//Upload is not supported, staging only reads external data files
+func (s *storager) Upload(ctx context.Context, destination string, mode os.FileMode, reader io.Reader, options ...storage.Option) error {
+	return errors.Errorf("unsupported operation: upload %v, azure connector is read only", destination)
+}
+
+//Create is not supported, staging only reads external data files
+func (s *storager) Create(ctx context.Context, destination string, mode os.FileMode, reader io.Reader, isDir bool, options ...storage.Option) error {
+	return errors.Errorf("unsupported operation: create %v, azure connector is read only", destination)
+}
+
+//Delete is not supported, staging only reads external data files
+func (s *storager) Delete(ctx context.Context, location string, options ...storage.Option) error {
+	return errors.Errorf("unsupported operation: delete %v, azure connector is read only", location)
+}
+
+func (s *storager) blobURL(location string) string {
+	var elements = strings.Split(location, "/")
+	for i := range elements {
+		elements[i] = url.PathEscape(elements[i])
+	}
+	return s.config.BaseURL() + "/" + url.PathEscape(s.container) + "/" + strings.Join(elements, "/")
+}
+
+//do sends authorized request, non 2xx response is returned as responseError
+func (s *storager) do(ctx context.Context, method, URL string, header http.Header) (*http.Response, error) {
+	request, err := http.NewRequest(method, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if err = s.config.authorize(request); err != nil {
+		return nil, err
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%v %v failed", method, URL)
+	}
+	if response.StatusCode/100 == 2 {
+		return response, nil
+	}
+	defer response.Body.Close()
+	data, _ := ioutil.ReadAll(response.Body)
+	return nil, &responseError{StatusCode: response.StatusCode, message: fmt.Sprintf("%v %v failed: %v, %s", method, URL, response.Status, data)}
+}
+
+func newStorager(baseURL string, config *Config, client *http.Client) *storager {
+	return &storager{config: config, container: aurl.Host(baseURL), client: client}
+}
@@ -0,0 +1,110 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	var blobs = map[string]string{
+		"/container/data/events1.json": "{\"id\":1}\n{\"id\":2}\n",
+		"/container/data/events2.json": "{\"id\":3}\n",
+	}
+	var authorized = true
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !strings.HasPrefix(request.Header.Get("Authorization"), "SharedKey account:") || request.Header.Get(headerDate) == "" {
+			authorized = false
+		}
+		if request.URL.Query().Get("comp") == "list" {
+			prefix := "/container/" + request.URL.Query().Get("prefix")
+			var items = make([]string, 0)
+			for name, content := range blobs {
+				if strings.HasPrefix(name, prefix) {
+					items = append(items, fmt.Sprintf("<Blob><Name>%v</Name><Properties><Last-Modified>Mon, 02 Jan 2006 15:04:05 GMT</Last-Modified><Etag>\"0x1\"</Etag><Content-Length>%v</Content-Length></Properties></Blob>", name[len("/container/"):], len(content)))
+				}
+			}
+			_, _ = writer.Write([]byte("<?xml version=\"1.0\" encoding=\"utf-8\"?><EnumerationResults><Blobs>" + strings.Join(items, "") + "</Blobs><NextMarker/></EnumerationResults>"))
+			return
+		}
+		content, ok := blobs[request.URL.Path]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Header().Set("ETag", "\"0x8D\"")
+		writer.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if rangeValue := request.Header.Get(headerRange); rangeValue != "" {
+			var start, end int
+			_, _ = fmt.Sscanf(rangeValue, "bytes=%d-%d", &start, &end)
+			content = content[start : end+1]
+			writer.Header().Set("Content-Length", fmt.Sprintf("%v", len(content)))
+			writer.WriteHeader(http.StatusPartialContent)
+		} else {
+			writer.Header().Set("Content-Length", fmt.Sprintf("%v", len(content)))
+		}
+		if request.Method != http.MethodHead {
+			_, _ = writer.Write([]byte(content))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	config := &Config{Account: "account", Key: "a2V5", Endpoint: server.URL}
+	srv := New(config, server.Client())
+	defer srv.Close()
+	getter := srv.(storage.Getter)
+
+	object, err := getter.Object(ctx, "azure://container/data/events1.json", option.NewObjectKind(true))
+	if assert.Nil(t, err) {
+		assert.EqualValues(t, 18, object.Size())
+		assert.EqualValues(t, &Properties{ETag: "0x8D"}, object.Sys())
+	}
+	_, err = getter.Object(ctx, "azure://container/data/missing.json", option.NewObjectKind(true))
+	assert.True(t, IsNotFound(err))
+
+	objects, err := srv.List(ctx, "azure://container/data")
+	if assert.Nil(t, err) && assert.Equal(t, 3, len(objects)) {
+		assert.True(t, objects[0].IsDir())
+		assert.ElementsMatch(t, []string{"events1.json", "events2.json"}, []string{objects[1].Name(), objects[2].Name()})
+	}
+
+	reader, err := srv.OpenURL(ctx, "azure://container/data/events1.json", option.NewStream(4, 18))
+	if assert.Nil(t, err) {
+		readerAt, ok := reader.(io.ReaderAt)
+		if assert.True(t, ok, "random access reader") {
+			data := make([]byte, 8)
+			read, err := readerAt.ReadAt(data, 9)
+			assert.Nil(t, err)
+			assert.EqualValues(t, "{\"id\":2}", string(data[:read]))
+		}
+		data, err := ioutil.ReadAll(reader)
+		assert.Nil(t, err)
+		assert.EqualValues(t, blobs["/container/data/events1.json"], string(data))
+	}
+	assert.True(t, authorized, "shared key authorization")
+}
+
+func TestStringToSign(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "https://account.blob.core.windows.net/container?restype=container&comp=list&prefix=data%2F", nil)
+	request.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	request.Header.Set("x-ms-version", apiVersion)
+	request.Header.Set("x-ms-range", "bytes=0-3")
+	expect := "GET\n\n\n\n\n\n\n\n\n\n\n\n" +
+		"x-ms-date:Mon, 02 Jan 2006 15:04:05 GMT\n" +
+		"x-ms-range:bytes=0-3\n" +
+		"x-ms-version:" + apiVersion + "\n" +
+		"/account/container\n" +
+		"comp:list\n" +
+		"prefix:data/\n" +
+		"restype:container"
+	assert.EqualValues(t, expect, stringToSign("account", request))
+}
@@ -0,0 +1,74 @@
+package staging
+
+import (
+	"time"
+)
+
+//Part represents source object byte range transferred as a separate staged object
+type Part struct {
+	Index  int
+	Offset int64
+	Size   int64
+	//MD5 base64 encoded part MD5 hash, set once part has been staged and verified
+	MD5 string `json:",omitempty"`
+}
+
+//Done returns true if part has been staged
+func (p *Part) Done() bool {
+	return p.MD5 != ""
+}
+
+//Journal represents staging progress, it allows a restarted event to resume partially staged data file
+type Journal struct {
+	EventID       string
+	SourceURL     string
+	SourceSize    int64
+	SourceModTime time.Time
+	//SourceVersion source object version (ETag), if reported by storage connector
+	SourceVersion string `json:",omitempty"`
+	StagedURL     string
+	PartSize      int64
+	Parts         []*Part
+	Started       time.Time
+	Updated       time.Time
+	//Completed set once staged object has been assembled and verified, completed journal allows the next event to reuse the staged object
+	Completed bool `json:",omitempty"`
+}
+
+//Matches returns true if journal was created for the same source object version
+func (j *Journal) Matches(size int64, modTime time.Time, version string, partSize int64) bool {
+	return j.SourceSize == size && j.SourceModTime.Equal(modTime) && j.SourceVersion == version && j.PartSize == partSize
+}
+
+//Pending returns parts that have not been staged yet
+func (j *Journal) Pending() []*Part {
+	var result = make([]*Part, 0)
+	for _, part := range j.Parts {
+		if !part.Done() {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+//NewJournal creates a journal with source object split into parts
+func NewJournal(eventID, sourceURL string, size int64, modTime time.Time, version, stagedURL string, partSize int64) *Journal {
+	result := &Journal{
+		EventID:       eventID,
+		SourceURL:     sourceURL,
+		SourceSize:    size,
+		SourceModTime: modTime,
+		SourceVersion: version,
+		StagedURL:     stagedURL,
+		PartSize:      partSize,
+		Started:       time.Now(),
+	}
+	for offset := int64(0); offset < size || len(result.Parts) == 0; offset += partSize {
+		partSize := partSize
+		if offset+partSize > size {
+			partSize = size - offset
+		}
+		result.Parts = append(result.Parts, &Part{Index: len(result.Parts), Offset: offset, Size: partSize})
+	}
+	return result
+}
@@ -0,0 +1,317 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/afs/url"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/shared"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/tail/staging/azure"
+	gstorage "google.golang.org/api/storage/v1"
+	"hash"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+const partsFolder = "_parts"
+
+//Service represents external data file staging service
+type Service interface {
+	//Stage copies source object to Google Storage staging location, it returns staged object
+	Stage(ctx context.Context, request *Request) (storage.Object, error)
+}
+
+//Request represents staging request
+type Request struct {
+	EventID string
+	Source  storage.Object
+	Staging *config.Staging
+}
+
+type service struct {
+	journalURL string
+	fs         afs.Service
+}
+
+//Stage copies source object in parallel parts, every staged part is verified with MD5 checksum and recorded in the journal, so that restarted event transfers only pending parts,
+//staged object is reused only if completed journal matches source object size, modification time and version
+func (s *service) Stage(ctx context.Context, request *Request) (storage.Object, error) {
+	source := request.Source
+	stagedURL := StagedURL(request.Staging.URL, source.URL())
+	journalURL := s.journalLocation(source)
+	journal, err := s.loadJournal(ctx, journalURL)
+	if err != nil {
+		return nil, err
+	}
+	version := SourceVersion(source)
+	matched := journal != nil && journal.Matches(source.Size(), source.ModTime(), version, request.Staging.PartSize())
+	if matched && journal.Completed {
+		if staged, _ := s.fs.Object(ctx, stagedURL, option.NewObjectKind(true)); staged != nil {
+			return staged, nil //already staged by previous event
+		}
+	}
+	if !matched || journal.Completed {
+		journal = NewJournal(request.EventID, source.URL(), source.Size(), source.ModTime(), version, stagedURL, request.Staging.PartSize())
+	} else if shared.IsInfoLoggingLevel() {
+		shared.LogF("resuming staging %v: %v/%v parts pending\n", source.URL(), len(journal.Pending()), len(journal.Parts))
+	}
+	if err = s.transfer(ctx, journal, journalURL, request.Staging.Concurrency); err != nil {
+		return nil, err
+	}
+	if len(journal.Parts) > 1 {
+		err = s.assemble(ctx, journal, journalURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	staged, err := s.fs.Object(ctx, stagedURL, option.NewObjectKind(true))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get staged object: %v", stagedURL)
+	}
+	if len(journal.Parts) > 1 {
+		_ = s.fs.Delete(ctx, s.partsURL(journal))
+	}
+	journal.Completed = true
+	if err = s.persistJournal(ctx, journal, journalURL); err != nil {
+		return nil, err
+	}
+	if shared.IsInfoLoggingLevel() {
+		shared.LogF("staged %v -> %v (%v bytes) in %s\n", source.URL(), stagedURL, staged.Size(), time.Since(journal.Started))
+	}
+	return staged, nil
+}
+
+//transfer transfers pending parts with bounded concurrency
+func (s *service) transfer(ctx context.Context, journal *Journal, journalURL string, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	mux := &sync.Mutex{}
+	waitGroup := &sync.WaitGroup{}
+	limiter := make(chan bool, concurrency)
+	var err error
+	for _, part := range journal.Pending() {
+		waitGroup.Add(1)
+		limiter <- true
+		go func(part *Part) {
+			defer func() {
+				<-limiter
+				waitGroup.Done()
+			}()
+			MD5, e := s.transferPart(ctx, journal, part)
+			mux.Lock()
+			defer mux.Unlock()
+			if e == nil {
+				part.MD5 = MD5
+				e = s.persistJournal(ctx, journal, journalURL)
+			}
+			if e != nil && err == nil {
+				err = e
+			}
+		}(part)
+	}
+	waitGroup.Wait()
+	return err
+}
+
+//transferPart copies source byte range to part URL, upload is verified by Google Storage with supplied MD5 hash
+func (s *service) transferPart(ctx context.Context, journal *Journal, part *Part) (string, error) {
+	reader, err := s.fs.OpenURL(ctx, journal.SourceURL, option.NewStream(int(journal.PartSize), int(journal.SourceSize)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %v", journal.SourceURL)
+	}
+	defer reader.Close()
+	section, err := sectionReader(reader, part)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to seek %v to %v", journal.SourceURL, part.Offset)
+	}
+	data := make([]byte, part.Size)
+	if _, err = io.ReadFull(section, data); err != nil {
+		return "", errors.Wrapf(err, "failed to read %v part %v", journal.SourceURL, part.Index)
+	}
+	checksum := option.NewMd5(data)
+	partURL := s.partURL(journal, part)
+	err = base.RunWithRetries(func() error {
+		return s.fs.Upload(ctx, partURL, file.DefaultFileOsMode, bytes.NewReader(data), checksum)
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to stage %v part %v", journal.SourceURL, part.Index)
+	}
+	return checksum.Encode(), nil
+}
+
+//assemble concatenates staged parts into staged object, each part is verified against journal checksum
+func (s *service) assemble(ctx context.Context, journal *Journal, journalURL string) error {
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	digest := md5.New()
+	go func() {
+		err := s.fs.Upload(ctx, journal.StagedURL, file.DefaultFileOsMode, io.TeeReader(reader, digest), option.NewSkipChecksum(true))
+		_ = reader.CloseWithError(err)
+		done <- err
+	}()
+	var err error
+	var corrupted *Part
+	copied := int64(0)
+	for _, part := range journal.Parts {
+		if corrupted, err = s.copyPart(ctx, journal, part, writer); err != nil {
+			break
+		}
+		copied += part.Size
+	}
+	if err == nil && copied != journal.SourceSize {
+		err = errors.Errorf("staged object size mismatch: %v, expected: %v, but had: %v", journal.StagedURL, journal.SourceSize, copied)
+	}
+	_ = writer.CloseWithError(err)
+	if uploadErr := <-done; err == nil && uploadErr != nil {
+		err = errors.Wrapf(uploadErr, "failed to upload staged object: %v", journal.StagedURL)
+	}
+	if corrupted != nil {
+		corrupted.MD5 = "" //transfer the part again on retry
+		_ = s.persistJournal(ctx, journal, journalURL)
+	}
+	if err != nil {
+		return err
+	}
+	return s.verifyChecksum(ctx, journal.StagedURL, digest)
+}
+
+//copyPart copies staged part to writer, it returns the part if its checksum does not match the journal
+func (s *service) copyPart(ctx context.Context, journal *Journal, part *Part, writer io.Writer) (*Part, error) {
+	partURL := s.partURL(journal, part)
+	reader, err := s.fs.OpenURL(ctx, partURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open staged part: %v", partURL)
+	}
+	defer reader.Close()
+	digest := md5.New()
+	copied, err := io.Copy(io.MultiWriter(writer, digest), reader)
+	if err != nil {
+		return nil, err
+	}
+	if actual := base64.StdEncoding.EncodeToString(digest.Sum(nil)); actual != part.MD5 || copied != part.Size {
+		return part, errors.Errorf("staged part checksum mismatch: %v, expected: %v, but had: %v", partURL, part.MD5, actual)
+	}
+	return nil, nil
+}
+
+//verifyChecksum compares assembled content checksum with checksum reported by Google Storage
+func (s *service) verifyChecksum(ctx context.Context, URL string, digest hash.Hash) error {
+	object, err := s.fs.Object(ctx, URL, option.NewObjectKind(true))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get staged object: %v", URL)
+	}
+	gsObject, ok := object.Sys().(*gstorage.Object)
+	if !ok || gsObject.Md5Hash == "" {
+		return nil
+	}
+	if expect := base64.StdEncoding.EncodeToString(digest.Sum(nil)); gsObject.Md5Hash != expect {
+		return errors.Errorf("staged object checksum mismatch: %v, expected: %v, but had: %v", URL, expect, gsObject.Md5Hash)
+	}
+	return nil
+}
+
+func (s *service) loadJournal(ctx context.Context, URL string) (*Journal, error) {
+	if ok, _ := s.fs.Exists(ctx, URL, option.NewObjectKind(true)); !ok {
+		return nil, nil
+	}
+	data, err := s.fs.DownloadWithURL(ctx, URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load staging journal: %v", URL)
+	}
+	journal := &Journal{}
+	if err = json.Unmarshal(data, journal); err != nil {
+		shared.LogF("ignoring invalid staging journal: %v, %v\n", URL, err)
+		return nil, nil
+	}
+	return journal, nil
+}
+
+func (s *service) persistJournal(ctx context.Context, journal *Journal, URL string) error {
+	journal.Updated = time.Now()
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	if err = s.fs.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(data)); err != nil {
+		return errors.Wrapf(err, "failed to update staging journal: %v", URL)
+	}
+	return nil
+}
+
+func (s *service) journalLocation(source storage.Object) string {
+	return url.Join(s.journalURL, fmt.Sprintf("%v_%v%v", base.Hash(source.URL()), source.ModTime().UnixNano(), shared.JSONExt))
+}
+
+func (s *service) partsURL(journal *Journal) string {
+	return journal.StagedURL + partsFolder
+}
+
+//partURL returns part location, single part data file is staged directly
+func (s *service) partURL(journal *Journal, part *Part) string {
+	if len(journal.Parts) == 1 {
+		return journal.StagedURL
+	}
+	return url.Join(s.partsURL(journal), fmt.Sprintf("%05d", part.Index))
+}
+
+//sectionReader returns part reader, stream readers support random access, other readers are advanced to part offset
+func sectionReader(reader io.Reader, part *Part) (io.Reader, error) {
+	if readerAt, ok := reader.(io.ReaderAt); ok {
+		return io.NewSectionReader(readerAt, part.Offset, part.Size), nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, reader, part.Offset); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+//StagedURL returns staged data file location: stagingURL/$bucket/$path
+func StagedURL(stagingURL, sourceURL string) string {
+	return url.Join(stagingURL, url.Host(sourceURL), url.Path(sourceURL))
+}
+
+//SourceVersion returns source object version (S3 or Azure Blob ETag), or empty string if storage connector does not report it
+func SourceVersion(source storage.Object) string {
+	switch sys := source.Sys().(type) {
+	case *s3.HeadObjectOutput:
+		if sys.ETag != nil {
+			return strings.Trim(*sys.ETag, `"`)
+		}
+	case *s3.Object:
+		if sys.ETag != nil {
+			return strings.Trim(*sys.ETag, `"`)
+		}
+	case *azure.Properties:
+		return sys.ETag
+	}
+	return ""
+}
+
+//IsSupported returns an error if source URL scheme has no registered storage connector
+func IsSupported(sourceURL string) error {
+	scheme := url.Scheme(sourceURL, file.Scheme)
+	if _, err := afs.GetRegistry().Get(scheme); err != nil {
+		return errors.Errorf("unsupported staging source scheme: %v, storage connector was not registered", scheme)
+	}
+	return nil
+}
+
+//New creates staging service
+func New(fs afs.Service, journalURL string) Service {
+	return &service{fs: fs, journalURL: journalURL}
+}
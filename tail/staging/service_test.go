@@ -0,0 +1,172 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/object"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/bqtail/tail/config"
+	"github.com/viant/bqtail/tail/staging/azure"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestService_Stage(t *testing.T) {
+	ctx := context.Background()
+	fs := afs.New()
+	journalURL := "mem://localhost/journal/staging"
+	stagingURL := "mem://localhost/staging"
+	modTime := time.Now()
+	data := bytes.Repeat([]byte("0123456789abcdef\n"), 150000) //~2.4MB
+
+	useCases := []struct {
+		description   string
+		sourceURL     string
+		data          []byte
+		journal       func(srv *service, journal *Journal) //prepares partially staged journal
+		previous      []byte                               //data file content staged by previous event
+		prevVersion   string
+		version       string
+		expectData    []byte
+		expectStaged  string
+		expectError   bool
+		expectPending int
+	}{
+		{
+			description:  "single part",
+			sourceURL:    "mem://s3bucket/data/case1.csv",
+			data:         []byte("1,abc\n2,xyz\n"),
+			expectStaged: "mem://localhost/staging/s3bucket/data/case1.csv",
+		},
+		{
+			description:  "multi part",
+			sourceURL:    "mem://s3bucket/data/case2.csv",
+			data:         data,
+			expectStaged: "mem://localhost/staging/s3bucket/data/case2.csv",
+		},
+		{
+			description: "resumed transfer",
+			sourceURL:   "mem://s3bucket/data/case3.csv",
+			data:        data,
+			journal: func(srv *service, journal *Journal) {
+				part := journal.Parts[1]
+				content := data[part.Offset : part.Offset+part.Size]
+				part.MD5 = option.NewMd5(content).Encode()
+				_ = fs.Upload(ctx, srv.partURL(journal, part), file.DefaultFileOsMode, bytes.NewReader(content))
+			},
+			expectStaged: "mem://localhost/staging/s3bucket/data/case3.csv",
+		},
+		{
+			description: "corrupted staged part",
+			sourceURL:   "mem://s3bucket/data/case4.csv",
+			data:        data,
+			journal: func(srv *service, journal *Journal) {
+				part := journal.Parts[0]
+				part.MD5 = option.NewMd5(data[part.Offset : part.Offset+part.Size]).Encode()
+				_ = fs.Upload(ctx, srv.partURL(journal, part), file.DefaultFileOsMode, bytes.NewReader([]byte("corrupted")))
+			},
+			expectError:   true,
+			expectPending: 1,
+		},
+		{
+			description:  "staged copy of the same source version reused",
+			sourceURL:    "mem://s3bucket/data/case5.csv",
+			previous:     []byte("1,abc\n"),
+			prevVersion:  "v1",
+			data:         []byte("2,xyz\n"),
+			version:      "v1",
+			expectData:   []byte("1,abc\n"),
+			expectStaged: "mem://localhost/staging/s3bucket/data/case5.csv",
+		},
+		{
+			description:  "stale staged copy of overwritten same size source restaged",
+			sourceURL:    "mem://s3bucket/data/case6.csv",
+			previous:     []byte("1,abc\n"),
+			prevVersion:  "v1",
+			data:         []byte("2,xyz\n"),
+			version:      "v2",
+			expectStaged: "mem://localhost/staging/s3bucket/data/case6.csv",
+		},
+	}
+
+	//mem storage does not report object size nor version
+	newSource := func(URL string, data []byte, version string) storage.Object {
+		return object.New(URL, file.NewInfo(path.Base(URL), int64(len(data)), file.DefaultFileOsMode, modTime, false, &azure.Properties{ETag: version}), nil)
+	}
+	for _, useCase := range useCases {
+		staging := &config.Staging{URL: stagingURL, PartSizeMb: 1}
+		staging.Init()
+		srv := New(fs, journalURL).(*service)
+		if useCase.previous != nil {
+			assert.Nil(t, fs.Upload(ctx, useCase.sourceURL, file.DefaultFileOsMode, bytes.NewReader(useCase.previous)), useCase.description)
+			_, err := srv.Stage(ctx, &Request{EventID: "e0", Source: newSource(useCase.sourceURL, useCase.previous, useCase.prevVersion), Staging: staging})
+			assert.Nil(t, err, useCase.description)
+		}
+		if !assert.Nil(t, fs.Upload(ctx, useCase.sourceURL, file.DefaultFileOsMode, bytes.NewReader(useCase.data)), useCase.description) {
+			continue
+		}
+		source := newSource(useCase.sourceURL, useCase.data, useCase.version)
+		if useCase.journal != nil {
+			journal := NewJournal("e0", source.URL(), source.Size(), source.ModTime(), useCase.version, StagedURL(stagingURL, source.URL()), staging.PartSize())
+			useCase.journal(srv, journal)
+			_ = srv.persistJournal(ctx, journal, srv.journalLocation(source))
+		}
+		staged, err := srv.Stage(ctx, &Request{EventID: "e1", Source: source, Staging: staging})
+		if useCase.expectError {
+			assert.NotNil(t, err, useCase.description)
+			journal, err := srv.loadJournal(ctx, srv.journalLocation(source))
+			if assert.Nil(t, err, useCase.description) && assert.NotNil(t, journal, useCase.description) {
+				assert.EqualValues(t, useCase.expectPending, len(journal.Pending()), useCase.description)
+			}
+			continue
+		}
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectStaged, staged.URL(), useCase.description)
+		actual, err := fs.DownloadWithURL(ctx, staged.URL())
+		assert.Nil(t, err, useCase.description)
+		expectData := useCase.expectData
+		if expectData == nil {
+			expectData = useCase.data
+		}
+		assert.EqualValues(t, string(expectData), string(actual), useCase.description)
+		journal, err := srv.loadJournal(ctx, srv.journalLocation(source))
+		if assert.Nil(t, err, useCase.description) && assert.NotNil(t, journal, useCase.description) {
+			assert.True(t, journal.Completed, useCase.description)
+		}
+		exists, _ := fs.Exists(ctx, staged.URL()+partsFolder)
+		assert.False(t, exists, useCase.description)
+	}
+}
+
+func TestIsSupported(t *testing.T) {
+	var useCases = []struct {
+		description string
+		sourceURL   string
+		hasError    bool
+	}{
+		{
+			description: "registered connector",
+			sourceURL:   "mem://localhost/data/events.json",
+		},
+		{
+			description: "azure blob",
+			sourceURL:   "azure://container/data/events.json",
+		},
+		{
+			description: "unregistered connector",
+			sourceURL:   "xyz://bucket/data/events.json",
+			hasError:    true,
+		},
+	}
+	for _, useCase := range useCases {
+		err := IsSupported(useCase.sourceURL)
+		assert.EqualValues(t, useCase.hasError, err != nil, useCase.description)
+	}
+}
@@ -46,6 +46,9 @@ func (a *Action) Init(ctx context.Context, fs afs.Service) error {
 	if a.Request == nil {
 		a.Request = make(map[string]interface{})
 	}
+	if err := a.When.Init(); err != nil {
+		return errors.Wrapf(err, "invalid %v action When.Expr", a.Action)
+	}
 	isEmptyRequest := len(a.Request) == 0
 	if isEmptyRequest {
 		switch a.Action {
@@ -147,7 +150,7 @@ func (a Action) Expand(root *stage.Process, expander data.Map) *Action {
 	}
 	var result = &Action{
 		Action: a.Action,
-		When:   a.When,
+		When:   a.When.Expand(expander),
 	}
 	expanded := expander.Expand(a.Request)
 	result.Request = toolbox.AsMap(expanded)
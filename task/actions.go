@@ -111,9 +111,13 @@ func (a Actions) ToRun(err error, job *base.Job) []*Action {
 				toRun[i].Request[shared.ResponseKey] = string(responseJSON)
 			}
 		}
+		if toRun[i].Action == shared.ActionReconcile && job.JobReference != nil {
+			toRun[i].Request[shared.ParentJobIDKey] = job.JobID()
+		}
 		if _, ok := toRun[i].Request[shared.JobSourceKey]; !ok {
 			toRun[i].Request[shared.JobSourceKey] = job.Source()
 		}
+		toRun[i].When = toRun[i].When.WithJob(job, err)
 	}
 	return toRun
 }
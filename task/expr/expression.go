@@ -0,0 +1,66 @@
+package expr
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+)
+
+//Expression represents parsed boolean expression, i.e. OutputRows > 0 && !hasSuffix(DestTable, '_tmp')
+type Expression struct {
+	text      string
+	root      node
+	variables []string
+}
+
+//Variables returns referenced top level variable names
+func (e *Expression) Variables() []string {
+	return e.variables
+}
+
+//Eval evaluates expression with supplied variables
+func (e *Expression) Eval(vars map[string]interface{}) (interface{}, error) {
+	result, err := e.root.eval(vars)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to evaluate: %v", e.text)
+	}
+	return result, nil
+}
+
+//Bool evaluates expression as boolean
+func (e *Expression) Bool(vars map[string]interface{}) (bool, error) {
+	result, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(result), nil
+}
+
+//String returns expression text
+func (e *Expression) String() string {
+	return e.text
+}
+
+//Parse parses expression, supported: literals (numbers, 'strings', true, false, null), dotted variables with optional $ prefix,
+//operators: || && ! == != < <= > >= + - * / %, parentheses and helper functions
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression: %v", text)
+	}
+	p := &parser{tokens: tokens, variables: make(map[string]bool)}
+	root, err := p.or()
+	if err == nil {
+		if rest := p.peek(); rest.kind != tokenEOF {
+			err = errors.Errorf("unexpected %q at position %v", rest.text, rest.position)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expression: %v", text)
+	}
+	result := &Expression{text: text, root: root}
+	for name := range p.variables {
+		result.variables = append(result.variables, name)
+	}
+	sort.Strings(result.variables)
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package expr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	vars := map[string]interface{}{
+		"OutputRows": int64(10),
+		"BadRecords": int64(0),
+		"DestTable":  "proj:ds.events_tmp",
+		"LoadURIs":   []interface{}{"gs://bucket/data/1.json", "gs://bucket/data/2.json"},
+		"Source": map[string]interface{}{
+			"URL":  "gs://bucket/data/1.json",
+			"Time": "2020-01-10T23:55:00Z",
+		},
+		"Params": map[string]interface{}{"mod": "3"},
+	}
+
+	useCases := []struct {
+		description string
+		expr        string
+		expect      interface{}
+		expectVars  []string
+		hasError    bool
+	}{
+		{
+			description: "comparison",
+			expr:        "OutputRows > 0",
+			expect:      true,
+			expectVars:  []string{"OutputRows"},
+		},
+		{
+			description: "boolean operators with $ prefix",
+			expr:        "$OutputRows >= 10 && (BadRecords > 0 || !hasSuffix(DestTable, '_tmp'))",
+			expect:      false,
+			expectVars:  []string{"BadRecords", "DestTable", "OutputRows"},
+		},
+		{
+			description: "nested variable and time function",
+			expr:        `hour(Source.Time) == 23 && minute(Source.Time) >= 55 && contains(Source.URL, "data/")`,
+			expect:      true,
+			expectVars:  []string{"Source"},
+		},
+		{
+			description: "collection functions and arithmetic",
+			expr:        "len(LoadURIs) * 2 - 1 == 3 && contains(LoadURIs, 'gs://bucket/data/2.json') && Params.mod % 2 == 1",
+			expect:      true,
+			expectVars:  []string{"LoadURIs", "Params"},
+		},
+		{
+			description: "missing variable",
+			expr:        "Missing > 0 || Missing == null",
+			expect:      true,
+			expectVars:  []string{"Missing"},
+		},
+		{
+			description: "regexp",
+			expr:        "matches(DestTable, '^proj:ds\\\\.events')",
+			expect:      true,
+			expectVars:  []string{"DestTable"},
+		},
+		{
+			description: "unknown function",
+			expr:        "foo(OutputRows)",
+			hasError:    true,
+		},
+		{
+			description: "invalid arity",
+			expr:        "len(LoadURIs, 1)",
+			hasError:    true,
+		},
+		{
+			description: "unbalanced parentheses",
+			expr:        "(OutputRows > 0",
+			hasError:    true,
+		},
+		{
+			description: "trailing tokens",
+			expr:        "OutputRows > 0 BadRecords",
+			hasError:    true,
+		},
+		{
+			description: "unterminated string",
+			expr:        "DestTable == 'abc",
+			hasError:    true,
+		},
+	}
+
+	for _, useCase := range useCases {
+		expression, err := Parse(useCase.expr)
+		if useCase.hasError {
+			assert.NotNil(t, err, useCase.description)
+			continue
+		}
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expectVars, expression.Variables(), useCase.description)
+		actual, err := expression.Bool(vars)
+		if !assert.Nil(t, err, useCase.description) {
+			continue
+		}
+		assert.EqualValues(t, useCase.expect, actual, useCase.description)
+	}
+}
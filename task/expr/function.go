@@ -0,0 +1,130 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//function represents expression helper function, negative arity accepts any number of arguments
+type function struct {
+	arity   int
+	handler func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]*function{
+	"len":       {arity: 1, handler: length},
+	"contains":  {arity: 2, handler: contains},
+	"hasPrefix": {arity: 2, handler: stringPredicate(strings.HasPrefix)},
+	"hasSuffix": {arity: 2, handler: stringPredicate(strings.HasSuffix)},
+	"matches":   {arity: 2, handler: matches},
+	"lower":     {arity: 1, handler: stringFunction(strings.ToLower)},
+	"upper":     {arity: 1, handler: stringFunction(strings.ToUpper)},
+	"now":       {arity: 0, handler: func(args []interface{}) (interface{}, error) { return time.Now().UTC(), nil }},
+	"hour":      {arity: 1, handler: timeFunction(func(t time.Time) int { return t.Hour() })},
+	"minute":    {arity: 1, handler: timeFunction(func(t time.Time) int { return t.Minute() })},
+	"day":       {arity: 1, handler: timeFunction(func(t time.Time) int { return t.Day() })},
+	"weekday":   {arity: 1, handler: timeFunction(func(t time.Time) int { return int(t.Weekday()) })},
+	"age":       {arity: 1, handler: age},
+}
+
+func length(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return 0, nil
+	}
+	if text, ok := args[0].(string); ok {
+		return len(text), nil
+	}
+	value := reflect.ValueOf(args[0])
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len(), nil
+	}
+	return nil, fmt.Errorf("unsupported argument type: %T", args[0])
+}
+
+//contains returns true if string contains substring or collection contains element
+func contains(args []interface{}) (interface{}, error) {
+	if text, ok := args[0].(string); ok {
+		return strings.Contains(text, fmt.Sprintf("%v", args[1])), nil
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	value := reflect.ValueOf(args[0])
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if equals(value.Index(i).Interface(), args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		return value.MapIndex(reflect.ValueOf(fmt.Sprintf("%v", args[1]))).IsValid(), nil
+	}
+	return nil, fmt.Errorf("unsupported argument type: %T", args[0])
+}
+
+func matches(args []interface{}) (interface{}, error) {
+	expr, err := regexp.Compile(fmt.Sprintf("%v", args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return expr.MatchString(asString(args[0])), nil
+}
+
+//age returns number of seconds elapsed since supplied time
+func age(args []interface{}) (interface{}, error) {
+	value, err := asTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return time.Since(value).Seconds(), nil
+}
+
+func stringPredicate(predicate func(s, affix string) bool) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		return predicate(asString(args[0]), asString(args[1])), nil
+	}
+}
+
+func stringFunction(fn func(s string) string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		return fn(asString(args[0])), nil
+	}
+}
+
+func timeFunction(fn func(t time.Time) int) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		value, err := asTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return fn(value), nil
+	}
+}
+
+func asString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+//asTime converts time or RFC3339 formatted time
+func asTime(value interface{}) (time.Time, error) {
+	switch actual := value.(type) {
+	case time.Time:
+		return actual, nil
+	case *time.Time:
+		if actual != nil {
+			return *actual, nil
+		}
+	case string:
+		return time.Parse(time.RFC3339Nano, actual)
+	}
+	return time.Time{}, fmt.Errorf("unsupported time: %v", value)
+}
@@ -0,0 +1,107 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	tokenEOF = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind     int
+	text     string
+	position int
+}
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+//tokenize splits expression into tokens
+func tokenize(text string) ([]*token, error) {
+	var result = make([]*token, 0)
+	for i := 0; i < len(text); {
+		c := rune(text[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			result = append(result, &token{kind: tokenLeftParen, text: "(", position: i})
+			i++
+		case c == ')':
+			result = append(result, &token{kind: tokenRightParen, text: ")", position: i})
+			i++
+		case c == ',':
+			result = append(result, &token{kind: tokenComma, text: ",", position: i})
+			i++
+		case c == '\'' || c == '"':
+			value, end, err := readString(text, i)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, &token{kind: tokenString, text: value, position: i})
+			i = end
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(text) && unicode.IsDigit(rune(text[i+1]))):
+			end := i
+			for end < len(text) && (unicode.IsDigit(rune(text[end])) || text[end] == '.') {
+				end++
+			}
+			result = append(result, &token{kind: tokenNumber, text: text[i:end], position: i})
+			i = end
+		case c == '$' || c == '_' || unicode.IsLetter(c):
+			end := i + 1
+			for end < len(text) && (text[end] == '_' || text[end] == '.' || unicode.IsLetter(rune(text[end])) || unicode.IsDigit(rune(text[end]))) {
+				end++
+			}
+			ident := strings.TrimPrefix(text[i:end], "$")
+			if ident == "" || strings.HasSuffix(ident, ".") || strings.Contains(ident, "..") {
+				return nil, fmt.Errorf("invalid identifier %q at position %v", text[i:end], i)
+			}
+			result = append(result, &token{kind: tokenIdent, text: ident, position: i})
+			i = end
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(text[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %v", c, i)
+			}
+			result = append(result, &token{kind: tokenOperator, text: operator, position: i})
+			i += len(operator)
+		}
+	}
+	result = append(result, &token{kind: tokenEOF, position: len(text)})
+	return result, nil
+}
+
+//readString reads quoted string literal starting at position, backslash escapes the next character
+func readString(text string, position int) (string, int, error) {
+	quote := text[position]
+	builder := strings.Builder{}
+	for i := position + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) {
+				i++
+				builder.WriteByte(text[i])
+			}
+		case quote:
+			return builder.String(), i + 1, nil
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %v", position)
+}
@@ -0,0 +1,249 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type node interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(vars map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+//variableNode represents dotted variable path, missing variable evaluates to nil
+type variableNode struct {
+	path []string
+}
+
+func (n *variableNode) eval(vars map[string]interface{}) (interface{}, error) {
+	var value interface{} = vars
+	for _, key := range n.path {
+		aMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		if value, ok = aMap[key]; !ok {
+			value = lookup(aMap, key)
+		}
+	}
+	return value, nil
+}
+
+//lookup returns case insensitive map value
+func lookup(aMap map[string]interface{}, key string) interface{} {
+	for k, v := range aMap {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+type unaryNode struct {
+	operator string
+	operand  node
+}
+
+func (n *unaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.operator == "!" {
+		return !Truthy(value), nil
+	}
+	number, ok := asNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("unable to negate %v", value)
+	}
+	return -number, nil
+}
+
+type binaryNode struct {
+	operator    string
+	left, right node
+}
+
+func (n *binaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator { //short circuit
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.operator {
+	case "&&", "||":
+		return Truthy(right), nil
+	case "==":
+		return equals(left, right), nil
+	case "!=":
+		return !equals(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.operator, left, right)
+	}
+	return arithmetic(n.operator, left, right)
+}
+
+type callNode struct {
+	name string
+	fn   *function
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	result, err := n.fn.handler(args)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", n.name, err)
+	}
+	return result, nil
+}
+
+//Truthy returns boolean value: false, nil, zero number, empty string and empty collection are false
+func Truthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	switch actual := value.(type) {
+	case bool:
+		return actual
+	case string:
+		return actual != ""
+	}
+	if number, ok := asNumber(value); ok {
+		return number != 0
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return reflect.ValueOf(value).Len() > 0
+	}
+	return true
+}
+
+func asNumber(value interface{}) (float64, bool) {
+	switch actual := value.(type) {
+	case float64:
+		return actual, true
+	case float32:
+		return float64(actual), true
+	case int:
+		return float64(actual), true
+	case int32:
+		return float64(actual), true
+	case int64:
+		return float64(actual), true
+	case uint64:
+		return float64(actual), true
+	case json.Number:
+		number, err := actual.Float64()
+		return number, err == nil
+	case string:
+		number, err := strconv.ParseFloat(actual, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func equals(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if leftNumber, ok := asNumber(left); ok {
+		if rightNumber, ok := asNumber(right); ok {
+			return leftNumber == rightNumber
+		}
+	}
+	if leftBool, ok := left.(bool); ok {
+		return leftBool == Truthy(right)
+	}
+	return fmt.Sprintf("%v", left) == fmt.Sprintf("%v", right)
+}
+
+//compare compares numbers or strings, comparison with missing value is false
+func compare(operator string, left, right interface{}) (bool, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+	var result int
+	leftNumber, isLeftNumber := asNumber(left)
+	rightNumber, isRightNumber := asNumber(right)
+	if isLeftNumber && isRightNumber {
+		switch {
+		case leftNumber < rightNumber:
+			result = -1
+		case leftNumber > rightNumber:
+			result = 1
+		}
+	} else {
+		leftText, isLeftText := left.(string)
+		rightText, isRightText := right.(string)
+		if !isLeftText || !isRightText {
+			return false, fmt.Errorf("unable to compare %v %v %v", left, operator, right)
+		}
+		result = strings.Compare(leftText, rightText)
+	}
+	switch operator {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	}
+	return result >= 0, nil
+}
+
+func arithmetic(operator string, left, right interface{}) (interface{}, error) {
+	leftNumber, isLeftNumber := asNumber(left)
+	rightNumber, isRightNumber := asNumber(right)
+	if !isLeftNumber || !isRightNumber {
+		if operator == "+" {
+			return fmt.Sprintf("%v%v", left, right), nil
+		}
+		return nil, fmt.Errorf("unable to evaluate %v %v %v", left, operator, right)
+	}
+	switch operator {
+	case "+":
+		return leftNumber + rightNumber, nil
+	case "-":
+		return leftNumber - rightNumber, nil
+	case "*":
+		return leftNumber * rightNumber, nil
+	}
+	if rightNumber == 0 || (operator == "%" && int64(rightNumber) == 0) {
+		return nil, fmt.Errorf("division by zero: %v %v %v", left, operator, right)
+	}
+	if operator == "%" {
+		return float64(int64(leftNumber) % int64(rightNumber)), nil
+	}
+	return leftNumber / rightNumber, nil
+}
@@ -0,0 +1,166 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	tokens    []*token
+	index     int
+	variables map[string]bool
+}
+
+func (p *parser) peek() *token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() *token {
+	result := p.tokens[p.index]
+	if result.kind != tokenEOF {
+		p.index++
+	}
+	return result
+}
+
+func (p *parser) isOperator(candidates ...string) (string, bool) {
+	current := p.peek()
+	if current.kind != tokenOperator {
+		return "", false
+	}
+	for _, candidate := range candidates {
+		if current.text == candidate {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+//binary parses left associative binary operators of the same precedence level
+func (p *parser) binary(operand func() (node, error), operators ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.isOperator(operators...)
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *parser) or() (node, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *parser) and() (node, error) {
+	return p.binary(p.equality, "&&")
+}
+
+func (p *parser) equality() (node, error) {
+	return p.binary(p.comparison, "==", "!=")
+}
+
+func (p *parser) comparison() (node, error) {
+	return p.binary(p.additive, "<", "<=", ">", ">=")
+}
+
+func (p *parser) additive() (node, error) {
+	return p.binary(p.multiplicative, "+", "-")
+}
+
+func (p *parser) multiplicative() (node, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+func (p *parser) unary() (node, error) {
+	if operator, ok := p.isOperator("!", "-"); ok {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{operator: operator, operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	current := p.next()
+	switch current.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(current.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %v", current.text, current.position)
+		}
+		return &literalNode{value: value}, nil
+	case tokenString:
+		return &literalNode{value: current.text}, nil
+	case tokenLeftParen:
+		result, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, fmt.Errorf("expected ')' at position %v", closing.position)
+		}
+		return result, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLeftParen {
+			return p.call(current)
+		}
+		switch current.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{}, nil
+		}
+		path := strings.Split(current.text, ".")
+		p.variables[path[0]] = true
+		return &variableNode{path: path}, nil
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %v", current.text, current.position)
+}
+
+func (p *parser) call(name *token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %v at position %v", name.text, name.position)
+	}
+	p.next()
+	result := &callNode{name: name.text, fn: fn}
+	if p.peek().kind == tokenRightParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			result.args = append(result.args, arg)
+			separator := p.next()
+			if separator.kind == tokenRightParen {
+				break
+			}
+			if separator.kind != tokenComma {
+				return nil, fmt.Errorf("expected ',' or ')' at position %v", separator.position)
+			}
+		}
+	}
+	if fn.arity >= 0 && len(result.args) != fn.arity {
+		return nil, fmt.Errorf("function %v expects %v argument(s), but had %v", name.text, fn.arity, len(result.args))
+	}
+	return result, nil
+}
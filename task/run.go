@@ -40,6 +40,14 @@ func canRun(ctx context.Context, when *When, registry Registry) (bool, error) {
 	if when == nil {
 		return true, nil
 	}
+	if when.Expr != "" {
+		if ok, err := when.Eval(); err != nil || !ok {
+			return false, err
+		}
+		if when.Exists == "" {
+			return true, nil
+		}
+	}
 	if when.Exists != "" {
 		exists, err := Run(ctx, registry, &Action{
 			Action: shared.ActionTableExists,
@@ -1,7 +1,131 @@
 package task
 
+import (
+	"encoding/json"
+	"github.com/viant/bqtail/base"
+	"github.com/viant/bqtail/task/expr"
+	"github.com/viant/toolbox/data"
+)
+
 //When conditional action exection
 type When struct {
 	//Exists if specified action would run only when table exists
 	Exists string `json:",omitempty"`
+	//Expr if specified action would run only when expression evaluates to true, i.e. OutputRows > 0 && hour(Source.Time) == 23
+	Expr string `json:",omitempty"`
+	//Vars expression variables captured from the process expander and the parent job statistics
+	Vars       map[string]interface{} `json:",omitempty"`
+	expression *expr.Expression
+}
+
+//Init parses and validates expression
+func (w *When) Init() error {
+	if w == nil || w.Expr == "" {
+		return nil
+	}
+	var err error
+	w.expression, err = expr.Parse(w.Expr)
+	return err
+}
+
+//Expand returns a clone with expression variables captured from the expander, only variables referenced by expression are captured
+func (w *When) Expand(expander data.Map) *When {
+	if w == nil || w.Expr == "" {
+		return w
+	}
+	result := w.clone()
+	if result.Init() != nil {
+		return result
+	}
+	captured := map[string]interface{}{}
+	for _, name := range result.expression.Variables() {
+		if value, ok := expander[name]; ok {
+			captured[name] = value
+		}
+	}
+	//normalise values, so that expression evaluates the same way before and after async action persistence
+	if encoded, err := json.Marshal(captured); err == nil {
+		_ = json.Unmarshal(encoded, &captured)
+	}
+	for k, v := range captured {
+		result.Vars[k] = v
+	}
+	return result
+}
+
+//WithJob returns a clone with the parent job statistics variables
+func (w *When) WithJob(job *base.Job, jobError error) *When {
+	if w == nil || w.Expr == "" {
+		return w
+	}
+	result := w.clone()
+	for k, v := range jobVars(job, jobError) {
+		result.Vars[k] = v
+	}
+	return result
+}
+
+//Eval evaluates expression, when without expression evaluates to true
+func (w *When) Eval() (bool, error) {
+	if w == nil || w.Expr == "" {
+		return true, nil
+	}
+	if w.expression == nil {
+		if err := w.Init(); err != nil {
+			return false, err
+		}
+	}
+	return w.expression.Bool(w.Vars)
+}
+
+func (w *When) clone() *When {
+	result := &When{Exists: w.Exists, Expr: w.Expr, expression: w.expression, Vars: make(map[string]interface{})}
+	for k, v := range w.Vars {
+		result.Vars[k] = v
+	}
+	return result
+}
+
+//jobVars returns job statistics variables
+func jobVars(job *base.Job, jobError error) map[string]interface{} {
+	result := map[string]interface{}{
+		"Error": "",
+	}
+	if jobError != nil {
+		result["Error"] = jobError.Error()
+	}
+	if job == nil {
+		return result
+	}
+	result["JobID"] = job.JobID()
+	if job.Configuration != nil {
+		result["JobType"] = job.Configuration.JobType
+	}
+	stats := job.Statistics
+	if stats == nil {
+		return result
+	}
+	result["TotalSlotMs"] = stats.TotalSlotMs
+	result["TotalBytesProcessed"] = stats.TotalBytesProcessed
+	if load := stats.Load; load != nil {
+		result["OutputRows"] = load.OutputRows
+		result["OutputBytes"] = load.OutputBytes
+		result["BadRecords"] = load.BadRecords
+		result["InputFiles"] = load.InputFiles
+		result["InputFileBytes"] = load.InputFileBytes
+	}
+	if query := stats.Query; query != nil {
+		result["NumDmlAffectedRows"] = query.NumDmlAffectedRows
+		result["TotalBytesBilled"] = query.TotalBytesBilled
+		result["TotalBytesProcessed"] = query.TotalBytesProcessed
+		result["CacheHit"] = query.CacheHit
+	}
+	if extract := stats.Extract; extract != nil {
+		files := int64(0)
+		for _, count := range extract.DestinationUriFileCounts {
+			files += count
+		}
+		result["DestinationFiles"] = files
+	}
+	return result
 }